@@ -5,11 +5,15 @@ go 1.25
 require (
 	github.com/alecthomas/kong v1.15.0
 	github.com/iancoleman/strcase v0.3.0
+	github.com/itchyny/gojq v0.12.7
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/term v0.40.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/itchyny/timefmt-go v0.1.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
 )
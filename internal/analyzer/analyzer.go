@@ -3,13 +3,24 @@ package analyzer
 import (
 	"encoding/json" // Added for json.Number
 	"fmt"
+	"maps"
+	"math"
 	"regexp"
+	"slices"
 	"sort" // Added for sorting map keys
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/iancoleman/strcase"
 	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/errors"
+	"github.com/mcncl/gotyper/internal/formats"
+	"github.com/mcncl/gotyper/internal/inflect"
 	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/parser"
+	"github.com/mcncl/gotyper/internal/tags"
 )
 
 // DefaultRootName is the default name for the root struct if not specified.
@@ -17,7 +28,8 @@ const DefaultRootName = "RootType"
 
 // Regex patterns for special types
 var (
-	uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	numericKeyRegex = regexp.MustCompile(`^[0-9]+$`)
+	uuidRegex       = regexp.MustCompile(formats.UUID)
 
 	// Time format patterns (ordered by specificity - most specific first)
 	// ISO8601 and RFC3339 formats
@@ -56,71 +68,186 @@ var (
 	// Unix timestamps (kept as separate patterns for potential configuration options)
 	unixTimestampRegex = regexp.MustCompile(`^1[0-9]{9}$`)  // Unix timestamp (seconds since 1970)
 	unixMilliRegex     = regexp.MustCompile(`^1[0-9]{12}$`) // Unix timestamp in milliseconds
-)
 
-// Analyzer analyzes JSON and determines Go types and struct definitions
+	// Numbers with a currency symbol, thousands separators or a percent sign, e.g. "$1,200.00" or "-12.5%"
+	formattedNumberRegex = regexp.MustCompile(`^(?:[-+]?[$€£¥]?|[$€£¥][-+])(?:\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?%?$`)
+)
 
+// Analyzer analyzes JSON and determines Go types and struct definitions.
+// An Analyzer can be reused for many documents: each Analyze call starts from
+// fresh state, and concurrent calls are serialized.
 type Analyzer struct {
+	// mu guards the per-call state below while Analyze runs
+	mu sync.Mutex
 	// structNames tracks generated struct names to avoid collisions
 	structNames map[string]int
 	// analysisResult holds discovered structs and imports
 	analysisResult models.AnalysisResult
+	// structBuckets indexes the discovered structs by fingerprint, so finding an
+	// equivalent struct only compares structs with the same shape
+	structBuckets map[uint64][]models.StructDef
+	// path holds the JSON keys leading from the root to the object being analyzed.
+	// Array elements and map values add no key.
+	path []string
+	// jsonPath is path as JSONPath segments (.key or ['key']), with a [*] for each
+	// array whose elements are being analyzed
+	jsonPath []string
+	// excludePatterns are the compiled json_tags.exclude_fields globs
+	excludePatterns []*regexp.Regexp
+	// keys holds every object key seen, to find configuration entries matching none
+	keys map[string]struct{}
+	// fallbackNames holds the FieldN names given to keys with nothing to name a field after
+	fallbackNames map[string]string
+	// usedDirectives holds the paths of the sidecar field directives applied, to find
+	// entries matching no field
+	usedDirectives map[string]bool
 	// config holds configuration settings for analysis
 	config *config.Config
 }
 
 // NewAnalyzer creates a new Analyzer instance.
 func NewAnalyzer() *Analyzer {
-	return &Analyzer{
-		structNames: make(map[string]int),
-		analysisResult: models.AnalysisResult{
-			Structs: make([]models.StructDef, 0),
-			Imports: make(map[string]struct{}),
-		},
-		config: config.NewConfig(), // Use default config if none provided
-	}
+	return NewAnalyzerWithConfig(config.NewConfig()) // Use default config if none provided
 }
 
 // NewAnalyzerWithConfig creates a new Analyzer instance with custom configuration.
 func NewAnalyzerWithConfig(cfg *config.Config) *Analyzer {
-	return &Analyzer{
-		structNames: make(map[string]int),
-		analysisResult: models.AnalysisResult{
-			Structs: make([]models.StructDef, 0),
-			Imports: make(map[string]struct{}),
-		},
-		config: cfg,
+	a := &Analyzer{config: cfg}
+	a.reset()
+	return a
+}
+
+// Reset discards the structs, names and imports found by the last Analyze call.
+// Analyze resets on its own, so this only releases that state early.
+func (a *Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reset()
+}
+
+// reset replaces the per-call state. It allocates new maps and slices rather than
+// clearing them, since an earlier result still refers to the old ones.
+func (a *Analyzer) reset() {
+	a.structNames = make(map[string]int)
+	a.structBuckets = make(map[uint64][]models.StructDef)
+	a.path = nil
+	a.jsonPath = nil
+	a.keys = make(map[string]struct{})
+	a.fallbackNames = make(map[string]string)
+	a.usedDirectives = make(map[string]bool)
+	a.excludePatterns = make([]*regexp.Regexp, len(a.config.JSONTags.ExcludeFields))
+	for i, pattern := range a.config.JSONTags.ExcludeFields {
+		a.excludePatterns[i] = fieldGlobRegexp(pattern)
+	}
+	a.analysisResult = models.AnalysisResult{
+		Structs: make([]models.StructDef, 0),
+		Imports: make(map[string]struct{}),
 	}
 }
 
-// Analyze processes JSON representation and returns struct definitions and imports
+// Analyze processes JSON representation and returns struct definitions and imports.
+// The result doesn't depend on earlier calls, and later calls don't modify it.
 func (a *Analyzer) Analyze(ir models.IntermediateRepresentation, rootStructName string) (models.AnalysisResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reset()
+	for _, key := range ir.DuplicateKeys {
+		a.warn(models.WarningDuplicateKey, key, fmt.Sprintf("duplicate JSON key %s, using the last value", key))
+	}
+	for _, path := range ir.NonStandardNumbers {
+		where := path
+		if where == "" {
+			where = "the root"
+		}
+		a.warn(models.WarningNonStandardNumber, path, fmt.Sprintf("%s is NaN or Infinity, typed as float64; encoding/json rejects these tokens, so decoding the JSON needs custom unmarshaling", where))
+	}
+
+	result, err := a.analyze(ir, rootStructName)
+	if err != nil {
+		return models.AnalysisResult{}, err
+	}
+	a.inlineFields(&result)
+
+	keys := make([]string, 0, len(a.keys))
+	for key := range a.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, unmatched := range a.config.UnmatchedEntries(keys) {
+		result.Warnings = append(result.Warnings, models.Warning{Type: models.WarningUnmatchedConfig, Message: unmatched})
+	}
+	paths := make([]string, 0, len(a.config.FieldDirectives))
+	for path := range a.config.FieldDirectives {
+		if !a.usedDirectives[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		result.Warnings = append(result.Warnings, models.Warning{Type: models.WarningUnmatchedConfig, Path: path, Message: fmt.Sprintf("sidecar entry %q matched no field", path)})
+	}
+	return result, nil
+}
+
+// analyze builds the result for Analyze from freshly reset state
+func (a *Analyzer) analyze(ir models.IntermediateRepresentation, rootStructName string) (models.AnalysisResult, error) {
 	if rootStructName == "" {
 		rootStructName = DefaultRootName
 	}
 
-	// Ensure the root name is a valid Go identifier and PascalCase
-	rootStructName = a.generateUniqueStructName(a.getFieldName(rootStructName))
+	sanitized, err := SanitizeRootName(rootStructName)
+	if err != nil {
+		return models.AnalysisResult{}, err
+	}
+	if sanitized != rootStructName {
+		a.warn(models.WarningRootName, "", fmt.Sprintf("root name %q is not a valid exported Go identifier, using %q", rootStructName, sanitized))
+	}
+	rootStructName = a.generateUniqueStructName(sanitized)
 
 	var rootTypeInfo models.TypeInfo
-	var err error
 
 	if ir.Root == nil {
 		// Create a struct to wrap the null value
+		jsonKey, goName := a.rootPrimitiveField()
+		if !a.config.Output.RootOptional {
+			a.warn(models.WarningNullRoot, "", fmt.Sprintf("the root is null, so %s only wraps it in a %s field; output.root_optional (--root-optional) decodes null as a nil *%s instead", rootStructName, goName, rootStructName))
+		}
 		candidateStructDef := models.StructDef{
 			Name: rootStructName,
 			Fields: []models.FieldInfo{
 				{
-					JSONKey: "value",
-					GoName:  "Value",
+					JSONKey: jsonKey,
+					GoName:  goName,
 					GoType:  models.TypeInfo{Kind: models.Interface, Name: "interface{}", IsPointer: true},
-					JSONTag: "`json:\"value,omitempty\"`",
+					JSONTag: fmt.Sprintf("`json:\"%s,omitempty\"`", jsonKey),
 				},
 			},
 			IsRoot: true,
 		}
-		a.analysisResult.Structs = append(a.analysisResult.Structs, candidateStructDef)
+		a.addStruct(candidateStructDef)
 		rootTypeInfo = models.TypeInfo{Kind: models.Struct, Name: rootStructName, StructName: rootStructName}
+	} else if ir.RootIsArray && a.config.Output.WrapRootArrayAs != "" {
+		// Elements are named after the wrapper field, which holds the slice
+		jsonKey, goName := wrapperField(a.getFieldName(a.config.Output.WrapRootArrayAs))
+		sliceType, err := a.analyzeNode(ir.Root, goName, true, false)
+		if err != nil {
+			return models.AnalysisResult{}, fmt.Errorf("failed to analyze root node: %w", err)
+		}
+		sliceType.IsPointer = false
+		for i := range a.analysisResult.Structs {
+			a.analysisResult.Structs[i].IsRoot = false
+		}
+		a.addStruct(models.StructDef{
+			Name: rootStructName,
+			Fields: []models.FieldInfo{
+				{JSONKey: jsonKey, GoName: goName, GoType: sliceType, JSONTag: fmt.Sprintf("`json:\"%s\"`", jsonKey)},
+			},
+			IsRoot:     true,
+			WrapsArray: true,
+		})
+		a.analysisResult.Structs = models.DiscoveryOrder(a.analysisResult.Structs, rootStructName)
+		a.analysisResult.Root = &models.TypeInfo{Kind: models.Struct, Name: rootStructName, StructName: rootStructName}
+		return a.analysisResult, nil
 	} else {
 		// For the root node, isArrayElement is false because it's not an element within an array
 		rootTypeInfo, err = a.analyzeNode(ir.Root, rootStructName, true, false) // true for isRootNode, false for isArrayElement
@@ -128,22 +255,35 @@ func (a *Analyzer) Analyze(ir models.IntermediateRepresentation, rootStructName
 			return models.AnalysisResult{}, fmt.Errorf("failed to analyze root node: %w", err)
 		}
 
-		// Handle primitive values at the root level by wrapping them in a struct
+		// Handle primitive values at the root level with a named type when requested
+		if !ir.RootIsArray && rootTypeInfo.Kind != models.Struct && a.config.Output.RootPrimitiveAlias {
+			a.analysisResult.NamedTypes = append(a.analysisResult.NamedTypes, models.NamedType{
+				Name: rootStructName,
+				Type: rootTypeInfo,
+				// Types with their own JSON methods, like time.Time, must be aliased to keep them
+				IsAlias: rootTypeInfo.Kind == models.Time,
+			})
+			a.analysisResult.Root = &models.TypeInfo{Kind: rootTypeInfo.Kind, Name: rootStructName}
+			return a.analysisResult, nil
+		}
+
+		// Otherwise wrap primitive values at the root level in a struct
 		if !ir.RootIsArray && rootTypeInfo.Kind != models.Struct {
 			// Create a struct to wrap the primitive value
+			jsonKey, goName := a.rootPrimitiveField()
 			candidateStructDef := models.StructDef{
 				Name: rootStructName,
 				Fields: []models.FieldInfo{
 					{
-						JSONKey: "value",
-						GoName:  "Value",
+						JSONKey: jsonKey,
+						GoName:  goName,
 						GoType:  rootTypeInfo,
-						JSONTag: "`json:\"value\"`",
+						JSONTag: fmt.Sprintf("`json:\"%s\"`", jsonKey),
 					},
 				},
 				IsRoot: true,
 			}
-			a.analysisResult.Structs = append(a.analysisResult.Structs, candidateStructDef)
+			a.addStruct(candidateStructDef)
 			rootTypeInfo = models.TypeInfo{Kind: models.Struct, Name: rootStructName, StructName: rootStructName}
 		}
 	}
@@ -166,13 +306,35 @@ func (a *Analyzer) Analyze(ir models.IntermediateRepresentation, rootStructName
 		}
 	}
 
+	a.analysisResult.Structs = models.DiscoveryOrder(a.analysisResult.Structs, rootTypeInfo.ReferencedStruct())
+	a.analysisResult.Root = &rootTypeInfo
+
 	return a.analysisResult, nil
 }
 
+// rootPrimitiveField returns the JSON key and Go field name used to wrap a primitive or null root.
+// The configured name is used as the Go field name, and its lower-cased first letter gives the key.
+func (a *Analyzer) rootPrimitiveField() (jsonKey, goName string) {
+	name := a.config.Output.RootPrimitiveField
+	if name == "" {
+		name = "Value"
+	}
+	jsonKey, _ = wrapperField(name)
+	return jsonKey, a.getFieldName(name)
+}
+
+// wrapperField returns the JSON key for a field the analyzer adds to wrap a root value,
+// the name with its first letter lower-cased, along with the name itself
+func wrapperField(name string) (jsonKey, goName string) {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:], name
+}
+
 // analyzeNode is the core recursive function that determines the TypeInfo for a given JSON node.
 // It also discovers and defines new structs as needed.
 // `suggestedName` is used when a new struct needs to be created from an object or array of objects.
-// `isRootNode` helps in naming the very first struct if the JSON root is an object.
+// `isRootNode` helps in naming the very first struct if the JSON root is an object,
+// or the element struct if the JSON root is an array.
 // `isArrayElement` indicates if this node is an element of an array (affects IsRoot flag).
 func (a *Analyzer) analyzeNode(node models.JSONValue, suggestedName string, isRootNode bool, isArrayElement bool) (models.TypeInfo, error) {
 	switch v := node.(type) {
@@ -187,12 +349,83 @@ func (a *Analyzer) analyzeNode(node models.JSONValue, suggestedName string, isRo
 	case models.JSONObject: // map[string]interface{}
 		return a.analyzeObject(v, suggestedName, isRootNode, isArrayElement)
 	case models.JSONArray: // []interface{}
-		return a.analyzeArray(v, suggestedName, isArrayElement)
+		return a.analyzeArray(v, suggestedName, isRootNode)
 	default:
 		return models.TypeInfo{}, fmt.Errorf("unexpected json value type: %T", v)
 	}
 }
 
+// formattedNumberType types a field value that is a number written with formatting,
+// such as "12.5%", "$1,200.00" or "1,024", with types.parse_formatted_numbers: int64
+// without a fractional part, otherwise float64. Plain digit strings such as "0150"
+// aren't formatted and stay strings. Only object fields are typed this way, since the
+// generated UnmarshalJSON converts fields, not array elements.
+func (a *Analyzer) formattedNumberType(val models.JSONValue) (models.TypeInfo, bool) {
+	s, ok := val.(string)
+	if !ok || !a.config.Types.ParseFormattedNumbers {
+		return models.TypeInfo{}, false
+	}
+	match := formattedNumberRegex.FindStringSubmatch(s)
+	if match == nil || !strings.ContainsAny(s, "$€£¥,%") {
+		return models.TypeInfo{}, false
+	}
+	if match[1] == "" {
+		return models.TypeInfo{Kind: models.Int, Name: "int64"}, true
+	}
+	return models.TypeInfo{Kind: models.Float, Name: "float64"}, true
+}
+
+// nestedJSONValue decodes the value of a types.nested_json_fields key when it is a
+// string holding a JSON object or array, so the decoded value is typed instead of the
+// string. A matching string holding anything else stays a string, with a warning.
+func (a *Analyzer) nestedJSONValue(key string, val models.JSONValue) (models.JSONValue, bool) {
+	s, ok := val.(string)
+	if !ok || !a.config.IsNestedJSONField(key) {
+		return val, false
+	}
+	ir, err := parser.ParseString(s)
+	if err == nil {
+		switch ir.Root.(type) {
+		case models.JSONObject, models.JSONArray:
+			return ir.Root, true
+		}
+	}
+	a.warn(models.WarningNestedJSON, a.pathTo(key), fmt.Sprintf("%s matches types.nested_json_fields but doesn't hold a JSON object or array; typed as a string", key))
+	return val, false
+}
+
+// decodeNestedJSON returns objects with the values of their types.nested_json_fields
+// keys decoded, copying only the objects that change, and the keys decoded in any
+func (a *Analyzer) decodeNestedJSON(objects []models.JSONObject) ([]models.JSONObject, map[string]bool) {
+	if len(a.config.Types.NestedJSONFields) == 0 {
+		return objects, nil
+	}
+	nested := make(map[string]bool)
+	decoded := make([]models.JSONObject, len(objects))
+	for i, obj := range objects {
+		decoded[i] = obj
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		copied := false
+		for _, key := range keys {
+			value, ok := a.nestedJSONValue(key, obj[key])
+			if !ok {
+				continue
+			}
+			if !copied {
+				decoded[i] = maps.Clone(obj)
+				copied = true
+			}
+			decoded[i][key] = value
+			nested[key] = true
+		}
+	}
+	return decoded, nested
+}
+
 func (a *Analyzer) analyzeString(s string) models.TypeInfo {
 	// Check for UUID pattern but use string type to avoid external dependency
 	if uuidRegex.MatchString(s) {
@@ -347,12 +580,18 @@ func (a *Analyzer) detectAmbiguousDate(s string) *models.TypeInfo {
 func (a *Analyzer) markAmbiguousDateUsed() {
 	if a.config.IsDateFormatDefault() {
 		a.analysisResult.UsedDefaultDateFormat = true
+		a.warn(models.WarningAmbiguousDate, a.pathTo(), "ambiguous date read as MM/DD/YYYY; set types.date_format to \"eu\" for DD/MM/YYYY")
 	}
 }
 
 func (a *Analyzer) analyzeNumber(num json.Number) models.TypeInfo {
 	numStr := string(num)
 
+	// NaN and Infinity, read by a parser allowing non-standard numbers
+	if numStr == "NaN" || strings.HasSuffix(numStr, "Infinity") {
+		return models.TypeInfo{Kind: models.Float, Name: "float64"}
+	}
+
 	// Check for Unix timestamps - common pattern in APIs
 	if unixTimestampRegex.MatchString(numStr) {
 		if a.config.Types.UnixTimestampsAsTime {
@@ -374,13 +613,23 @@ func (a *Analyzer) analyzeNumber(num json.Number) models.TypeInfo {
 	}
 
 	// Try to parse as integer first
-	if _, err := num.Int64(); err == nil {
+	_, err := num.Int64()
+	if err == nil {
 		// Use int64 for all integers - simpler and more consistent for JSON APIs
 		return models.TypeInfo{Kind: models.Int, Name: "int64"}
 	}
+	if a.config.Types.BigNumberType != "" {
+		isInteger := !strings.ContainsAny(numStr, ".eE")
+		if isInteger || needsBigFloat(numStr) {
+			return a.bigNumberType(isInteger)
+		}
+	}
 
 	// If it's not an int, it's a float - use float64 as standard
-	if _, err := num.Float64(); err == nil {
+	if f, err := num.Float64(); err == nil {
+		if a.config.Types.ScientificIntegers && strings.ContainsAny(numStr, "eE") && isInt64Value(f) {
+			return models.TypeInfo{Kind: models.Int, Name: "int64"}
+		}
 		return models.TypeInfo{Kind: models.Float, Name: "float64"}
 	}
 
@@ -388,11 +637,51 @@ func (a *Analyzer) analyzeNumber(num json.Number) models.TypeInfo {
 	return models.TypeInfo{Kind: models.Float, Name: "float64"}
 }
 
+// needsBigFloat reports whether a JSON number is out of float64's range or has more
+// significant digits than a float64 can hold and give back unchanged
+func needsBigFloat(numStr string) bool {
+	if _, err := json.Number(numStr).Float64(); err != nil {
+		return true
+	}
+	mantissa := numStr
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		mantissa = mantissa[:i]
+	}
+	digits := strings.Trim(strings.NewReplacer("-", "", ".", "").Replace(mantissa), "0")
+	return len(digits) > 17
+}
+
+// bigNumberType returns the types.big_number_type type for a number int64 or float64
+// can't hold. *big.Float is only decoded from JSON strings, so it comes with a warning.
+func (a *Analyzer) bigNumberType(isInteger bool) models.TypeInfo {
+	if a.config.Types.BigNumberType == config.BigNumberJSONNumber {
+		a.analysisResult.Imports["encoding/json"] = struct{}{}
+		return models.TypeInfo{Kind: models.Float, Name: "json.Number", Import: "encoding/json"}
+	}
+	a.analysisResult.Imports["math/big"] = struct{}{}
+	if isInteger {
+		return models.TypeInfo{Kind: models.Int, Name: "big.Int", IsPointer: true, Import: "math/big"}
+	}
+	a.warn(models.WarningBigNumber, a.pathTo(),
+		fmt.Sprintf("%s needs more range or precision than float64 has; *big.Float only decodes from JSON strings, so numbers need custom unmarshaling", a.pathTo()))
+	return models.TypeInfo{Kind: models.Float, Name: "big.Float", IsPointer: true, Import: "math/big"}
+}
+
+// isInt64Value reports whether f is a whole number within the range of int64
+func isInt64Value(f float64) bool {
+	return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+}
+
 func (a *Analyzer) analyzeObject(obj models.JSONObject, suggestedName string, isParentObject bool, isArrayElement bool) (models.TypeInfo, error) {
 	// Prepare the struct name for the candidate
 	structName := suggestedName
 	if !isParentObject { // If it's a nested object, convert its key to PascalCase
 		structName = a.getFieldName(suggestedName)
+
+		mapType, isMap, err := a.inferMap([]models.JSONObject{obj}, structName)
+		if err != nil || isMap {
+			return mapType, err
+		}
 	}
 
 	// Create a candidate struct definition with fields
@@ -409,14 +698,19 @@ func (a *Analyzer) analyzeObject(obj models.JSONObject, suggestedName string, is
 	sort.Strings(keys) // Sort keys alphabetically
 
 	for _, key := range keys {
+		a.keys[key] = struct{}{}
+		if a.excluded(key) {
+			continue
+		}
 		val := obj[key]
-		goFieldName := a.getFieldName(key)
+		goFieldName := a.fieldName(key)
 
 		// Check for custom type mapping first
 		if mapping, found := a.checkTypeMapping(key); found {
 			fieldTypeInfo := models.TypeInfo{
-				Kind: models.String, // Default to string, but this will be overridden
-				Name: mapping.Type,
+				Kind:   models.String, // Default to string, but this will be overridden
+				Name:   mapping.Type,
+				Import: mapping.Import,
 			}
 
 			// Add import if specified
@@ -449,11 +743,15 @@ func (a *Analyzer) analyzeObject(obj models.JSONObject, suggestedName string, is
 			continue
 		}
 
+		val, nestedJSON := a.nestedJSONValue(key, val)
+
 		// For nested structs, suggest a name based on the current struct name and field name
 		nestedStructSuggestedName := structName + goFieldName
 
 		// Pass isArrayElement=false for nested fields, as they're not direct array elements
+		leave := a.enter(key)
 		fieldTypeInfo, err := a.analyzeNode(val, nestedStructSuggestedName, false, false) // false for isRootNode, false for isArrayElement
+		leave()
 		if err != nil {
 			return models.TypeInfo{}, fmt.Errorf("failed to analyze field '%s' in object '%s': %w", key, structName, err)
 		}
@@ -468,38 +766,62 @@ func (a *Analyzer) analyzeObject(obj models.JSONObject, suggestedName string, is
 			fieldTypeInfo.IsPointer = true
 		}
 
+		numberType, formatted := a.formattedNumberType(val)
+		if formatted {
+			fieldTypeInfo = numberType
+		}
+		fieldTypeInfo, durationUnit := a.durationField(key, fieldTypeInfo)
+
 		// Generate enhanced tags
 		jsonTag, tags, comment := a.generateFieldTags(key, fieldTypeInfo, val)
 
 		// Add field to the candidate struct
 		candidateStructDef.Fields = append(candidateStructDef.Fields, models.FieldInfo{
-			JSONKey: key,
-			GoName:  goFieldName,
-			GoType:  fieldTypeInfo,
-			JSONTag: jsonTag,
-			Tags:    tags,
-			Comment: comment,
+			JSONKey:         key,
+			GoName:          goFieldName,
+			GoType:          fieldTypeInfo,
+			JSONTag:         jsonTag,
+			Tags:            tags,
+			Comment:         comment,
+			DurationUnit:    durationUnit,
+			FormattedNumber: formatted,
+			NestedJSON:      nestedJSON,
 		})
 	}
 
+	a.pluralizeSliceFields(candidateStructDef.Fields)
+
 	// Check if this struct definition already exists or add it as a new one
 	typeInfo := a.findOrAddStructDef(candidateStructDef, structName, isParentObject, isArrayElement)
 	return typeInfo, nil
 }
 
-func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName string, isArrayElement bool) (models.TypeInfo, error) {
+// analyzeArray determines the slice type of an array. `isRootArray` is true when the array
+// is the JSON root, in which case its element struct takes the (singular) root name as is.
+func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName string, isRootArray bool) (models.TypeInfo, error) {
 	if len(arr) == 0 {
 		// Empty array defaults to []interface{}
 		elementType := models.TypeInfo{Kind: models.Interface, Name: "interface{}", IsPointer: false}
 		return models.TypeInfo{Kind: models.Slice, Name: "[]interface{}", SliceElementType: &elementType, IsPointer: true}, nil
 	}
 
-	// Suggested name for elements of an array should be singularized form of the array's suggested name.
-	elementSuggestedName := singularize(a.getFieldName(suggestedElementName), a.config.Naming.CustomSingulars)
+	// An array of only nulls says nothing about its element type. Unlike an empty
+	// array that is worth pointing out, since the sample looks populated.
+	values := nonNullElements(arr)
+	if len(values) == 0 {
+		a.warn(models.WarningNullArray, a.pathTo(), fmt.Sprintf("every element of the %s array is null; typed as []interface{}", suggestedElementName))
+		elementType := models.TypeInfo{Kind: models.Interface, Name: "interface{}", IsPointer: false}
+		return models.TypeInfo{Kind: models.Slice, Name: "[]interface{}", SliceElementType: &elementType, IsPointer: true}, nil
+	}
+
+	if a.config.Types.PreserveNullFields {
+		if len(values) < len(arr) {
+			return a.analyzeNullableArray(values, suggestedElementName, isRootArray)
+		}
+	}
 
-	// Check if this is a root array (if the suggested name is already in structNames with count 1)
-	// For root arrays in tests like TestAnalyze_ArrayOfObjects, we want to preserve the exact name
-	isRootArray := a.structNames[elementSuggestedName] == 1
+	// Suggested name for elements of an array should be singularized form of the array's suggested name.
+	elementSuggestedName := inflect.Singularize(a.getFieldName(suggestedElementName), a.config.Naming.CustomSingulars)
 
 	// Special handling for arrays of objects - we'll try to merge them into a single struct type
 	// First, check if all elements are objects
@@ -517,13 +839,24 @@ func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName strin
 	// If all elements are objects, try to merge them into a single struct
 	if allObjects && len(objectElements) > 0 {
 		// Create a merged struct definition with fields from all objects
+		leave := a.enterElements()
+		if typeInfo, ok, err := a.analyzeUnion(objectElements, elementSuggestedName); ok || err != nil {
+			leave()
+			return typeInfo, err
+		}
+		if typeInfo, ok, err := a.analyzeShapes(objectElements, elementSuggestedName, isRootArray); ok || err != nil {
+			leave()
+			return typeInfo, err
+		}
 		mergedStructDef, err := a.createMergedStructDef(objectElements, elementSuggestedName)
 		if err != nil {
+			leave()
 			return models.TypeInfo{}, fmt.Errorf("failed to create merged struct definition: %w", err)
 		}
 
 		// Add the merged struct to our results
 		typeInfo := a.findOrAddStructDef(mergedStructDef, elementSuggestedName, isRootArray, true)
+		leave()
 
 		// For structs, prefer pointer elements in slices (common Go practice)
 		sliceName := "[]*" + typeInfo.Name
@@ -546,7 +879,9 @@ func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName strin
 		// For subsequent elements or non-root arrays, pass isRootNode=false
 		isRootElement := isRootArray && i == 0
 		// Always set isArrayElement=true for array elements
+		leave := a.enterElements()
 		typeInfo, err := a.analyzeNode(element, elementSuggestedName, isRootElement, true)
+		leave()
 		if err != nil {
 			return models.TypeInfo{}, fmt.Errorf("failed to analyze element %d of array '%s': %w", i, suggestedElementName, err)
 		}
@@ -618,6 +953,14 @@ func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName strin
 	}
 
 	// Heterogeneous array - default to []interface{}
+	var kinds []string
+	for _, info := range elementInfos {
+		if kind := typeDescription(info); !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	a.warn(models.WarningHeterogeneousArray, a.pathTo(),
+		fmt.Sprintf("the %s array mixes %s elements; typed as []interface{}", suggestedElementName, strings.Join(kinds, ", ")))
 	return models.TypeInfo{
 		Kind:             models.Slice,
 		Name:             "[]interface{}",
@@ -626,7 +969,450 @@ func (a *Analyzer) analyzeArray(arr models.JSONArray, suggestedElementName strin
 	}, nil
 }
 
+// analyzeUnion types an array of objects as a discriminated union when arrays.strategy
+// is "interface_union": every object must have a string types.discriminator key, with
+// at least two different values. The objects of each value are merged into a variant
+// struct, and the array becomes a named slice of an interface they all implement,
+// e.g. Shapes, a []Shape holding *CircleShape and *SquareShape values. It reports
+// false for arrays that aren't unions, which are merged as usual.
+func (a *Analyzer) analyzeUnion(objects []models.JSONObject, elementName string) (models.TypeInfo, bool, error) {
+	discriminator := a.config.Types.Discriminator
+	if a.config.Arrays.Strategy != config.ArrayStrategyInterfaceUnion || discriminator == "" {
+		return models.TypeInfo{}, false, nil
+	}
+
+	groups := make(map[string][]models.JSONObject)
+	for _, obj := range objects {
+		value, ok := obj[discriminator].(string)
+		if !ok || value == "" {
+			return models.TypeInfo{}, false, nil
+		}
+		groups[value] = append(groups[value], obj)
+	}
+	if len(groups) < 2 || a.tooManyVariants(len(groups), elementName, fmt.Sprintf("%q values", discriminator)) {
+		return models.TypeInfo{}, false, nil
+	}
+	values := make([]string, 0, len(groups))
+	for value := range groups {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	interfaceName := a.generateUniqueStructName(elementName)
+	union := &models.Union{Discriminator: discriminator}
+	for _, value := range values {
+		variantName, err := SanitizeRootName(a.getFieldName(value) + interfaceName)
+		if err != nil {
+			variantName = interfaceName + "Variant"
+		}
+		variantDef, err := a.createMergedStructDef(groups[value], variantName)
+		if err != nil {
+			return models.TypeInfo{}, false, fmt.Errorf("failed to create the %q variant of %s: %w", value, interfaceName, err)
+		}
+		variantType := a.findOrAddStructDef(variantDef, variantName, false, true)
+		union.Variants = append(union.Variants, models.UnionVariant{Value: value, Struct: variantType.Name})
+	}
+	union.SliceName = a.generateUniqueStructName(inflect.Pluralize(interfaceName))
+
+	// Variants of the same shape share a struct, which the comment names once
+	var structs []string
+	for _, variant := range union.Variants {
+		if !slices.Contains(structs, variant.Struct) {
+			structs = append(structs, variant.Struct)
+		}
+	}
+	a.analysisResult.NamedTypes = append(a.analysisResult.NamedTypes, models.NamedType{
+		Name:    interfaceName,
+		Type:    models.TypeInfo{Kind: models.Interface, Name: interfaceName},
+		Comment: fmt.Sprintf("%s is one of %s, chosen by the JSON %q key.", interfaceName, strings.Join(structs, ", "), discriminator),
+		Union:   union,
+	})
+
+	elementType := models.TypeInfo{Kind: models.Interface, Name: interfaceName}
+	return models.TypeInfo{
+		Kind:             models.Slice,
+		Name:             union.SliceName,
+		SliceElementType: &elementType,
+		IsPointer:        true,
+	}, true, nil
+}
+
+// analyzeShapes types a root array of objects with arrays.per_shape_structs, when its
+// objects don't all have the same keys: the objects of each key set are merged into a
+// struct of their own, named after a key only that shape has (EmailUser) or its
+// position (User2), and the array becomes a named []interface{} listing them. Shapes
+// are grouped in the order they first appear. It reports false for other arrays,
+// which are merged as usual.
+func (a *Analyzer) analyzeShapes(objects []models.JSONObject, elementName string, isRootArray bool) (models.TypeInfo, bool, error) {
+	if !a.config.Arrays.PerShapeStructs || !isRootArray {
+		return models.TypeInfo{}, false, nil
+	}
+
+	var shapes []string
+	groups := make(map[string][]models.JSONObject)
+	keySets := make(map[string][]string)
+	for _, obj := range objects {
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		shape := strings.Join(keys, "\x00")
+		if _, seen := groups[shape]; !seen {
+			shapes = append(shapes, shape)
+			keySets[shape] = keys
+		}
+		groups[shape] = append(groups[shape], obj)
+	}
+	if len(shapes) < 2 || a.tooManyVariants(len(shapes), elementName, "shapes") {
+		return models.TypeInfo{}, false, nil
+	}
+
+	// A key is representative of a shape when no other shape has it
+	shapesWithKey := make(map[string]int)
+	for _, shape := range shapes {
+		for _, key := range keySets[shape] {
+			shapesWithKey[key]++
+		}
+	}
+
+	var structs []string
+	for i, shape := range shapes {
+		name := fmt.Sprintf("%s%d", elementName, i+1)
+		for _, key := range keySets[shape] {
+			if shapesWithKey[key] == 1 {
+				if keyed, err := SanitizeRootName(a.getFieldName(key) + elementName); err == nil {
+					name = keyed
+				}
+				break
+			}
+		}
+		structDef, err := a.createMergedStructDef(groups[shape], name)
+		if err != nil {
+			return models.TypeInfo{}, false, fmt.Errorf("failed to create the %s struct: %w", name, err)
+		}
+		// findOrAddStructDef's structural hashing reuses a struct that already has the shape
+		structType := a.findOrAddStructDef(structDef, name, false, true)
+		if !slices.Contains(structs, structType.Name) {
+			structs = append(structs, structType.Name)
+		}
+	}
+
+	sliceName := a.generateUniqueStructName(inflect.Pluralize(elementName))
+	elementType := models.TypeInfo{Kind: models.Interface, Name: "interface{}"}
+	sliceType := models.TypeInfo{Kind: models.Slice, Name: sliceName, SliceElementType: &elementType}
+	a.analysisResult.NamedTypes = append(a.analysisResult.NamedTypes, models.NamedType{
+		Name:    sliceName,
+		Type:    models.TypeInfo{Kind: models.Slice, Name: "[]interface{}", SliceElementType: &elementType},
+		Comment: fmt.Sprintf("%s holds %s objects, a struct per shape. Elements decode as map[string]interface{}.", sliceName, joinNames(structs)),
+	})
+	sliceType.IsPointer = true
+	return sliceType, true, nil
+}
+
+// tooManyVariants reports whether an array of objects has more variants, described by
+// what, than arrays.max_union_members allows, warning that it is merged instead
+func (a *Analyzer) tooManyVariants(count int, elementName, what string) bool {
+	limit := a.config.Arrays.MaxUnionMembers
+	if limit == 0 || count <= limit {
+		return false
+	}
+	a.warn(models.WarningUnionMembers, a.pathTo(),
+		fmt.Sprintf("the %s array has %d %s, more than arrays.max_union_members (%d); merged into one struct", elementName, count, what, limit))
+	return true
+}
+
+// joinNames lists names as "A, B and C"
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// inferMap decides whether a nested object (seen once, or once per merged array element)
+// is a map rather than a struct, following types.infer_maps: it must have at least that
+// many distinct keys, and its values must be all objects, all arrays of one element
+// type, or all of one scalar type.
+// With types.id_keyed_as_map, an object whose keys all look like IDs and whose values
+// are all objects is a map of pointers regardless of its size.
+// Object values are merged into a single value struct.
+func (a *Analyzer) inferMap(objects []models.JSONObject, structName string) (models.TypeInfo, bool, error) {
+	threshold := a.config.Types.InferMaps
+	if threshold <= 0 && !a.config.Types.IDKeyedAsMap {
+		return models.TypeInfo{}, false, nil
+	}
+
+	keys := make(map[string]struct{})
+	var values []models.JSONValue
+	idKeyed := a.config.Types.IDKeyedAsMap
+	for _, obj := range objects {
+		for key, val := range obj {
+			keys[key] = struct{}{}
+			values = append(values, val)
+			idKeyed = idKeyed && isIDKey(key)
+		}
+	}
+	if _, ok := allObjects(values); !ok || len(keys) == 0 {
+		idKeyed = false
+	}
+	if !idKeyed && (threshold <= 0 || len(keys) < threshold) {
+		return models.TypeInfo{}, false, nil
+	}
+
+	valueName := inflect.Singularize(structName, a.config.Naming.CustomSingulars)
+	if valueName == structName {
+		valueName += "Value"
+	}
+
+	var valueType models.TypeInfo
+	if objectValues, ok := allObjects(values); ok {
+		// The values are met under every key, which JSONPath writes as .*
+		leave := a.enterSegment(".*")
+		merged, err := a.createMergedStructDef(objectValues, valueName)
+		if err != nil {
+			leave()
+			return models.TypeInfo{}, false, err
+		}
+		valueType = a.findOrAddStructDef(merged, valueName, false, false)
+		leave()
+		valueType.IsPointer = a.config.Types.MapValuePointers || idKeyed
+	} else if arrayValues, ok := allArrays(values); ok {
+		// {"mon": [1, 2], "tue": [3]} is a map[string][]int64: the elements of every
+		// value are analyzed as one array, whose element type must be known
+		var elements models.JSONArray
+		for _, arr := range arrayValues {
+			elements = append(elements, arr...)
+		}
+		leave := a.enterSegment(".*")
+		typeInfo, err := a.analyzeArray(elements, valueName, false)
+		leave()
+		if err != nil {
+			return models.TypeInfo{}, false, err
+		}
+		if typeInfo.Kind != models.Slice || untypedElement(typeInfo.SliceElementType) {
+			return models.TypeInfo{}, false, nil
+		}
+		valueType = typeInfo
+		valueType.IsPointer = false
+	} else {
+		for i, val := range values {
+			switch val.(type) {
+			case nil, models.JSONObject, models.JSONArray:
+				return models.TypeInfo{}, false, nil
+			}
+			typeInfo, err := a.analyzeNode(val, valueName, false, false)
+			if err != nil {
+				return models.TypeInfo{}, false, err
+			}
+			if i > 0 && !areTypeInfosEqual(&valueType, &typeInfo) {
+				return models.TypeInfo{}, false, nil
+			}
+			valueType = typeInfo
+		}
+	}
+
+	name := "map[string]" + valueType.Name
+	if valueType.IsPointer {
+		name = "map[string]*" + valueType.Name
+	}
+	return models.TypeInfo{Kind: models.Map, Name: name, MapValueType: &valueType}, true, nil
+}
+
+// isIDKey reports whether an object key looks like a record ID: a number or a UUID
+func isIDKey(key string) bool {
+	return numericKeyRegex.MatchString(key) || uuidRegex.MatchString(key)
+}
+
+// allObjects returns values as objects if every one of them is an object
+func allObjects(values []models.JSONValue) ([]models.JSONObject, bool) {
+	objects := make([]models.JSONObject, 0, len(values))
+	for _, val := range values {
+		obj, ok := val.(models.JSONObject)
+		if !ok {
+			return nil, false
+		}
+		objects = append(objects, obj)
+	}
+	return objects, true
+}
+
+// allArrays returns values as arrays if every one of them is an array
+func allArrays(values []models.JSONValue) ([]models.JSONArray, bool) {
+	arrays := make([]models.JSONArray, 0, len(values))
+	for _, val := range values {
+		arr, ok := val.(models.JSONArray)
+		if !ok {
+			return nil, false
+		}
+		arrays = append(arrays, arr)
+	}
+	return arrays, true
+}
+
+// enter adds a JSON key to the path while the value under it is analyzed. The returned
+// function removes it again.
+func (a *Analyzer) enter(key string) func() {
+	a.path = append(a.path, key)
+	depth := len(a.path)
+	leaveSegment := a.enterSegment(jsonPathSegment(key))
+	return func() {
+		a.path = a.path[:depth-1]
+		leaveSegment()
+	}
+}
+
+// enterElements records that the elements of an array are being analyzed, for
+// sourcePath; it adds nothing to the key path. The returned function undoes it.
+func (a *Analyzer) enterElements() func() {
+	return a.enterSegment("[*]")
+}
+
+// enterSegment appends a segment to jsonPath and returns a function removing it
+func (a *Analyzer) enterSegment(segment string) func() {
+	a.jsonPath = append(a.jsonPath, segment)
+	depth := len(a.jsonPath)
+	return func() { a.jsonPath = a.jsonPath[:depth-1] }
+}
+
+// sourcePath returns the JSONPath of the value being analyzed, e.g. $.orders[*].items
+func (a *Analyzer) sourcePath() string {
+	return "$" + strings.Join(a.jsonPath, "")
+}
+
+// jsonPathSegment writes a key as a JSONPath member: .key, or ['key'] for keys that
+// aren't identifiers
+func jsonPathSegment(key string) string {
+	if identifierKeyRegexp.MatchString(key) {
+		return "." + key
+	}
+	return "['" + strings.ReplaceAll(strings.ReplaceAll(key, `\`, `\\`), "'", `\'`) + "']"
+}
+
+var identifierKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// excluded reports whether the field under key in the object being analyzed is skipped
+// by its sidecar directive or matches a json_tags.exclude_fields pattern. Patterns containing a dot are matched against the
+// dotted path from the root, e.g. "user.debug_info"; others against the key alone.
+func (a *Analyzer) excluded(key string) bool {
+	if directive, ok := a.fieldDirective(key); ok && directive.Skip {
+		return true
+	}
+	if len(a.excludePatterns) == 0 {
+		return false
+	}
+	path := strings.Join(append(append([]string(nil), a.path...), key), ".")
+	for i, pattern := range a.excludePatterns {
+		if strings.Contains(a.config.JSONTags.ExcludeFields[i], ".") {
+			if pattern.MatchString(path) {
+				return true
+			}
+		} else if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldDirective returns the sidecar directive of the field under key in the object
+// being analyzed, looked up by its dotted path from the root
+func (a *Analyzer) fieldDirective(key string) (config.FieldDirective, bool) {
+	if len(a.config.FieldDirectives) == 0 {
+		return config.FieldDirective{}, false
+	}
+	path := a.pathTo(key)
+	directive, ok := a.config.FieldDirectives[path]
+	if ok {
+		a.usedDirectives[path] = true
+	}
+	return directive, ok
+}
+
+// fieldGlobRegexp compiles an exclude_fields glob, in which * matches any run of
+// characters (dots included) and ? a single character
+func fieldGlobRegexp(glob string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// warn records a warning for the user once, however often the same inference is repeated
+func (a *Analyzer) warn(kind, path, message string) {
+	warning := models.Warning{Type: kind, Path: path, Message: message}
+	for _, existing := range a.analysisResult.Warnings {
+		if existing == warning {
+			return
+		}
+	}
+	a.analysisResult.Warnings = append(a.analysisResult.Warnings, warning)
+}
+
+// pathTo returns the dotted JSON path of the value being analyzed, followed by keys
+func (a *Analyzer) pathTo(keys ...string) string {
+	return strings.Join(append(append([]string(nil), a.path...), keys...), ".")
+}
+
+// analyzeNullableArray types an array whose elements are partly null by the type of its
+// other elements, made a pointer so the nulls survive decoding (types.preserve_null_fields)
+func (a *Analyzer) analyzeNullableArray(values models.JSONArray, suggestedElementName string, isRootArray bool) (models.TypeInfo, error) {
+	typeInfo, err := a.analyzeArray(values, suggestedElementName, isRootArray)
+	if err != nil {
+		return models.TypeInfo{}, err
+	}
+
+	// Interface elements already hold null, and a nil slice encodes as null. Nested
+	// slices record their innermost element type, so check the name matches too.
+	elementType := typeInfo.SliceElementType
+	if elementType == nil || elementType.IsPointer || elementType.Kind == models.Interface ||
+		elementType.Kind == models.Slice || typeInfo.Name != "[]"+elementType.Name {
+		return typeInfo, nil
+	}
+	pointerElementInfo := *elementType
+	pointerElementInfo.IsPointer = true
+	typeInfo.SliceElementType = &pointerElementInfo
+	typeInfo.Name = "[]*" + elementType.Name
+	return typeInfo, nil
+}
+
+// nonNullElements returns the elements of arr that aren't JSON null
+func nonNullElements(arr models.JSONArray) models.JSONArray {
+	values := make(models.JSONArray, 0, len(arr))
+	for _, element := range arr {
+		if element != nil {
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+// SanitizeRootName turns a root struct name into an exported Go identifier. Characters
+// other than letters and digits separate words, each word's first letter is upper-cased
+// and the separators are dropped, so "my-type" becomes MyType. A name that would start
+// with a digit or a letter without an upper case is prefixed with "Type", so "2cool"
+// becomes Type2cool. Names without letters or digits are an error.
+func SanitizeRootName(name string) (string, error) {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+
+	sanitized := b.String()
+	if sanitized == "" {
+		return "", errors.NewAnalysisError(fmt.Sprintf("root name %q has no letters or digits to build a Go identifier from", name), nil)
+	}
+	if first, _ := utf8.DecodeRuneInString(sanitized); !unicode.IsUpper(first) {
+		sanitized = "Type" + sanitized
+	}
+	return sanitized, nil
+}
+
 // generateUniqueStructName ensures that the struct name is unique by appending a number if needed.
+// Names over naming.max_name_length are truncated after numbering, so the hash covers the number.
 func (a *Analyzer) generateUniqueStructName(baseName string) string {
 	name := baseName
 	count := a.structNames[baseName]
@@ -634,7 +1420,7 @@ func (a *Analyzer) generateUniqueStructName(baseName string) string {
 		name = fmt.Sprintf("%s%d", baseName, count)
 	}
 	a.structNames[baseName] = count + 1
-	return name
+	return a.config.LimitNameLength(name)
 }
 
 // jsonKeyToPascalCase converts a JSON key to a Go-style PascalCase identifier.
@@ -650,85 +1436,270 @@ func jsonKeyToPascalCase(jsonKey string) string {
 	return pascalCaseName
 }
 
-// getFieldName returns the Go field name for a JSON key using configuration
+// getFieldName returns the Go field name for a JSON key using configuration. Keys
+// with nothing to name a field after, such as "名前" with naming.ascii_only, are
+// numbered Field1, Field2, ... in the order they are met, the same key keeping its name.
 func (a *Analyzer) getFieldName(jsonKey string) string {
-	return a.config.GetFieldName(jsonKey)
+	if name := a.config.GetFieldName(jsonKey); name != "" {
+		return name
+	}
+	name, ok := a.fallbackNames[jsonKey]
+	if !ok {
+		name = fmt.Sprintf("Field%d", len(a.fallbackNames)+1)
+		a.fallbackNames[jsonKey] = name
+	}
+	return name
+}
+
+// fieldName returns the Go name of the field under key in the object being analyzed,
+// renamed by its sidecar directive or named by configuration
+func (a *Analyzer) fieldName(key string) string {
+	if directive, ok := a.fieldDirective(key); ok && directive.Rename != "" {
+		return directive.Rename
+	}
+	return a.getFieldName(key)
+}
+
+// inlineFields promotes the fields of nested objects matching types.inline_fields into
+// their parent structs. Promoted fields are tagged json:"-" and remember the object
+// they came from, for the generator's UnmarshalJSON and MarshalJSON to move them
+// through it. Nested structs left unreferenced are dropped.
+func (a *Analyzer) inlineFields(result *models.AnalysisResult) {
+	if len(a.config.Types.InlineFields) == 0 {
+		return
+	}
+	byName := make(map[string]models.StructDef, len(result.Structs))
+	for _, structDef := range result.Structs {
+		byName[structDef.Name] = structDef
+	}
+
+	inlined := make(map[string]bool)
+	for i := range result.Structs {
+		structDef := &result.Structs[i]
+		names := make(map[string]bool, len(structDef.Fields))
+		for _, field := range structDef.Fields {
+			names[field.GoName] = true
+		}
+
+		fields := make([]models.FieldInfo, 0, len(structDef.Fields))
+		for _, field := range structDef.Fields {
+			nested, ok := byName[field.GoType.StructName]
+			if field.GoType.Kind != models.Struct || !ok || nested.Name == structDef.Name || !a.config.IsInlineField(field.JSONKey) || hasInlinedFields(nested) {
+				fields = append(fields, field)
+				continue
+			}
+			inlined[nested.Name] = true
+			for _, inner := range nested.Fields {
+				tagValue, ok := tags.Lookup(inner.JSONTag, "json")
+				if !ok {
+					tagValue = inner.JSONKey
+				}
+				if tagValue == "-" {
+					continue
+				}
+				promoted := inner
+				if a.config.Types.InlinePrefix || names[inner.GoName] {
+					promoted.GoName = field.GoName + inner.GoName
+				}
+				names[promoted.GoName] = true
+				promoted.InlinedFrom = field.JSONKey
+				promoted.InlinedTag = tagValue
+				promoted.Optional = inner.Optional || field.Optional
+				promoted.JSONTag = tags.Build(append(tags.Parse(inner.JSONTag), tags.Tag{Key: "json", Value: "-"}), a.config.TagOrder())
+				promoted.Tags = make(map[string]string, len(inner.Tags))
+				for key, value := range inner.Tags {
+					promoted.Tags[key] = value
+				}
+				promoted.Tags["json"] = "-"
+				fields = append(fields, promoted)
+			}
+		}
+		structDef.Fields = fields
+	}
+
+	// An inlined struct may still be the type of a field that wasn't inlined
+	referenced := make(map[string]bool)
+	if result.Root != nil {
+		referenced[result.Root.ReferencedStruct()] = true
+	}
+	for _, structDef := range result.Structs {
+		for _, field := range structDef.Fields {
+			referenced[field.GoType.ReferencedStruct()] = true
+		}
+	}
+	structs := result.Structs[:0]
+	for _, structDef := range result.Structs {
+		if !inlined[structDef.Name] || referenced[structDef.Name] {
+			structs = append(structs, structDef)
+		}
+	}
+	result.Structs = structs
+}
+
+// hasInlinedFields reports whether a struct holds fields promoted out of a nested
+// object, which can't be promoted again
+func hasInlinedFields(structDef models.StructDef) bool {
+	for _, field := range structDef.Fields {
+		if field.InlinedFrom != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pluralizeSliceFields gives slice fields plural Go names when naming.pluralize_slice_fields
+// is set, so "child": [...] becomes Children with its json tag unchanged. Names from
+// naming.field_mappings are kept, as are plurals another field already uses.
+func (a *Analyzer) pluralizeSliceFields(fields []models.FieldInfo) {
+	if !a.config.Naming.PluralizeSliceFields {
+		return
+	}
+
+	taken := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		taken[field.GoName] = true
+	}
+	for i, field := range fields {
+		if field.GoType.Kind != models.Slice {
+			continue
+		}
+		if _, mapped := a.config.Naming.FieldMappings[field.JSONKey]; mapped {
+			continue
+		}
+		plural := pluralizeName(field.GoName, a.config.Naming.CustomSingulars)
+		if plural == field.GoName || taken[plural] {
+			continue
+		}
+		delete(taken, field.GoName)
+		taken[plural] = true
+		fields[i].GoName = plural
+	}
 }
 
-// checkTypeMapping checks if a field name matches any configured type mappings
-func (a *Analyzer) checkTypeMapping(fieldName string) (config.TypeMapping, bool) {
-	return a.config.FindTypeMapping(fieldName)
+// pluralizeName pluralizes the last word of a PascalCase name, unless it is already plural
+func pluralizeName(name string, customSingulars map[string]string) string {
+	start := 0
+	for i := len(name) - 1; i > 0; i-- {
+		if unicode.IsUpper(rune(name[i])) && (unicode.IsLower(rune(name[i-1])) || unicode.IsDigit(rune(name[i-1]))) {
+			start = i
+			break
+		}
+	}
+	word := name[start:]
+	if inflect.Singularize(word, customSingulars) != word {
+		return name
+	}
+	return name[:start] + inflect.Pluralize(word)
+}
+
+// durationField converts numeric fields matching types.duration_fields to time.Duration.
+// It returns the (possibly unchanged) type and the JSON unit, which is empty when no rule applies.
+func (a *Analyzer) durationField(key string, typeInfo models.TypeInfo) (models.TypeInfo, string) {
+	if typeInfo.Kind != models.Int && typeInfo.Kind != models.Float {
+		return typeInfo, ""
+	}
+	rule, found := a.config.FindDurationField(key)
+	if !found {
+		return typeInfo, ""
+	}
+
+	a.analysisResult.Imports["time"] = struct{}{}
+	return models.TypeInfo{Kind: models.Duration, Name: "time.Duration", IsPointer: typeInfo.IsPointer}, rule.Unit
+}
+
+// checkTypeMapping returns the type of the field under key given by its sidecar
+// directive or, failing that, the configured type mappings
+func (a *Analyzer) checkTypeMapping(key string) (config.TypeMapping, bool) {
+	if mapping, ok := a.directiveType(key); ok {
+		return mapping, true
+	}
+	return a.config.FindTypeMapping(key)
+}
+
+// directiveType returns the type the sidecar directive of the field under key gives it
+func (a *Analyzer) directiveType(key string) (config.TypeMapping, bool) {
+	if directive, ok := a.fieldDirective(key); ok && directive.Type != "" {
+		return config.TypeMapping{Pattern: a.pathTo(key), Type: directive.Type, Import: directive.Import}, true
+	}
+	return config.TypeMapping{}, false
 }
 
 // generateFieldTags creates tags for a field based on configuration
 func (a *Analyzer) generateFieldTags(jsonKey string, fieldTypeInfo models.TypeInfo, originalValue models.JSONValue) (string, map[string]string, string) {
-	tags := make(map[string]string)
+	fieldTags := make(map[string]string)
 	var comment string
 
 	// Generate JSON tag with custom options
-	jsonTag := a.generateJSONTag(jsonKey, fieldTypeInfo, originalValue)
-	tags["json"] = strings.Trim(jsonTag, "`")
-	tags["json"] = strings.TrimPrefix(tags["json"], "json:")
-	tags["json"] = strings.Trim(tags["json"], "\"")
+	fieldTags["json"] = a.generateJSONTag(jsonKey, fieldTypeInfo, originalValue)
+	if !tags.ValidJSONName(jsonKey) {
+		a.warn(models.WarningTagName, a.pathTo(jsonKey), fmt.Sprintf("encoding/json ignores the tag name %q because of the characters in it, so the field is matched by its Go name", jsonKey))
+	}
 
 	// Generate additional format tags
 	for _, format := range a.config.JSONTags.AdditionalTags {
 		switch format {
 		case "yaml":
-			tags["yaml"] = a.generateYAMLTag(jsonKey, fieldTypeInfo)
+			fieldTags["yaml"] = a.generateYAMLTag(jsonKey, fieldTypeInfo)
 		case "xml":
-			tags["xml"] = a.generateXMLTag(jsonKey, fieldTypeInfo)
+			fieldTags["xml"] = a.generateXMLTag(jsonKey, fieldTypeInfo)
 		}
 	}
 
+	// Viper lowercases config keys, and mapstructure matches them case-insensitively
+	if a.config.JSONTags.Mapstructure {
+		fieldTags["mapstructure"] = strings.ToLower(jsonKey)
+	}
+
 	// Check for custom tag options and comments
 	if tagOption, found := a.config.FindTagOption(jsonKey); found {
 		if tagOption.Options != "" {
 			if tagOption.Options == "-" {
 				// Exclude field from JSON serialization entirely
-				tags["json"] = "-"
+				fieldTags["json"] = "-"
 			} else {
 				// Override JSON tag with custom options
-				tags["json"] = jsonKey + "," + tagOption.Options
+				fieldTags["json"] = jsonKey + "," + tagOption.Options
 			}
 		}
 		if tagOption.Comment != "" {
 			comment = tagOption.Comment
 		}
 	}
+	if directive, ok := a.fieldDirective(jsonKey); ok && directive.Comment != "" {
+		comment = directive.Comment
+	}
 
 	// Add validation tag if configured
 	if validationRule, found := a.config.FindValidationRule(jsonKey); found {
-		tags["validate"] = validationRule.Tag
+		fieldTags["validate"] = validationRule.Tag
 	}
 
-	// Build final tag string
-	var tagParts []string
-	if jsonValue, ok := tags["json"]; ok && jsonValue != "-" {
-		tagParts = append(tagParts, fmt.Sprintf("json:\"%s\"", jsonValue))
-	} else if jsonValue == "-" {
-		tagParts = append(tagParts, "json:\"-\"")
-	}
+	return a.buildTags(fieldTags), fieldTags, comment
+}
 
+// buildTags writes the tag string for a field's tags, in the configured tag order
+func (a *Analyzer) buildTags(fieldTags map[string]string) string {
+	tagParts := []tags.Tag{{Key: "json", Value: fieldTags["json"]}}
 	for _, format := range a.config.JSONTags.AdditionalTags {
-		if value, ok := tags[format]; ok {
-			tagParts = append(tagParts, fmt.Sprintf("%s:\"%s\"", format, value))
+		if value, ok := fieldTags[format]; ok {
+			tagParts = append(tagParts, tags.Tag{Key: format, Value: value})
 		}
 	}
+	if value, ok := fieldTags["mapstructure"]; ok {
+		tagParts = append(tagParts, tags.Tag{Key: "mapstructure", Value: value})
+	}
 
-	// Add validation tag if present (tag value already includes the full tag format)
-	if validateTag, ok := tags["validate"]; ok {
-		tagParts = append(tagParts, validateTag)
+	// The validation rule's tag is written in full, e.g. validate:"required,email"
+	if validateTag, ok := fieldTags["validate"]; ok {
+		tagParts = append(tagParts, tags.Parse(validateTag)...)
 	}
 
-	finalTag := "`" + strings.Join(tagParts, " ") + "`"
-	return finalTag, tags, comment
+	return tags.Build(tagParts, a.config.TagOrder())
 }
 
-// generateJSONTag creates a JSON tag with proper omitempty handling
+// generateJSONTag creates the json tag value, e.g. "name,omitempty", with proper omitempty handling
 func (a *Analyzer) generateJSONTag(jsonKey string, fieldTypeInfo models.TypeInfo, originalValue models.JSONValue) string {
-	omitempty := a.determineOmitempty(originalValue, fieldTypeInfo)
-	return fmt.Sprintf("`json:\"%s%s\"`", jsonKey, omitempty)
+	return a.config.TagKey(jsonKey) + a.determineOmitempty(originalValue, fieldTypeInfo)
 }
 
 // generateYAMLTag creates a YAML tag
@@ -743,8 +1714,20 @@ func (a *Analyzer) generateXMLTag(jsonKey string, fieldTypeInfo models.TypeInfo)
 	return jsonKey
 }
 
-// determineOmitempty decides if ",omitempty" should be added to the JSON tag using config
+// determineOmitempty decides if ",omitempty" (or ",omitzero") should be added to the JSON tag using config
 func (a *Analyzer) determineOmitempty(originalValue models.JSONValue, typeInfo models.TypeInfo) string {
+	switch a.config.JSONTags.Omitempty {
+	case config.OmitemptyNone:
+		return ""
+	case config.OmitemptyOptionalOnly:
+		// Null values are optional; fields missing from merged objects get
+		// omitempty once presence is known, in withOmitempty
+		if typeInfo.Kind == models.Interface {
+			return ",omitempty"
+		}
+		return ""
+	}
+
 	if typeInfo.IsPointer && a.config.JSONTags.OmitemptyForPointers {
 		return ",omitempty"
 	}
@@ -758,236 +1741,15 @@ func (a *Analyzer) determineOmitempty(originalValue models.JSONValue, typeInfo m
 		return ",omitempty"
 	}
 
-	return ""
-}
-
-// singularize attempts to convert a plural name to a singular one.
-// Uses a dictionary of known singulars plus suffix-based rules for common patterns.
-// The customSingulars parameter allows users to provide additional mappings via config.
-func singularize(plural string, customSingulars map[string]string) string {
-	lowerPlural := strings.ToLower(plural)
-
-	// Check custom singulars first (user config takes precedence)
-	if customSingulars != nil {
-		if singular, ok := customSingulars[lowerPlural]; ok {
-			return preserveCase(plural, singular)
+	// omitempty never omits struct or array values, so prefer omitzero where the target Go supports it
+	if !typeInfo.IsPointer && a.config.JSONTags.PreferOmitzero && a.config.SupportsOmitzero() {
+		switch typeInfo.Kind {
+		case models.Struct, models.Time, models.UUID:
+			return ",omitzero"
 		}
 	}
 
-	// Check built-in dictionary
-	if singular, ok := knownSingulars[lowerPlural]; ok {
-		return preserveCase(plural, singular)
-	}
-
-	// Rule-based singularization (order matters - most specific first)
-
-	// Words ending in -ies (but not -eies like "keys")
-	if strings.HasSuffix(lowerPlural, "ies") && len(lowerPlural) > 4 {
-		// Check it's not a word like "series" or "species" (already in dictionary)
-		// Convert -ies to -y: companies -> company
-		return plural[:len(plural)-3] + "y"
-	}
-
-	// Words ending in -ves -> -f or -fe (leaves -> leaf, lives -> life)
-	if strings.HasSuffix(lowerPlural, "ves") && len(lowerPlural) > 4 {
-		base := plural[:len(plural)-3]
-		// Most -ves words become -f (leaf, wolf, half, etc.)
-		return base + "f"
-	}
-
-	// Words ending in -xes -> -x (boxes -> box, indexes -> index)
-	if strings.HasSuffix(lowerPlural, "xes") && len(lowerPlural) > 4 {
-		return plural[:len(plural)-2]
-	}
-
-	// Words ending in -ches -> -ch (matches -> match, branches -> branch)
-	if strings.HasSuffix(lowerPlural, "ches") && len(lowerPlural) > 5 {
-		return plural[:len(plural)-2]
-	}
-
-	// Words ending in -shes -> -sh (crashes -> crash, hashes -> hash)
-	if strings.HasSuffix(lowerPlural, "shes") && len(lowerPlural) > 5 {
-		return plural[:len(plural)-2]
-	}
-
-	// Words ending in -sses -> -ss (classes -> class, passes -> pass)
-	if strings.HasSuffix(lowerPlural, "sses") && len(lowerPlural) > 5 {
-		return plural[:len(plural)-2]
-	}
-
-	// Words ending in -oes -> -o (heroes -> hero, potatoes -> potato)
-	// But not all -oes words: some are just -os (photos, videos)
-	if strings.HasSuffix(lowerPlural, "oes") && len(lowerPlural) > 4 {
-		return plural[:len(plural)-2]
-	}
-
-	// Words ending in -ses (but not -sses which is handled above)
-	// This handles cases like "responses -> response", "databases -> database"
-	if strings.HasSuffix(lowerPlural, "ses") && len(lowerPlural) > 4 {
-		// Check if it's a word ending in -se (response, database, house)
-		// vs a word ending in -sis (analysis, basis) - those are in dictionary
-		return plural[:len(plural)-1]
-	}
-
-	// Avoid removing 's' from words that end in these patterns
-	if strings.HasSuffix(lowerPlural, "ss") || // class, pass, etc.
-		strings.HasSuffix(lowerPlural, "us") || // status, virus, etc.
-		strings.HasSuffix(lowerPlural, "is") || // analysis, basis, etc.
-		strings.HasSuffix(lowerPlural, "os") { // chaos, etc.
-		return plural
-	}
-
-	// Generic -s removal (users -> user, items -> item)
-	if strings.HasSuffix(lowerPlural, "s") && len(lowerPlural) > 1 {
-		return plural[:len(plural)-1]
-	}
-
-	return plural
-}
-
-// knownSingulars contains built-in plural to singular mappings for common words.
-var knownSingulars = map[string]string{
-	// Irregular plurals
-	"children":   "child",
-	"people":     "person",
-	"men":        "man",
-	"women":      "woman",
-	"teeth":      "tooth",
-	"feet":       "foot",
-	"mice":       "mouse",
-	"geese":      "goose",
-	"oxen":       "ox",
-	"indices":    "index",
-	"vertices":   "vertex",
-	"matrices":   "matrix",
-	"appendices": "appendix",
-
-	// Uncountable / same singular and plural
-	"series":      "series",
-	"species":     "species",
-	"news":        "news",
-	"data":        "data",
-	"media":       "media",
-	"metadata":    "metadata",
-	"info":        "info",
-	"information": "information",
-	"equipment":   "equipment",
-	"feedback":    "feedback",
-	"software":    "software",
-	"hardware":    "hardware",
-	"firmware":    "firmware",
-	"middleware":  "middleware",
-	"malware":     "malware",
-	"analytics":   "analytics",
-	"metrics":     "metrics",
-	"contents":    "contents",
-	"settings":    "settings",
-	"credentials": "credentials",
-	"permissions": "permission",
-
-	// Common API/programming terms that need explicit mapping
-	"statuses":       "status",
-	"status":         "status",
-	"analyses":       "analysis",
-	"analysis":       "analysis",
-	"bases":          "basis",
-	"basis":          "basis",
-	"crises":         "crisis",
-	"criteria":       "criterion",
-	"phenomena":      "phenomenon",
-	"schemas":        "schema",
-	"schemata":       "schema",
-	"aliases":        "alias",
-	"addresses":      "address",
-	"processes":      "process",
-	"classes":        "class",
-	"buses":          "bus",
-	"gases":          "gas",
-	"caches":         "cache",
-	"matches":        "match",
-	"batches":        "batch",
-	"patches":        "patch",
-	"watches":        "watch",
-	"switches":       "switch",
-	"dispatches":     "dispatch",
-	"searches":       "search",
-	"branches":       "branch",
-	"crashes":        "crash",
-	"flashes":        "flash",
-	"splashes":       "splash",
-	"meshes":         "mesh",
-	"hashes":         "hash",
-	"pushes":         "push",
-	"indexes":        "index",
-	"boxes":          "box",
-	"taxes":          "tax",
-	"fixes":          "fix",
-	"mixes":          "mix",
-	"prefixes":       "prefix",
-	"suffixes":       "suffix",
-	"proxies":        "proxy",
-	"replies":        "reply",
-	"queries":        "query",
-	"entries":        "entry",
-	"histories":      "history",
-	"factories":      "factory",
-	"repositories":   "repository",
-	"directories":    "directory",
-	"categories":     "category",
-	"properties":     "property",
-	"bodies":         "body",
-	"copies":         "copy",
-	"policies":       "policy",
-	"strategies":     "strategy",
-	"entities":       "entity",
-	"activities":     "activity",
-	"priorities":     "priority",
-	"capacities":     "capacity",
-	"velocities":     "velocity",
-	"identities":     "identity",
-	"authorities":    "authority",
-	"utilities":      "utility",
-	"facilities":     "facility",
-	"capabilities":   "capability",
-	"availabilities": "availability",
-	"dependencies":   "dependency",
-	"currencies":     "currency",
-	"frequencies":    "frequency",
-	"deliveries":     "delivery",
-	"discoveries":    "discovery",
-	"inventories":    "inventory",
-	"accessories":    "accessory",
-	"summaries":      "summary",
-	"libraries":      "library",
-	"binaries":       "binary",
-	"boundaries":     "boundary",
-	"memories":       "memory",
-	"registries":     "registry",
-	"keys":           "key",
-	"values":         "value",
-	"leaves":         "leaf",
-	"lives":          "life",
-	"selves":         "self",
-	"halves":         "half",
-	"wolves":         "wolf",
-	"knives":         "knife",
-	"wives":          "wife",
-	"shelves":        "shelf",
-	"calves":         "calf",
-	"loaves":         "loaf",
-	"thieves":        "thief",
-}
-
-// preserveCase applies the casing pattern from the original word to the result
-func preserveCase(original, result string) string {
-	if len(original) == 0 || len(result) == 0 {
-		return result
-	}
-	// If original starts with uppercase, capitalize result
-	if original[0] >= 'A' && original[0] <= 'Z' {
-		return strings.ToUpper(string(result[0])) + result[1:]
-	}
-	return result
+	return ""
 }
 
 // areTypeInfosEqual checks if two TypeInfo objects represent the same type.
@@ -1002,6 +1764,9 @@ func areTypeInfosEqual(t1, t2 *models.TypeInfo) bool {
 	if t1.Kind == models.Slice {
 		return areTypeInfosEqual(t1.SliceElementType, t2.SliceElementType)
 	}
+	if t1.Kind == models.Map {
+		return areTypeInfosEqual(t1.MapValueType, t2.MapValueType)
+	}
 	return true
 }
 
@@ -1026,24 +1791,67 @@ func areStructDefsEquivalent(s1, s2 *models.StructDef) bool {
 			return false // Field in s2 not found in s1 by JSONKey
 		}
 		// Compare critical aspects of FieldInfo
-		if f1.GoName != f2.GoName || f1.JSONTag != f2.JSONTag || !areTypeInfosEqual(&f1.GoType, &f2.GoType) {
+		if f1.GoName != f2.GoName || f1.JSONTag != f2.JSONTag || !areTypeInfosEqual(&f1.GoType, &f2.GoType) || f1.CodecSignature() != f2.CodecSignature() {
 			return false
 		}
 	}
 	return true
 }
 
+// combineArrays concatenates the arrays each key holds across objects, for keys found
+// in more than one object whose values are all arrays of objects or null. Arrays of
+// other values are left to the usual field merging and its arrays.merge_strategy.
+func combineArrays(objects []models.JSONObject) map[string]models.JSONArray {
+	combined := make(map[string]models.JSONArray)
+	counts := make(map[string]int)
+	mixed := make(map[string]bool)
+	for _, obj := range objects {
+		for key, val := range obj {
+			switch arr := val.(type) {
+			case nil:
+			case models.JSONArray:
+				combined[key] = append(combined[key], arr...)
+				counts[key]++
+				for _, element := range arr {
+					if _, isObject := element.(models.JSONObject); !isObject {
+						mixed[key] = true
+					}
+				}
+			default:
+				mixed[key] = true
+			}
+		}
+	}
+	for key := range combined {
+		if counts[key] < 2 || mixed[key] {
+			delete(combined, key)
+		}
+	}
+	return combined
+}
+
 // createMergedStructDef creates a struct definition that merges fields from multiple JSON objects.
 // This is particularly useful for array elements that may have slightly different fields.
 func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedName string) (models.StructDef, error) {
 	// Create a map to track all unique fields across all objects
 	allFields := make(map[string]models.FieldInfo)
+	// Index of the first object where each field holds a non-null non-object value or
+	// an object, so a type conflict can be resolved in favour of the type seen first
+	firstScalar := make(map[string]int)
+	firstObject := make(map[string]int)
+	var conflicts []string
+
+	// Strings holding encoded JSON merge like the objects and arrays they decode to
+	objects, nestedJSON := a.decodeNestedJSON(objects)
 
 	// Track nested object fields that need merging
 	nestedObjectFields := make(map[string][]models.JSONObject)
+	// Arrays under the same key are analyzed as one, so their object elements merge
+	// into one struct rather than a struct per object
+	combinedArrays := combineArrays(objects)
 
 	// Process each object and collect all unique fields
-	for _, obj := range objects {
+	for objIndex, obj := range objects {
 		// Extract keys and sort them for deterministic processing
 		keys := make([]string, 0, len(obj))
 		for k := range obj {
@@ -1053,11 +1861,39 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 
 		// Process each field in the object
 		for _, key := range keys {
+			a.keys[key] = struct{}{}
+			if a.excluded(key) {
+				continue
+			}
 			val := obj[key]
-			goFieldName := a.getFieldName(key)
+			goFieldName := a.fieldName(key)
 			// For nested structs, suggest a name based on the current struct name and field name
 			nestedStructSuggestedName := suggestedName + goFieldName
 
+			// A sidecar type replaces the analysis of every value of the field
+			if mapping, found := a.directiveType(key); found {
+				if mapping.Import != "" {
+					a.analysisResult.Imports[mapping.Import] = struct{}{}
+				}
+				if existing, exists := allFields[key]; exists {
+					if val == nil {
+						allFields[key] = a.withPointer(existing)
+					}
+					continue
+				}
+				fieldTypeInfo := models.TypeInfo{Kind: models.String, Name: mapping.Type, Import: mapping.Import, IsPointer: val == nil}
+				jsonTag, tags, comment := a.generateFieldTags(key, fieldTypeInfo, val)
+				allFields[key] = models.FieldInfo{
+					JSONKey: key,
+					GoName:  goFieldName,
+					GoType:  fieldTypeInfo,
+					JSONTag: jsonTag,
+					Tags:    tags,
+					Comment: comment,
+				}
+				continue
+			}
+
 			// Special handling for nested objects that might need merging
 			if nestedObj, isObject := val.(models.JSONObject); isObject {
 				// Add this nested object to our tracking map for later merging
@@ -1065,13 +1901,24 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 					nestedObjectFields[key] = make([]models.JSONObject, 0)
 				}
 				nestedObjectFields[key] = append(nestedObjectFields[key], nestedObj)
+				if _, exists := firstObject[key]; !exists {
+					firstObject[key] = objIndex
+				}
 
 				// We'll process this field after collecting all instances
 				continue
 			}
+			if combined, ok := combinedArrays[key]; ok && val != nil {
+				if _, analyzed := firstScalar[key]; analyzed {
+					continue
+				}
+				val = combined
+			}
 
 			// For non-object fields, process normally
+			leave := a.enter(key)
 			fieldTypeInfo, err := a.analyzeNode(val, nestedStructSuggestedName, false, false)
+			leave()
 			if err != nil {
 				return models.StructDef{}, fmt.Errorf("failed to analyze field '%s' in merged object: %w", key, err)
 			}
@@ -1086,17 +1933,42 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 				fieldTypeInfo.IsPointer = true
 			}
 
+			numberType, formatted := a.formattedNumberType(val)
+			if formatted {
+				fieldTypeInfo = numberType
+			}
+			fieldTypeInfo, durationUnit := a.durationField(key, fieldTypeInfo)
+
 			// Generate enhanced tags
 			jsonTag, tags, comment := a.generateFieldTags(key, fieldTypeInfo, val)
 
 			// Create field info
 			fieldInfo := models.FieldInfo{
-				JSONKey: key,
-				GoName:  goFieldName,
-				GoType:  fieldTypeInfo,
-				JSONTag: jsonTag,
-				Tags:    tags,
-				Comment: comment,
+				JSONKey:         key,
+				GoName:          goFieldName,
+				GoType:          fieldTypeInfo,
+				JSONTag:         jsonTag,
+				Tags:            tags,
+				Comment:         comment,
+				DurationUnit:    durationUnit,
+				FormattedNumber: formatted,
+			}
+
+			// Reconcile with the same field in earlier objects. Nulls never conflict,
+			// they only make the field a pointer.
+			if existing, exists := allFields[key]; exists {
+				_, hasValue := firstScalar[key]
+				switch {
+				case val == nil:
+					fieldInfo = a.withPointer(existing)
+				case !hasValue:
+					fieldInfo = a.withPointer(fieldInfo)
+				default:
+					fieldInfo = a.mergeFieldTypes(existing, fieldInfo, suggestedName, &conflicts)
+				}
+			}
+			if _, exists := firstScalar[key]; !exists && val != nil {
+				firstScalar[key] = objIndex
 			}
 
 			// Add to our map of all fields
@@ -1107,17 +1979,55 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 	// Now process all the nested object fields we collected
 	for key, nestedObjects := range nestedObjectFields {
 		if len(nestedObjects) > 0 {
-			goFieldName := a.getFieldName(key)
+			goFieldName := a.fieldName(key)
 			nestedStructSuggestedName := suggestedName + goFieldName
 
+			// The same key may also hold scalars or arrays in other objects. The nested
+			// struct is only generated if the object type wins the conflict.
+			if scalarIndex, hasScalar := firstScalar[key]; hasScalar {
+				objectFirst := firstObject[key] < scalarIndex
+				if a.config.Arrays.MergeStrategy != config.MergeFirst || !objectFirst {
+					objectField := allFields[key]
+					objectField.GoType = models.TypeInfo{Kind: models.Struct, Name: "object", StructName: "object"}
+					if objectFirst {
+						allFields[key] = a.mergeFieldTypes(objectField, allFields[key], suggestedName, &conflicts)
+					} else {
+						allFields[key] = a.mergeFieldTypes(allFields[key], objectField, suggestedName, &conflicts)
+					}
+					continue
+				}
+			}
+
+			leave := a.enter(key)
+			mapType, isMap, err := a.inferMap(nestedObjects, nestedStructSuggestedName)
+			if err != nil {
+				leave()
+				return models.StructDef{}, fmt.Errorf("failed to analyze nested field '%s' as a map: %w", key, err)
+			}
+			if isMap {
+				leave()
+				jsonTag, tags, comment := a.generateFieldTags(key, mapType, nil)
+				allFields[key] = models.FieldInfo{
+					JSONKey: key,
+					GoName:  goFieldName,
+					GoType:  mapType,
+					JSONTag: jsonTag,
+					Tags:    tags,
+					Comment: comment,
+				}
+				continue
+			}
+
 			// Create a merged struct for this nested field
 			mergedNestedStruct, err := a.createMergedStructDef(nestedObjects, nestedStructSuggestedName)
 			if err != nil {
+				leave()
 				return models.StructDef{}, fmt.Errorf("failed to create merged struct for nested field '%s': %w", key, err)
 			}
 
 			// Add the merged struct to our results
 			typeInfo := a.findOrAddStructDef(mergedNestedStruct, nestedStructSuggestedName, false, false)
+			leave()
 
 			// Make it a pointer since it's a nested object
 			typeInfo.IsPointer = true
@@ -1140,6 +2050,46 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 		}
 	}
 
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return models.StructDef{}, errors.NewAnalysisError(
+			fmt.Sprintf("conflicting types for merged fields: %s (arrays.merge_strategy is %q)", strings.Join(conflicts, "; "), config.MergeError),
+			errors.ErrMergeConflict,
+		)
+	}
+
+	// Fields missing from some objects are optional. With types.optional_threshold they
+	// must be missing from enough objects, and optional fields become pointers.
+	presence := make(map[string]int, len(allFields))
+	nullable := make(map[string]bool)
+	for _, obj := range objects {
+		for key, val := range obj {
+			presence[key]++
+			if val == nil {
+				nullable[key] = true
+			}
+		}
+	}
+	threshold := a.config.Types.OptionalThreshold
+	for key, field := range allFields {
+		switch field.GoType.Kind {
+		case models.Struct, models.Slice, models.Map:
+			field.NestedJSON = nestedJSON[key]
+		}
+		if threshold > 0 {
+			field.Optional = float64(presence[key])/float64(len(objects)) < threshold
+			if field.Optional {
+				field = a.withPointer(field)
+			}
+		} else {
+			field.Optional = presence[key] < len(objects)
+		}
+		if a.config.JSONTags.Omitempty == config.OmitemptyOptionalOnly && (field.Optional || nullable[key]) {
+			field = a.withOmitempty(field)
+		}
+		allFields[key] = field
+	}
+
 	// Convert the map of fields to a slice
 	fields := make([]models.FieldInfo, 0, len(allFields))
 	// Extract keys and sort them for deterministic field order
@@ -1153,6 +2103,7 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 	for _, key := range keys {
 		fields = append(fields, allFields[key])
 	}
+	a.pluralizeSliceFields(fields)
 
 	// Create the merged struct definition
 	return models.StructDef{
@@ -1162,6 +2113,186 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 	}, nil
 }
 
+// withPointer makes a merged field nullable because another object had null for it
+func (a *Analyzer) withPointer(field models.FieldInfo) models.FieldInfo {
+	if field.GoType.IsPointer {
+		return field
+	}
+	field.GoType.IsPointer = true
+	field.JSONTag, field.Tags, _ = a.generateFieldTags(field.JSONKey, field.GoType, nil)
+	return field
+}
+
+// withOmitempty adds omitempty to the field's json tag, unless a custom tag
+// option already set its options or excluded it
+func (a *Analyzer) withOmitempty(field models.FieldInfo) models.FieldInfo {
+	jsonTag := field.Tags["json"]
+	if jsonTag == "" || jsonTag == "-" || strings.Contains(jsonTag, ",") {
+		return field
+	}
+	fieldTags := make(map[string]string, len(field.Tags))
+	for k, v := range field.Tags {
+		fieldTags[k] = v
+	}
+	fieldTags["json"] = jsonTag + ",omitempty"
+	field.Tags = fieldTags
+	field.JSONTag = a.buildTags(fieldTags)
+	return field
+}
+
+// mergeFieldTypes reconciles a field whose type differs between merged objects.
+// Compatible types are combined (numbers widen to float64, empty arrays take the
+// other array's type); real conflicts are resolved by arrays.merge_strategy.
+// With the "error" strategy the conflict is recorded and first is returned.
+func (a *Analyzer) mergeFieldTypes(first, second models.FieldInfo, structName string, conflicts *[]string) models.FieldInfo {
+	nullable := first.GoType.IsPointer || second.GoType.IsPointer
+	t1, t2 := first.GoType, second.GoType
+	t1.IsPointer, t2.IsPointer = false, false
+
+	merged, ok := compatibleType(t1, t2)
+	if ok {
+		field := second
+		if merged.Kind == t1.Kind && merged.Name == t1.Name {
+			field = first
+		}
+		field.GoType = merged
+		field.GoType.IsPointer = nullable || merged.Kind == models.Slice || merged.Kind == models.Struct
+		// "12.5%" in one object and 3 in another still need the formatting stripped
+		field.FormattedNumber = (first.FormattedNumber || second.FormattedNumber) && (merged.Kind == models.Int || merged.Kind == models.Float)
+		field.JSONTag, field.Tags, _ = a.generateFieldTags(field.JSONKey, field.GoType, nil)
+		return field
+	}
+
+	switch a.config.Arrays.MergeStrategy {
+	case config.MergeFirst:
+		if nullable && !first.GoType.IsPointer {
+			return a.withPointer(first)
+		}
+		return first
+	case config.MergeError:
+		*conflicts = append(*conflicts, fmt.Sprintf("%s.%s is %s and %s", structName, first.JSONKey, typeDescription(t1), typeDescription(t2)))
+		return first
+	case config.MergeString:
+		if isScalarKind(t1.Kind) && isScalarKind(t2.Kind) {
+			field := first
+			field.GoType = models.TypeInfo{Kind: models.String, Name: "string", IsPointer: nullable}
+			field.DurationUnit = ""
+			field.FormattedNumber = false
+			field.LenientString = true
+			field.JSONTag, field.Tags, field.Comment = a.generateFieldTags(field.JSONKey, field.GoType, nil)
+			if field.Comment == "" {
+				field.Comment = fmt.Sprintf("Merged from %s and %s", typeDescription(t1), typeDescription(t2))
+			}
+			return field
+		}
+	}
+
+	// interface (the default), and string for non-scalar conflicts
+	a.warn(models.WarningAmbiguousField, a.pathTo(first.JSONKey),
+		fmt.Sprintf("%s.%s is %s and %s in different objects; typed as interface{}", structName, first.JSONKey, typeDescription(t1), typeDescription(t2)))
+	field := first
+	field.GoType = models.TypeInfo{Kind: models.Interface, Name: "interface{}", IsPointer: true}
+	field.DurationUnit = ""
+	field.FormattedNumber = false
+	field.JSONTag, field.Tags, field.Comment = a.generateFieldTags(field.JSONKey, field.GoType, nil)
+	return field
+}
+
+// compatibleType combines two non-pointer types that don't conflict
+func compatibleType(t1, t2 models.TypeInfo) (models.TypeInfo, bool) {
+	switch {
+	case t1.Kind == models.Struct && t2.Kind == models.Struct:
+		// Differently shaped structs keep the latest shape, as before merge strategies existed
+		return t2, true
+	case t1.Kind == models.Slice && t2.Kind == models.Slice:
+		e1, e2 := t1.SliceElementType, t2.SliceElementType
+		switch {
+		case untypedElement(e2):
+			return t1, true
+		case untypedElement(e1) || e1.Kind == e2.Kind && (e1.Kind != models.Interface || e1.Name == e2.Name):
+			return t2, true
+		}
+		return models.TypeInfo{}, false
+	case t1.Kind == t2.Kind && t1.Name == t2.Name:
+		return t1, true
+	case isBigNumber(t1) || isBigNumber(t2):
+		return widerNumber(t1, t2)
+	case t1.Kind == models.Int && t2.Kind == models.Float:
+		return t2, true
+	case t1.Kind == models.Float && t2.Kind == models.Int:
+		return t1, true
+	}
+	return models.TypeInfo{}, false
+}
+
+// untypedElement reports whether a slice element type says nothing about the
+// elements, as for an empty array. A union's interface is a real element type.
+func untypedElement(e *models.TypeInfo) bool {
+	return e == nil || e.Kind == models.Interface && (e.Name == "" || e.Name == "interface{}")
+}
+
+// isBigNumber reports whether a type is one of the types.big_number_type types
+func isBigNumber(t models.TypeInfo) bool {
+	switch t.Name {
+	case "json.Number", "big.Int", "big.Float":
+		return t.Import != ""
+	}
+	return false
+}
+
+// widerNumber combines two number types, at least one of them a types.big_number_type
+// type, into the type that holds both: json.Number holds anything, *big.Float holds
+// any float, and *big.Int holds any integer
+func widerNumber(t1, t2 models.TypeInfo) (models.TypeInfo, bool) {
+	for _, t := range []models.TypeInfo{t1, t2} {
+		if t.Kind != models.Int && t.Kind != models.Float {
+			return models.TypeInfo{}, false
+		}
+	}
+	for _, name := range []string{"json.Number", "big.Float"} {
+		for _, t := range []models.TypeInfo{t1, t2} {
+			if t.Name == name && t.Import != "" {
+				return t, true
+			}
+		}
+	}
+	if t1.Kind == models.Float || t2.Kind == models.Float {
+		// An integer too big for int64 alongside a float64
+		return models.TypeInfo{Kind: models.Float, Name: "big.Float", Import: "math/big"}, true
+	}
+	if isBigNumber(t1) {
+		return t1, true
+	}
+	return t2, true
+}
+
+// isScalarKind reports whether a kind holds a single JSON string, number or boolean
+func isScalarKind(kind models.GoTypeKind) bool {
+	switch kind {
+	case models.String, models.Int, models.Float, models.Bool, models.Time, models.UUID, models.Duration:
+		return true
+	}
+	return false
+}
+
+// typeDescription names a type for conflict messages
+func typeDescription(typeInfo models.TypeInfo) string {
+	if typeInfo.Kind == models.Struct {
+		return "object"
+	}
+	return typeInfo.Name
+}
+
+// addStruct adds a finished struct to the results and to structBuckets
+func (a *Analyzer) addStruct(structDef models.StructDef) {
+	if structDef.SourcePath == "" {
+		structDef.SourcePath = a.sourcePath()
+	}
+	a.analysisResult.Structs = append(a.analysisResult.Structs, structDef)
+	hash := structDef.Fingerprint()
+	a.structBuckets[hash] = append(a.structBuckets[hash], structDef)
+}
+
 // findOrAddStructDef checks if an equivalent struct definition already exists.
 // If yes, it returns the TypeInfo of the existing struct.
 // If no, it finalizes the new structDef (assigns a unique name, adds it to results)
@@ -1171,7 +2302,7 @@ func (a *Analyzer) createMergedStructDef(objects []models.JSONObject, suggestedN
 // `isArrayElement` indicates if this struct represents an element in an array.
 func (a *Analyzer) findOrAddStructDef(candidateStructDef models.StructDef, suggestedName string, isRoot bool, isArrayElement bool) models.TypeInfo {
 	// First check if an equivalent struct already exists
-	for _, existingStruct := range a.analysisResult.Structs {
+	for _, existingStruct := range a.structBuckets[candidateStructDef.Fingerprint()] {
 		if areStructDefsEquivalent(&candidateStructDef, &existingStruct) {
 			return models.TypeInfo{
 				Kind:       models.Struct,
@@ -1194,6 +2325,7 @@ func (a *Analyzer) findOrAddStructDef(candidateStructDef models.StructDef, sugge
 
 	// Update the candidate with the final name
 	candidateStructDef.Name = finalName
+	candidateStructDef.SourcePath = a.sourcePath()
 
 	// If this struct represents an array element, it should never be marked as root
 	// The array itself is the root, not the element struct
@@ -1203,8 +2335,7 @@ func (a *Analyzer) findOrAddStructDef(candidateStructDef models.StructDef, sugge
 		candidateStructDef.IsRoot = isRoot
 	}
 
-	// Add to our results
-	a.analysisResult.Structs = append(a.analysisResult.Structs, candidateStructDef)
+	a.addStruct(candidateStructDef)
 
 	return models.TypeInfo{
 		Kind:       models.Struct,
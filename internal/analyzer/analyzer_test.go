@@ -1,8 +1,11 @@
 package analyzer
 
 import (
+	"fmt"
+	"go/token"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/mcncl/gotyper/internal/config"
@@ -1136,186 +1139,6 @@ func TestJsonKeyToPascalCase(t *testing.T) {
 	}
 }
 
-func TestSingularize(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		// Basic -s removal
-		{"users", "user"},
-		{"items", "item"},
-		{"events", "event"},
-		{"requests", "request"},
-		{"responses", "response"},
-
-		// Case preservation
-		{"Items", "Item"},
-		{"Users", "User"},
-		{"Properties", "Property"},
-		{"Cities", "City"},
-
-		// Irregular plurals (dictionary)
-		{"children", "child"},
-		{"people", "person"},
-		{"men", "man"},
-		{"women", "woman"},
-		{"teeth", "tooth"},
-		{"feet", "foot"},
-		{"mice", "mouse"},
-		{"geese", "goose"},
-		{"oxen", "ox"},
-		{"indices", "index"},
-		{"vertices", "vertex"},
-		{"matrices", "matrix"},
-
-		// Uncountable words (same singular/plural)
-		{"data", "data"},
-		{"media", "media"},
-		{"metadata", "metadata"},
-		{"series", "series"},
-		{"species", "species"},
-		{"news", "news"},
-		{"analytics", "analytics"},
-		{"metrics", "metrics"},
-		{"software", "software"},
-		{"hardware", "hardware"},
-		{"feedback", "feedback"},
-		{"information", "information"},
-
-		// Words ending in -es that need dictionary
-		{"statuses", "status"},
-		{"addresses", "address"},
-		{"processes", "process"},
-		{"classes", "class"},
-		{"buses", "bus"},
-		{"aliases", "alias"},
-		{"analyses", "analysis"},
-
-		// Words ending in -ches (rule-based)
-		{"matches", "match"},
-		{"batches", "batch"},
-		{"patches", "patch"},
-		{"branches", "branch"},
-		{"searches", "search"},
-		{"switches", "switch"},
-
-		// Words ending in -shes (rule-based)
-		{"crashes", "crash"},
-		{"hashes", "hash"},
-		{"flashes", "flash"},
-		{"pushes", "push"},
-		{"meshes", "mesh"},
-
-		// Words ending in -xes (rule-based)
-		{"boxes", "box"},
-		{"indexes", "index"},
-		{"taxes", "tax"},
-		{"fixes", "fix"},
-		{"prefixes", "prefix"},
-		{"suffixes", "suffix"},
-
-		// Words ending in -sses (rule-based)
-		{"classes", "class"},
-		{"passes", "pass"},
-		{"processes", "process"},
-
-		// Words ending in -ies (rule-based)
-		{"categories", "category"},
-		{"queries", "query"},
-		{"entries", "entry"},
-		{"replies", "reply"},
-		{"factories", "factory"},
-		{"repositories", "repository"},
-		{"directories", "directory"},
-		{"properties", "property"},
-		{"policies", "policy"},
-		{"strategies", "strategy"},
-		{"entities", "entity"},
-		{"activities", "activity"},
-		{"dependencies", "dependency"},
-		{"currencies", "currency"},
-		{"libraries", "library"},
-
-		// Words ending in -ves (dictionary)
-		{"leaves", "leaf"},
-		{"lives", "life"},
-		{"knives", "knife"},
-		{"shelves", "shelf"},
-		{"wolves", "wolf"},
-		{"halves", "half"},
-
-		// Words ending in -oes (rule-based)
-		{"heroes", "hero"},
-		{"potatoes", "potato"},
-		{"tomatoes", "tomato"},
-		{"echoes", "echo"},
-
-		// Words ending in -ses (rule-based)
-		{"databases", "database"},
-		{"responses", "response"},
-		{"purposes", "purpose"},
-		{"houses", "house"},
-
-		// Words that should NOT be singularized (ending in ss, us, is, os)
-		{"status", "status"},
-		{"analysis", "analysis"},
-		{"basis", "basis"},
-		{"class", "class"},
-		{"pass", "pass"},
-		{"virus", "virus"},
-		{"chaos", "chaos"},
-
-		// Already singular
-		{"item", "item"},
-		{"user", "user"},
-		{"person", "person"},
-
-		// Common programming/API terms
-		{"schemas", "schema"},
-		{"caches", "cache"},
-		{"proxies", "proxy"},
-		{"credentials", "credentials"},
-		{"settings", "settings"},
-		{"permissions", "permission"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			assert.Equal(t, tt.expected, singularize(tt.input, nil))
-		})
-	}
-}
-
-func TestSingularize_CustomSingulars(t *testing.T) {
-	customSingulars := map[string]string{
-		"datums":    "datum",
-		"appendix":  "appendix", // Override to keep as-is
-		"octopuses": "octopus",
-		"cacti":     "cactus",
-	}
-
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		// Custom singulars take precedence
-		{"datums", "datum"},
-		{"Datums", "Datum"}, // Case preservation
-		{"octopuses", "octopus"},
-		{"cacti", "cactus"},
-		// Built-in still works when not overridden
-		{"users", "user"},
-		{"children", "child"},
-		{"categories", "category"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			assert.Equal(t, tt.expected, singularize(tt.input, customSingulars))
-		})
-	}
-}
-
 // TestAnalyze_MixedTypeArray tests arrays with mixed types (not all objects)
 func TestAnalyze_MixedTypeArray(t *testing.T) {
 	jsonInput := `[42, "string", true, null]` // Mixed primitives only
@@ -1741,3 +1564,1455 @@ validation:
 		})
 	}
 }
+
+func TestAnalyze_NonPascalFieldsAreExported(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Naming.PascalCaseFields = false
+
+	ir, err := parser.ParseString(`{
+		"user_id": 1,
+		"_private": "x",
+		"2fa_enabled": true,
+		"profile": {"display_name": "Ann", "tags": ["a"]},
+		"items": [{"sku": "A1"}]
+	}`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+	require.NoError(t, err)
+
+	fieldNames := make(map[string]string)
+	for _, structDef := range result.Structs {
+		for _, field := range structDef.Fields {
+			assert.True(t, token.IsExported(field.GoName), "field %s.%s must be exported for encoding/json", structDef.Name, field.GoName)
+			assert.True(t, token.IsIdentifier(field.GoName), "field %s.%s must be a valid identifier", structDef.Name, field.GoName)
+			fieldNames[field.JSONKey] = field.GoName
+		}
+	}
+
+	// The remainder of the key keeps its original style
+	assert.Equal(t, "User_id", fieldNames["user_id"])
+	assert.Equal(t, "X_private", fieldNames["_private"])
+	assert.Equal(t, "X2fa_enabled", fieldNames["2fa_enabled"])
+	assert.Equal(t, "Display_name", fieldNames["display_name"])
+}
+
+func TestAnalyze_DurationFields(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.DurationFields = []config.DurationField{
+		{Pattern: "_seconds$", Unit: "s"},
+		{Pattern: "_ms$", Unit: "ms"},
+	}
+
+	ir, err := parser.ParseString(`{
+		"timeout_seconds": 30,
+		"jitter_ms": 12.5,
+		"label_seconds": "thirty",
+		"name": "worker",
+		"steps": [{"wait_seconds": 5}]
+	}`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Config")
+	require.NoError(t, err)
+	assert.Contains(t, result.Imports, "time")
+
+	fields := make(map[string]models.FieldInfo)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			fields[f.JSONKey] = f
+		}
+	}
+
+	assert.Equal(t, models.Duration, fields["timeout_seconds"].GoType.Kind)
+	assert.Equal(t, "time.Duration", fields["timeout_seconds"].GoType.Name)
+	assert.Equal(t, "s", fields["timeout_seconds"].DurationUnit)
+
+	assert.Equal(t, "time.Duration", fields["jitter_ms"].GoType.Name)
+	assert.Equal(t, "ms", fields["jitter_ms"].DurationUnit)
+
+	// Array element structs are covered too
+	assert.Equal(t, "time.Duration", fields["wait_seconds"].GoType.Name)
+
+	// Only numeric values become durations
+	assert.Equal(t, "string", fields["label_seconds"].GoType.Name)
+	assert.Empty(t, fields["label_seconds"].DurationUnit)
+	assert.Empty(t, fields["name"].DurationUnit)
+}
+
+func TestAnalyze_RootPrimitiveField(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.RootPrimitiveField = "Count"
+
+	ir, err := parser.ParseString(`42`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Total")
+	require.NoError(t, err)
+	require.Len(t, result.Structs, 1)
+
+	root := result.Structs[0]
+	assert.Equal(t, "Total", root.Name)
+	require.Len(t, root.Fields, 1)
+	assert.Equal(t, "count", root.Fields[0].JSONKey)
+	assert.Equal(t, "Count", root.Fields[0].GoName)
+	assert.Equal(t, "int64", root.Fields[0].GoType.Name)
+	assert.Equal(t, "`json:\"count\"`", root.Fields[0].JSONTag)
+
+	// Null roots use the same field name
+	ir, err = parser.ParseString(`null`)
+	require.NoError(t, err)
+	result, err = NewAnalyzerWithConfig(cfg).Analyze(ir, "Total")
+	require.NoError(t, err)
+	assert.Equal(t, "`json:\"count,omitempty\"`", result.Structs[0].Fields[0].JSONTag)
+
+	// Default stays Value/value
+	ir, err = parser.ParseString(`"hello"`)
+	require.NoError(t, err)
+	result, err = NewAnalyzer().Analyze(ir, "Greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "Value", result.Structs[0].Fields[0].GoName)
+	assert.Equal(t, "value", result.Structs[0].Fields[0].JSONKey)
+}
+
+func TestAnalyze_RootPrimitiveAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected models.TypeInfo
+	}{
+		{name: "string", input: `"hello"`, expected: models.TypeInfo{Kind: models.String, Name: "string"}},
+		{name: "integer", input: `42`, expected: models.TypeInfo{Kind: models.Int, Name: "int64"}},
+		{name: "float", input: `4.2`, expected: models.TypeInfo{Kind: models.Float, Name: "float64"}},
+		{name: "bool", input: `true`, expected: models.TypeInfo{Kind: models.Bool, Name: "bool"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Output.RootPrimitiveAlias = true
+
+			ir, err := parser.ParseString(tt.input)
+			require.NoError(t, err)
+
+			result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+			require.NoError(t, err)
+
+			assert.Empty(t, result.Structs)
+			assert.Equal(t, []models.NamedType{{Name: "Root", Type: tt.expected}}, result.NamedTypes)
+		})
+	}
+}
+
+func TestAnalyze_RootPrimitiveAliasTime(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.RootPrimitiveAlias = true
+
+	ir, err := parser.ParseString(`"2024-01-15T10:30:00Z"`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Timestamp")
+	require.NoError(t, err)
+
+	// A true alias keeps time.Time's JSON methods
+	require.Len(t, result.NamedTypes, 1)
+	assert.True(t, result.NamedTypes[0].IsAlias)
+	assert.Equal(t, "time.Time", result.NamedTypes[0].Type.Name)
+	assert.Contains(t, result.Imports, "time")
+}
+
+func TestAnalyze_OmitzeroByGoVersion(t *testing.T) {
+	jsonInput := `{"created_at": "2024-01-15T10:30:00Z", "count": 3, "tags": ["a"]}`
+
+	tests := []struct {
+		name           string
+		goVersion      string
+		preferOmitzero bool
+		expectedTag    string
+	}{
+		{name: "go 1.21 keeps plain tags", goVersion: "1.21", preferOmitzero: true, expectedTag: "`json:\"created_at\"`"},
+		{name: "go 1.24 uses omitzero", goVersion: "1.24", preferOmitzero: true, expectedTag: "`json:\"created_at,omitzero\"`"},
+		{name: "go 1.24 without opt-in", goVersion: "1.24", preferOmitzero: false, expectedTag: "`json:\"created_at\"`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.GoVersion = tt.goVersion
+			cfg.JSONTags.PreferOmitzero = tt.preferOmitzero
+
+			ir, err := parser.ParseString(jsonInput)
+			require.NoError(t, err)
+			result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Event")
+			require.NoError(t, err)
+
+			fields := make(map[string]models.FieldInfo)
+			for _, f := range result.Structs[0].Fields {
+				fields[f.JSONKey] = f
+			}
+
+			assert.Equal(t, tt.expectedTag, fields["created_at"].JSONTag)
+			// Primitives and pointers are unaffected
+			assert.Equal(t, "`json:\"count\"`", fields["count"].JSONTag)
+			assert.Equal(t, "`json:\"tags,omitempty\"`", fields["tags"].JSONTag)
+		})
+	}
+}
+
+func TestAnalyze_MaxNameLength(t *testing.T) {
+	// Two deep branches that share a long common prefix but end in differently shaped leaves
+	jsonInput := `{"leaf": {"blue": 1}}`
+	for i := 0; i < 12; i++ {
+		jsonInput = `{"environment_configuration": ` + jsonInput + `}`
+	}
+	jsonInput = `{"primary": ` + jsonInput + `, "secondary": ` + strings.Replace(jsonInput, `"blue": 1`, `"green": "x"`, 1) + `}`
+
+	cfg := config.NewConfig()
+	cfg.Naming.MaxNameLength = 40
+
+	ir, err := parser.ParseString(jsonInput)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "RootType")
+	require.NoError(t, err)
+
+	names := make(map[string]bool)
+	truncated := 0
+	for _, structDef := range result.Structs {
+		assert.LessOrEqual(t, len(structDef.Name), 40, structDef.Name)
+		assert.True(t, token.IsIdentifier(structDef.Name), structDef.Name)
+		assert.False(t, names[structDef.Name], "duplicate struct name %s", structDef.Name)
+		names[structDef.Name] = true
+		if len(structDef.Name) == 40 {
+			truncated++
+		}
+	}
+	assert.Greater(t, truncated, 2, "deep names should have been truncated")
+
+	// Every field still refers to a struct that exists under its truncated name
+	for _, structDef := range result.Structs {
+		for _, field := range structDef.Fields {
+			if field.GoType.Kind == models.Struct {
+				assert.True(t, names[field.GoType.StructName], "%s.%s refers to missing struct %s", structDef.Name, field.GoName, field.GoType.StructName)
+			}
+		}
+	}
+}
+
+func TestAnalyze_MergeStrategy(t *testing.T) {
+	jsonInput := `[{"id": 1, "count": 1}, {"id": "abc", "count": 2.5}, {"id": null}]`
+
+	tests := []struct {
+		strategy     string
+		expectedType string
+		lenient      bool
+		expectedErr  string
+	}{
+		{strategy: config.MergeInterface, expectedType: "interface{}"},
+		{strategy: config.MergeFirst, expectedType: "int64"},
+		{strategy: config.MergeString, expectedType: "string", lenient: true},
+		{strategy: config.MergeError, expectedErr: "Item.id is int64 and string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Arrays.MergeStrategy = tt.strategy
+
+			ir, err := parser.ParseString(jsonInput)
+			require.NoError(t, err)
+			result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Items")
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			fields := make(map[string]models.FieldInfo)
+			for _, f := range result.Structs[0].Fields {
+				fields[f.JSONKey] = f
+			}
+
+			assert.Equal(t, tt.expectedType, fields["id"].GoType.Name)
+			assert.True(t, fields["id"].GoType.IsPointer, "a null id makes the field nullable")
+			assert.Equal(t, tt.lenient, fields["id"].LenientString)
+
+			// Numbers widen instead of conflicting, whatever the strategy
+			assert.Equal(t, "float64", fields["count"].GoType.Name)
+		})
+	}
+}
+
+func TestAnalyze_MergeStrategyObjectConflict(t *testing.T) {
+	jsonInput := `[{"meta": "plain"}, {"meta": {"source": "api"}}]`
+
+	for strategy, expected := range map[string]string{
+		config.MergeInterface: "interface{}",
+		config.MergeFirst:     "string",
+		config.MergeString:    "interface{}",
+	} {
+		cfg := config.NewConfig()
+		cfg.Arrays.MergeStrategy = strategy
+
+		ir, err := parser.ParseString(jsonInput)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Items")
+		require.NoError(t, err)
+
+		// The losing object type doesn't leave an unused struct behind
+		require.Len(t, result.Structs, 1, strategy)
+		assert.Equal(t, expected, result.Structs[0].Fields[0].GoType.Name, strategy)
+	}
+}
+
+// Pins the default merge of conflicting array elements, which replaced the
+// old last-element-wins typing
+func TestAnalyze_MergeDefaults(t *testing.T) {
+	tests := []struct {
+		input    string
+		typeName string
+		pointer  bool
+		warning  bool
+	}{
+		// Used to be A string
+		{input: `[{"a": 1}, {"a": "x"}]`, typeName: "interface{}", pointer: true, warning: true},
+		// Used to be A int64, or *interface{} with the null last
+		{input: `[{"a": null}, {"a": 1}]`, typeName: "int64", pointer: true},
+		{input: `[{"a": 1}, {"a": null}]`, typeName: "int64", pointer: true},
+		// Used to be A int64
+		{input: `[{"a": 1.5}, {"a": 2}]`, typeName: "float64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ir, err := parser.ParseString(tt.input)
+			require.NoError(t, err)
+			result, err := NewAnalyzer().Analyze(ir, "Items")
+			require.NoError(t, err)
+
+			require.Len(t, result.Structs, 1)
+			field := result.Structs[0].Fields[0]
+			assert.Equal(t, tt.typeName, field.GoType.Name)
+			assert.Equal(t, tt.pointer, field.GoType.IsPointer)
+
+			if tt.warning {
+				require.Len(t, result.Warnings, 1)
+				assert.Equal(t, models.WarningAmbiguousField, result.Warnings[0].Type)
+				assert.Equal(t, "a", result.Warnings[0].Path)
+			} else {
+				assert.Empty(t, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestAnalyze_TrimKeyPrefixes(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Naming.TrimKeyPrefixes = []string{"attr_"}
+
+	ir, err := parser.ParseString(`{"attr_id": 1, "attr_address": {"attr_city": "Oslo"}}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "User")
+	require.NoError(t, err)
+
+	structs := make(map[string]models.StructDef)
+	for _, s := range result.Structs {
+		structs[s.Name] = s
+	}
+	require.Contains(t, structs, "UserAddress")
+
+	fields := make(map[string]models.FieldInfo)
+	for _, f := range structs["User"].Fields {
+		fields[f.GoName] = f
+	}
+	assert.Equal(t, "`json:\"attr_id\"`", fields["Id"].JSONTag)
+	assert.Equal(t, "attr_address", fields["Address"].JSONKey)
+	assert.Equal(t, "City", structs["UserAddress"].Fields[0].GoName)
+	assert.Equal(t, "`json:\"attr_city\"`", structs["UserAddress"].Fields[0].JSONTag)
+}
+
+func TestAnalyze_ASCIIOnly(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Naming.ASCIIOnly = true
+
+	ir, err := parser.ParseString(`{"naïve": true, "名前": "Ann", "住所": {"café": "Oslo"}, "items": [{"価格": 1}, {"価格": 2, "数量": 3}]}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "User")
+	require.NoError(t, err)
+
+	tags := make(map[string]string)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			tags[s.Name+"."+f.GoName] = f.JSONTag
+		}
+	}
+	assert.Equal(t, map[string]string{
+		// Keys are met in sorted order, depth first: items' elements come before 住所 and 名前
+		"User.Items":      "`json:\"items,omitempty\"`",
+		"User.Naive":      "`json:\"naïve\"`",
+		"User.Field3":     "`json:\"住所,omitempty\"`",
+		"User.Field4":     "`json:\"名前\"`",
+		"UserField3.Cafe": "`json:\"café\"`",
+		"UserItem.Field1": "`json:\"価格\"`",
+		"UserItem.Field2": "`json:\"数量\"`",
+	}, tags)
+}
+
+func TestAnalyze_OmitemptyOptionalOnly(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.JSONTags.Omitempty = config.OmitemptyOptionalOnly
+
+	ir, err := parser.ParseString(`[
+		{"id": 1, "tags": ["a"], "labels": ["x"], "note": null, "owner": {"name": "Ann"}},
+		{"id": 2, "tags": [], "note": "hi", "owner": {"name": "Bob"}}
+	]`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Item")
+	require.NoError(t, err)
+
+	tags := make(map[string]string)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			tags[s.Name+"."+f.GoName] = f.JSONTag
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"Item.Id":        "`json:\"id\"`",
+		"Item.Tags":      "`json:\"tags\"`",
+		"Item.Labels":    "`json:\"labels,omitempty\"`",
+		"Item.Note":      "`json:\"note,omitempty\"`",
+		"Item.Owner":     "`json:\"owner\"`",
+		"ItemOwner.Name": "`json:\"name\"`",
+	}, tags)
+}
+
+func TestAnalyze_OmitemptyNone(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.JSONTags.Omitempty = config.OmitemptyNone
+
+	ir, err := parser.ParseString(`{"tags": ["a"], "owner": {"name": "Ann"}, "note": null}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Item")
+	require.NoError(t, err)
+
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			assert.NotContains(t, f.JSONTag, "omit", "%s.%s", s.Name, f.GoName)
+		}
+	}
+}
+
+func TestAnalyze_InterfaceUnion(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Arrays.Strategy = config.ArrayStrategyInterfaceUnion
+	cfg.Types.Discriminator = "type"
+
+	ir, err := parser.ParseString(`[{"type": "circle", "radius": 1.5}, {"type": "square", "side": 2}, {"type": "circle", "radius": 3}]`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Shapes")
+	require.NoError(t, err)
+
+	require.Len(t, result.NamedTypes, 1)
+	shape := result.NamedTypes[0]
+	assert.Equal(t, "Shape", shape.Name)
+	require.NotNil(t, shape.Union)
+	assert.Equal(t, "type", shape.Union.Discriminator)
+	assert.Equal(t, []models.UnionVariant{{Value: "circle", Struct: "CircleShape"}, {Value: "square", Struct: "SquareShape"}}, shape.Union.Variants)
+
+	fields := make(map[string][]string)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			fields[s.Name] = append(fields[s.Name], f.GoName)
+		}
+	}
+	assert.Equal(t, []string{"Radius", "Type"}, fields["CircleShape"])
+	assert.Equal(t, []string{"Side", "Type"}, fields["SquareShape"])
+
+	// Without a second discriminator value the array is merged as usual
+	ir, err = parser.ParseString(`{"shapes": [{"type": "circle", "radius": 1}]}`)
+	require.NoError(t, err)
+	result, err = NewAnalyzerWithConfig(cfg).Analyze(ir, "Drawing")
+	require.NoError(t, err)
+	assert.Empty(t, result.NamedTypes)
+}
+
+func TestAnalyze_InterfaceUnionSharedStruct(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Arrays.Strategy = config.ArrayStrategyInterfaceUnion
+	cfg.Types.Discriminator = "type"
+
+	// Both variants have the same shape, so they share one struct
+	ir, err := parser.ParseString(`{"shapes": [{"type": "a", "x": 1}, {"type": "b", "x": 2}]}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "RootType")
+	require.NoError(t, err)
+
+	require.Len(t, result.NamedTypes, 1)
+	shape := result.NamedTypes[0]
+	require.NotNil(t, shape.Union)
+	require.Len(t, shape.Union.Variants, 2)
+	shared := shape.Union.Variants[0].Struct
+	assert.Equal(t, shared, shape.Union.Variants[1].Struct)
+	assert.Equal(t, 1, strings.Count(shape.Comment, shared), "the shared struct is named once: %s", shape.Comment)
+	assert.Equal(t, fmt.Sprintf("%s is one of %s, chosen by the JSON \"type\" key.", shape.Name, shared), shape.Comment)
+}
+
+func TestAnalyze_MaxUnionMembers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Arrays.Strategy = config.ArrayStrategyInterfaceUnion
+	cfg.Types.Discriminator = "type"
+	cfg.Arrays.MaxUnionMembers = 2
+
+	input := `{"events": [{"type": "click", "x": 1}, {"type": "scroll", "dy": 2}, {"type": "key", "code": "a"}]}`
+	ir, err := parser.ParseString(input)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Page")
+	require.NoError(t, err)
+
+	// Three variants exceed the limit, so the events merge into one struct
+	assert.Empty(t, result.NamedTypes)
+	var names []string
+	for _, s := range result.Structs {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"Page", "PageEvent"}, names)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, models.WarningUnionMembers, result.Warnings[0].Type)
+	assert.Equal(t, "events", result.Warnings[0].Path)
+	assert.Contains(t, result.Warnings[0].Message, `has 3 "type" values, more than arrays.max_union_members (2)`)
+
+	// Per-shape structs are limited the same way
+	cfg = config.NewConfig()
+	cfg.Arrays.PerShapeStructs = true
+	cfg.Arrays.MaxUnionMembers = 2
+	ir, err = parser.ParseString(`[{"a": 1}, {"b": 2}, {"c": 3}]`)
+	require.NoError(t, err)
+	result, err = NewAnalyzerWithConfig(cfg).Analyze(ir, "Items")
+	require.NoError(t, err)
+	assert.Empty(t, result.NamedTypes)
+	require.Len(t, result.Structs, 1)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0].Message, "has 3 shapes")
+
+	// At the limit the union is kept
+	cfg.Arrays.MaxUnionMembers = 3
+	result, err = NewAnalyzerWithConfig(cfg).Analyze(ir, "Items")
+	require.NoError(t, err)
+	assert.Len(t, result.NamedTypes, 1)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestAnalyze_ArrayElementNamingIsDeterministic(t *testing.T) {
+	analyze := func(t *testing.T, input, rootName string) []string {
+		t.Helper()
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzer().Analyze(ir, rootName)
+		require.NoError(t, err)
+		names := make([]string, 0, len(result.Structs))
+		for _, s := range result.Structs {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	// A root array's element struct takes the singular root name
+	assert.Equal(t, []string{"Item"}, analyze(t, `[{"id": 1}]`, "Items"))
+	assert.Equal(t, []string{"RootType"}, analyze(t, `[{"id": 1}]`, "RootType"))
+
+	// A nested array of the same key is named after its parent
+	assert.ElementsMatch(t, []string{"Order", "OrderItem"}, analyze(t, `{"items": [{"id": 1}]}`, "Order"))
+
+	// A nested array whose element name is already taken gets a unique name instead
+	// of being mistaken for the root array and reusing the name
+	names := analyze(t, `{"user": {"id": 1}, "users": [{"name": "Ann"}]}`, "Account")
+	assert.ElementsMatch(t, []string{"Account", "AccountUser", "AccountUser1"}, names)
+}
+
+func TestAnalyze_MergedFieldPresence(t *testing.T) {
+	ir, err := parser.ParseString(`[{"id": 1, "note": null, "tags": ["a"]}, {"id": 2, "note": "x"}]`)
+	require.NoError(t, err)
+	result, err := NewAnalyzer().Analyze(ir, "Items")
+	require.NoError(t, err)
+
+	optional := make(map[string]bool)
+	for _, f := range result.Structs[0].Fields {
+		optional[f.JSONKey] = f.Optional
+	}
+	// A null value still counts as present
+	assert.Equal(t, map[string]bool{"id": false, "note": false, "tags": true}, optional)
+}
+
+func TestAnalyze_MergesArrayElementsAcrossObjects(t *testing.T) {
+	// Two samples whose items arrays hold objects with different keys
+	ir, err := parser.ParseString(`[{"items": [{"a": 1}]}, {"items": [{"b": 2}]}]`)
+	require.NoError(t, err)
+	result, err := NewAnalyzer().Analyze(ir, "Order")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Structs))
+	for _, s := range result.Structs {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"Order", "OrderItem"}, names)
+
+	structs := make(map[string]models.StructDef)
+	for _, s := range result.Structs {
+		structs[s.Name] = s
+	}
+	require.Len(t, structs["Order"].Fields, 1)
+	assert.Equal(t, "OrderItem", structs["Order"].Fields[0].GoType.ReferencedStruct())
+
+	optional := make(map[string]bool)
+	for _, f := range structs["OrderItem"].Fields {
+		optional[f.JSONKey] = f.Optional
+	}
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, optional)
+}
+
+func TestAnalyze_TagOrder(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Validation.Enabled = true
+	cfg.Validation.Rules = []config.ValidationRule{{Pattern: "^email$", Tag: "validate:\"required,email\""}}
+	cfg.JSONTags.AdditionalTags = []string{"yaml"}
+
+	analyze := func(t *testing.T) string {
+		t.Helper()
+		ir, err := parser.ParseString(`{"email": "a@example.com"}`)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "User")
+		require.NoError(t, err)
+		require.Len(t, result.Structs[0].Fields, 1)
+		return result.Structs[0].Fields[0].JSONTag
+	}
+
+	// By default json leads, followed by additional_tags and then validate
+	assert.Equal(t, "`json:\"email\" yaml:\"email\" validate:\"required,email\"`", analyze(t))
+
+	// Listed tags come first in order; the rest follow alphabetically
+	cfg.JSONTags.TagOrder = []string{"validate", "json"}
+	assert.Equal(t, "`validate:\"required,email\" json:\"email\" yaml:\"email\"`", analyze(t))
+}
+
+func TestAnalyze_PreserveNullFields(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.PreserveNullFields = true
+
+	analyze := func(t *testing.T, input string) models.AnalysisResult {
+		t.Helper()
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("field null in some elements", func(t *testing.T) {
+		result := analyze(t, `[{"x":null},{"x":5}]`)
+		require.Len(t, result.Structs, 1)
+		field := result.Structs[0].Fields[0]
+		assert.Equal(t, models.Int, field.GoType.Kind)
+		assert.True(t, field.GoType.IsPointer)
+		assert.Equal(t, "int64", field.GoType.Name)
+	})
+
+	t.Run("null array elements", func(t *testing.T) {
+		result := analyze(t, `{"x": [null, 5], "y": [{"a": 1}, null], "z": [null, "a", 5]}`)
+		fields := make(map[string]models.TypeInfo)
+		for _, s := range result.Structs {
+			if s.IsRoot {
+				for _, f := range s.Fields {
+					fields[f.GoName] = f.GoType
+				}
+			}
+		}
+		require.Contains(t, fields, "X")
+		assert.Equal(t, "[]*int64", fields["X"].Name)
+		assert.True(t, fields["X"].SliceElementType.IsPointer)
+		assert.Equal(t, "[]*RootY", fields["Y"].Name)
+		// Mixed types still fall back to interface{}
+		assert.Equal(t, "[]interface{}", fields["Z"].Name)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ir, err := parser.ParseString(`{"x": [null, 5]}`)
+		require.NoError(t, err)
+		result, err := NewAnalyzer().Analyze(ir, "Root")
+		require.NoError(t, err)
+		assert.Equal(t, "[]interface{}", result.Structs[0].Fields[0].GoType.Name)
+	})
+}
+
+func TestAnalyze_MapstructureTags(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.JSONTags.Mapstructure = true
+
+	ir, err := parser.ParseString(`{"db_host": "localhost", "maxConns": 10}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Config")
+	require.NoError(t, err)
+
+	fields := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fields[f.JSONKey] = f
+	}
+	assert.Equal(t, "`json:\"db_host\" mapstructure:\"db_host\"`", fields["db_host"].JSONTag)
+	// Keys are lowercased to match how Viper stores them
+	assert.Equal(t, "`json:\"maxConns\" mapstructure:\"maxconns\"`", fields["maxConns"].JSONTag)
+	assert.Equal(t, "maxconns", fields["maxConns"].Tags["mapstructure"])
+}
+
+func TestAnalyze_AllNullArray(t *testing.T) {
+	ir, err := parser.ParseString(`{"xs": [null, null], "ys": []}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzer().Analyze(ir, "Root")
+	require.NoError(t, err)
+
+	fields := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fields[f.JSONKey] = f
+	}
+	assert.Equal(t, "[]interface{}", fields["xs"].GoType.Name)
+	assert.False(t, fields["xs"].GoType.SliceElementType.IsPointer)
+	assert.Equal(t, "[]interface{}", fields["ys"].GoType.Name)
+
+	// Only the all-null array is reported; an empty array is unremarkable
+	assert.Equal(t, []models.Warning{{
+		Type:    models.WarningNullArray,
+		Path:    "xs",
+		Message: "every element of the RootXs array is null; typed as []interface{}",
+	}}, result.Warnings)
+}
+
+func TestAnalyze_ParseFormattedNumbers(t *testing.T) {
+	input := `{"rate": "12.5%", "price": "$1,200.00", "count": "1,024", "zip": "0150", "label": "50% off", "items": [{"cost": "$5"}, {"cost": 3.5}]}`
+
+	analyze := func(parse bool) map[string]models.FieldInfo {
+		cfg := config.NewConfig()
+		cfg.Types.ParseFormattedNumbers = parse
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Offer")
+		require.NoError(t, err)
+		fields := make(map[string]models.FieldInfo)
+		for _, s := range result.Structs {
+			for _, f := range s.Fields {
+				fields[f.JSONKey] = f
+			}
+		}
+		return fields
+	}
+
+	fields := analyze(true)
+	for key, want := range map[string]string{"rate": "float64", "price": "float64", "count": "int64", "cost": "float64"} {
+		assert.Equal(t, want, fields[key].GoType.Name, key)
+		assert.True(t, fields[key].FormattedNumber, key)
+	}
+	for _, key := range []string{"zip", "label"} {
+		assert.Equal(t, "string", fields[key].GoType.Name, key)
+		assert.False(t, fields[key].FormattedNumber, key)
+	}
+
+	// Off by default
+	fields = analyze(false)
+	assert.Equal(t, "string", fields["rate"].GoType.Name)
+	assert.False(t, fields["rate"].FormattedNumber)
+}
+
+func TestAnalyze_FormattedNumberStructsStaySeparate(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.ParseFormattedNumbers = true
+	ir, err := parser.ParseString(`{"a": {"p": 1.5}, "b": {"p": "12.5%"}}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "RootType")
+	require.NoError(t, err)
+
+	structs := make(map[string]models.StructDef)
+	for _, s := range result.Structs {
+		structs[s.Name] = s
+	}
+	require.Contains(t, structs, "RootTypeB", "a struct of formatted numbers needs its own UnmarshalJSON")
+	assert.False(t, structs["RootTypeA"].Fields[0].FormattedNumber)
+	assert.True(t, structs["RootTypeB"].Fields[0].FormattedNumber)
+	assert.NotEqual(t, structs["RootTypeA"].Fingerprint(), structs["RootTypeB"].Fingerprint())
+}
+
+func TestAnalyze_InlineFields(t *testing.T) {
+	input := `{"id": 7, "name": "Ann", "meta": {"version": 3, "created_by": "bob", "name": "m"}, "owner": {"meta": {"version": 1}}}`
+
+	analyze := func(prefix bool) models.AnalysisResult {
+		cfg := config.NewConfig()
+		cfg.Types.InlineFields = []string{"^meta$"}
+		cfg.Types.InlinePrefix = prefix
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Doc")
+		require.NoError(t, err)
+		return result
+	}
+
+	result := analyze(true)
+	var names []string
+	for _, s := range result.Structs {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"Doc", "DocOwner"}, names, "the meta structs are inlined away")
+
+	fields := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fields[f.GoName] = f
+	}
+	require.Contains(t, fields, "MetaVersion")
+	assert.Equal(t, "int64", fields["MetaVersion"].GoType.Name)
+	assert.Equal(t, "meta", fields["MetaVersion"].InlinedFrom)
+	assert.Equal(t, "version", fields["MetaVersion"].InlinedTag)
+	assert.Equal(t, "`json:\"-\"`", fields["MetaVersion"].JSONTag)
+	assert.Equal(t, "created_by", fields["MetaCreatedBy"].InlinedTag)
+	assert.NotContains(t, fields, "Meta")
+	assert.Equal(t, "meta", result.Structs[1].Fields[0].InlinedFrom)
+
+	// Without the prefix, only names colliding with the parent's fields are prefixed
+	fields = make(map[string]models.FieldInfo)
+	for _, f := range analyze(false).Structs[0].Fields {
+		fields[f.GoName] = f
+	}
+	assert.Contains(t, fields, "Version")
+	assert.Contains(t, fields, "CreatedBy")
+	assert.Equal(t, "name", fields["Name"].JSONKey)
+	assert.Equal(t, "meta", fields["MetaName"].InlinedFrom)
+}
+
+func TestAnalyze_NestedJSONFields(t *testing.T) {
+	analyze := func(input string) models.AnalysisResult {
+		cfg := config.NewConfig()
+		cfg.Types.NestedJSONFields = []string{"^payload$"}
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Event")
+		require.NoError(t, err)
+		return result
+	}
+
+	result := analyze(`[{"payload": "{\"a\": 1}", "note": "{}"}, {"payload": "{\"a\": 2, \"b\": \"x\"}", "note": "{}"}]`)
+	assert.Empty(t, result.Warnings)
+	fields := make(map[string]models.FieldInfo)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			fields[f.JSONKey] = f
+		}
+	}
+	assert.Equal(t, models.Struct, fields["payload"].GoType.Kind)
+	assert.True(t, fields["payload"].NestedJSON)
+	assert.Contains(t, fields, "b", "the decoded objects merge")
+	assert.Equal(t, "string", fields["note"].GoType.Name, "only matching keys are decoded")
+	assert.False(t, fields["note"].NestedJSON)
+
+	result = analyze(`{"payload": "not json"}`)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, models.WarningNestedJSON, result.Warnings[0].Type)
+	assert.Equal(t, "string", result.Structs[0].Fields[0].GoType.Name)
+	assert.False(t, result.Structs[0].Fields[0].NestedJSON)
+}
+
+func TestAnalyze_PerShapeStructs(t *testing.T) {
+	input := `[{"id": 1, "name": "Ann", "email": "ann@example.com"}, {"id": 2, "sku": "A-1", "price": 9.5}, {"id": 3, "name": "Bob", "email": "bob@example.com"}]`
+
+	analyze := func(perShape bool) models.AnalysisResult {
+		cfg := config.NewConfig()
+		cfg.Arrays.PerShapeStructs = perShape
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Item")
+		require.NoError(t, err)
+		return result
+	}
+
+	result := analyze(true)
+	structs := make(map[string][]string)
+	for _, s := range result.Structs {
+		for _, f := range s.Fields {
+			structs[s.Name] = append(structs[s.Name], f.JSONKey)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"EmailItem": {"email", "id", "name"},
+		"PriceItem": {"id", "price", "sku"},
+	}, structs)
+
+	require.Len(t, result.NamedTypes, 1)
+	assert.Equal(t, "Items", result.NamedTypes[0].Name)
+	assert.Equal(t, "[]interface{}", result.NamedTypes[0].Type.Name)
+	assert.Contains(t, result.NamedTypes[0].Comment, "EmailItem and PriceItem")
+	require.NotNil(t, result.Root)
+	assert.Equal(t, "Items", result.Root.Name)
+
+	// Without the option the shapes merge into one struct
+	result = analyze(false)
+	require.Len(t, result.Structs, 1)
+	assert.Len(t, result.Structs[0].Fields, 5)
+}
+
+func TestAnalyze_InferMaps(t *testing.T) {
+	input := `{"scores": {"alice": 1, "bob": 2}, "users": {"u1": {"name": "a"}, "u2": {"name": "b"}}, "point": {"x": 1, "y": "2"}}`
+
+	for _, tt := range []struct {
+		name          string
+		valuePointers bool
+		wantUsers     string
+	}{
+		{name: "values", wantUsers: "map[string]RootUser"},
+		{name: "pointers", valuePointers: true, wantUsers: "map[string]*RootUser"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Types.InferMaps = 2
+			cfg.Types.MapValuePointers = tt.valuePointers
+
+			ir, err := parser.ParseString(input)
+			require.NoError(t, err)
+			result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+			require.NoError(t, err)
+
+			fields := make(map[string]models.TypeInfo)
+			structNames := make([]string, 0, len(result.Structs))
+			for _, s := range result.Structs {
+				structNames = append(structNames, s.Name)
+				if s.IsRoot {
+					for _, f := range s.Fields {
+						fields[f.JSONKey] = f.GoType
+					}
+				}
+			}
+
+			assert.Equal(t, "map[string]int64", fields["scores"].Name)
+			assert.Equal(t, tt.wantUsers, fields["users"].Name)
+			require.NotNil(t, fields["users"].MapValueType)
+			assert.Equal(t, tt.valuePointers, fields["users"].MapValueType.IsPointer)
+			// Values of different types keep the object a struct
+			assert.Equal(t, models.Struct, fields["point"].Kind)
+			assert.ElementsMatch(t, []string{"Root", "RootUser", "RootPoint"}, structNames)
+		})
+	}
+}
+
+func TestAnalyze_InferMapsOfSlices(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.InferMaps = 2
+
+	input := `{
+		"hours": {"mon": [1, 2], "tue": [3], "wed": []},
+		"visits": {"mon": [{"by": "a"}], "tue": [{"by": "b", "note": "late"}]},
+		"mixed": {"mon": [1], "tue": ["a"]}
+	}`
+	ir, err := parser.ParseString(input)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Week")
+	require.NoError(t, err)
+
+	fields := make(map[string]models.TypeInfo)
+	for _, s := range result.Structs {
+		if s.IsRoot {
+			for _, f := range s.Fields {
+				fields[f.JSONKey] = f.GoType
+			}
+		}
+	}
+	assert.Equal(t, "map[string][]int64", fields["hours"].Name)
+	require.NotNil(t, fields["hours"].MapValueType)
+	assert.Equal(t, models.Slice, fields["hours"].MapValueType.Kind)
+	assert.Equal(t, "map[string][]*WeekVisit", fields["visits"].Name)
+	// Arrays with elements of different types keep the object a struct
+	assert.Equal(t, models.Struct, fields["mixed"].Kind)
+
+}
+
+func TestAnalyze_IDKeyedAsMap(t *testing.T) {
+	input := `{
+		"orders": {"123": {"total": 5}, "456": {"total": 7, "note": "gift"}},
+		"sessions": {"0b5e6a1c-2f3d-4e5f-8a9b-0c1d2e3f4a5b": {"active": true}},
+		"labels": {"123": "a", "456": "b"},
+		"meta": {"123": {"total": 1}, "name": {"total": 2}}
+	}`
+
+	analyze := func(idKeyed bool) map[string]models.TypeInfo {
+		cfg := config.NewConfig()
+		cfg.Types.IDKeyedAsMap = idKeyed
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+
+		fields := make(map[string]models.TypeInfo)
+		for _, s := range result.Structs {
+			if s.IsRoot {
+				for _, f := range s.Fields {
+					fields[f.JSONKey] = f.GoType
+				}
+			}
+		}
+		return fields
+	}
+
+	fields := analyze(true)
+	// Numeric keys, with the values merged into one struct
+	assert.Equal(t, "map[string]*RootOrder", fields["orders"].Name)
+	require.NotNil(t, fields["orders"].MapValueType)
+	assert.True(t, fields["orders"].MapValueType.IsPointer)
+	// UUID keys, even with a single entry
+	assert.Equal(t, "map[string]*RootSession", fields["sessions"].Name)
+	// Scalar values are left to types.infer_maps
+	assert.Equal(t, models.Struct, fields["labels"].Kind)
+	// Any key that isn't an ID keeps the object a struct
+	assert.Equal(t, models.Struct, fields["meta"].Kind)
+
+	assert.Equal(t, models.Struct, analyze(false)["orders"].Kind)
+}
+
+func TestAnalyze_PluralizeSliceFields(t *testing.T) {
+	input := `{
+		"child": [{"name": "a"}],
+		"tag": ["x"],
+		"items": [1],
+		"data": [2],
+		"person": {"name": "b"},
+		"box": [true],
+		"boxes": 3,
+		"group": [{"member": ["m"]}, {"member": ["n"]}]
+	}`
+
+	analyze := func(pluralize bool) (map[string]string, map[string]string) {
+		cfg := config.NewConfig()
+		cfg.Naming.PluralizeSliceFields = pluralize
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+
+		names := make(map[string]string)
+		tags := make(map[string]string)
+		for _, s := range result.Structs {
+			for _, f := range s.Fields {
+				names[f.JSONKey] = f.GoName
+				tags[f.JSONKey] = f.JSONTag
+			}
+		}
+		return names, tags
+	}
+
+	names, tags := analyze(true)
+	assert.Equal(t, "Children", names["child"])
+	assert.Equal(t, "`json:\"child,omitempty\"`", tags["child"])
+	assert.Equal(t, "Tags", names["tag"])
+	// Already plural or uncountable
+	assert.Equal(t, "Items", names["items"])
+	assert.Equal(t, "Data", names["data"])
+	// Not a slice
+	assert.Equal(t, "Person", names["person"])
+	// The plural is taken by another field
+	assert.Equal(t, "Box", names["box"])
+	// Slices inside merged array elements
+	assert.Equal(t, "Members", names["member"])
+
+	names, _ = analyze(false)
+	assert.Equal(t, "Child", names["child"])
+}
+
+func TestAnalyze_DiscoveryOrder(t *testing.T) {
+	ir, err := parser.ParseString(`{"boxes": [{"lid": {"a": 1}}], "box_label": {"b": 1}}`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzer().Analyze(ir, "Shelf")
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range result.Structs {
+		names = append(names, s.Name)
+	}
+	// The root, then each struct followed by the ones its fields use, in key order
+	assert.Equal(t, []string{"Shelf", "ShelfBoxLabel", "ShelfBox", "ShelfBoxLid"}, names)
+}
+
+// BenchmarkAnalyze_ManyStructs analyzes a wide object of distinct nested objects,
+// where every new struct is checked against all those found before it
+func BenchmarkAnalyze_ManyStructs(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `"obj_%d": {"id": %d, "name": "n", "field_%d": true}`, i, i, i)
+	}
+	sb.WriteString("}")
+	ir, err := parser.ParseString(sb.String())
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewAnalyzer().Analyze(ir, "Root"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAnalyzer_Reset(t *testing.T) {
+	ir, err := parser.ParseString(`{"owner": {"name": "ann"}}`)
+	require.NoError(t, err)
+
+	a := NewAnalyzer()
+	first, err := a.Analyze(ir, "Repo")
+	require.NoError(t, err)
+
+	a.Reset()
+	second, err := a.Analyze(ir, "Repo")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestAnalyzer_ConcurrentAnalyze(t *testing.T) {
+	ir, err := parser.ParseString(`{"id": 1, "tags": ["a"], "owner": {"name": "ann"}}`)
+	require.NoError(t, err)
+	cfg := config.NewConfig()
+	cfg.Types.Mappings = []config.TypeMapping{{Pattern: "^id$", Type: "int32"}}
+
+	shared := NewAnalyzerWithConfig(cfg)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// One analyzer per goroutine sharing the config, plus one shared analyzer
+			_, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Repo")
+			assert.NoError(t, err)
+			_, err = shared.Analyze(ir, "Repo")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAnalyze_ReusedAnalyzerIsIndependent(t *testing.T) {
+	userIR, err := parser.ParseString(`{"id": 1, "address": {"city": "Oslo"}}`)
+	require.NoError(t, err)
+	orderIR, err := parser.ParseString(`{"total": 9.5, "placed_at": "2024-01-02T03:04:05Z", "address": {"city": "Oslo"}}`)
+	require.NoError(t, err)
+
+	a := NewAnalyzer()
+	user, err := a.Analyze(userIR, "Root")
+	require.NoError(t, err)
+	order, err := a.Analyze(orderIR, "Root")
+	require.NoError(t, err)
+
+	freshUser, err := NewAnalyzer().Analyze(userIR, "Root")
+	require.NoError(t, err)
+	freshOrder, err := NewAnalyzer().Analyze(orderIR, "Root")
+	require.NoError(t, err)
+
+	// No "Root2" or reused address struct from the first call, and the first
+	// result is untouched by the second
+	assert.Equal(t, freshOrder, order)
+	assert.Equal(t, freshUser, user)
+	assert.Contains(t, order.Imports, "time")
+	assert.NotContains(t, user.Imports, "time")
+}
+
+func TestSanitizeRootName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"RootType":    "RootType",
+		"userData":    "UserData",
+		"user_data":   "UserData",
+		"my-type":     "MyType",
+		"api v2.0":    "ApiV20",
+		"2cool":       "Type2cool",
+		"_private":    "Private",
+		"héllo wörld": "HélloWörld",
+	} {
+		actual, err := SanitizeRootName(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	for _, input := range []string{"", "-", "$%!"} {
+		_, err := SanitizeRootName(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestAnalyze_SanitizesRootName(t *testing.T) {
+	ir, err := parser.ParseString(`{"id": 1}`)
+	require.NoError(t, err)
+
+	result, err := NewAnalyzer().Analyze(ir, "my-type")
+	require.NoError(t, err)
+	require.Len(t, result.Structs, 1)
+	assert.Equal(t, "MyType", result.Structs[0].Name)
+	assert.Equal(t, []string{`root name "my-type" is not a valid exported Go identifier, using "MyType"`}, warningMessages(result, models.WarningRootName))
+
+	result, err = NewAnalyzer().Analyze(ir, "Order")
+	require.NoError(t, err)
+	assert.Equal(t, "Order", result.Structs[0].Name)
+	assert.Empty(t, result.Warnings)
+
+	_, err = NewAnalyzer().Analyze(ir, "???")
+	assert.Error(t, err)
+}
+
+func TestAnalyze_ExcludeFields(t *testing.T) {
+	input := `{
+		"id": 1,
+		"debug_info": {"trace": "root"},
+		"user": {"name": "a", "debug_info": {"trace": "x"}, "profile": {"bio": "b", "debug_info": "y"}},
+		"items": [{"k": 1, "debug_info": {"n": 1}}, {"k": 2}],
+		"blob": {"data": "..."}
+	}`
+
+	analyze := func(patterns ...string) map[string][]string {
+		cfg := config.NewConfig()
+		cfg.JSONTags.ExcludeFields = patterns
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+
+		keys := make(map[string][]string)
+		for _, s := range result.Structs {
+			keys[s.Name] = nil
+			for _, f := range s.Fields {
+				keys[s.Name] = append(keys[s.Name], f.JSONKey)
+			}
+		}
+		return keys
+	}
+
+	// Nested debug_info fields go everywhere, with the structs they would have needed
+	keys := analyze("*.debug_info")
+	assert.Equal(t, []string{"blob", "debug_info", "id", "items", "user"}, keys["Root"])
+	assert.Equal(t, []string{"name", "profile"}, keys["RootUser"])
+	assert.Equal(t, []string{"bio"}, keys["RootUserProfile"])
+	assert.Equal(t, []string{"k"}, keys["RootItem"])
+	assert.Contains(t, keys, "RootDebugInfo")
+	assert.NotContains(t, keys, "RootUserDebugInfo")
+	assert.NotContains(t, keys, "RootItemDebugInfo")
+
+	// A pattern without a dot matches the key at any depth
+	keys = analyze("debug_info", "bl?b")
+	assert.Equal(t, []string{"id", "items", "user"}, keys["Root"])
+	assert.Equal(t, []string{"name", "profile"}, keys["RootUser"])
+	assert.NotContains(t, keys, "RootBlob")
+
+	// Dotted paths are anchored at the root
+	keys = analyze("user.profile")
+	assert.Equal(t, []string{"debug_info", "name"}, keys["RootUser"])
+	assert.NotContains(t, keys, "RootUserProfile")
+}
+
+func TestAnalyze_OptionalThreshold(t *testing.T) {
+	// nickname is in 9 of 10 objects (90%), coupon in 1 (10%)
+	elements := make([]string, 10)
+	for i := range elements {
+		switch i {
+		case 0:
+			elements[i] = `{"id": 0, "nickname": "n0", "coupon": "SAVE"}`
+		case 9:
+			elements[i] = `{"id": 9}`
+		default:
+			elements[i] = fmt.Sprintf(`{"id": %d, "nickname": "n%d"}`, i, i)
+		}
+	}
+	input := "[" + strings.Join(elements, ",") + "]"
+
+	analyze := func(threshold float64) map[string]models.FieldInfo {
+		cfg := config.NewConfig()
+		cfg.Types.OptionalThreshold = threshold
+		ir, err := parser.ParseString(input)
+		require.NoError(t, err)
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Orders")
+		require.NoError(t, err)
+		require.Len(t, result.Structs, 1)
+
+		fields := make(map[string]models.FieldInfo)
+		for _, f := range result.Structs[0].Fields {
+			fields[f.JSONKey] = f
+		}
+		return fields
+	}
+
+	// Default: anything missing is optional, but keeps its type
+	fields := analyze(0)
+	assert.True(t, fields["nickname"].Optional)
+	assert.True(t, fields["coupon"].Optional)
+	assert.False(t, fields["nickname"].GoType.IsPointer)
+	assert.False(t, fields["id"].Optional)
+
+	// 90% clears an 0.8 threshold, so nickname is required
+	fields = analyze(0.8)
+	assert.False(t, fields["nickname"].Optional)
+	assert.Equal(t, "`json:\"nickname\"`", fields["nickname"].JSONTag)
+	assert.True(t, fields["coupon"].Optional)
+	assert.True(t, fields["coupon"].GoType.IsPointer)
+	assert.Equal(t, "`json:\"coupon,omitempty\"`", fields["coupon"].JSONTag)
+
+	// Exactly 90% meets a 0.9 threshold
+	fields = analyze(0.9)
+	assert.False(t, fields["nickname"].Optional)
+
+	// Under a 0.95 threshold both are optional pointers; id, present everywhere, never is
+	fields = analyze(0.95)
+	assert.True(t, fields["nickname"].Optional)
+	assert.True(t, fields["nickname"].GoType.IsPointer)
+	assert.True(t, fields["coupon"].GoType.IsPointer)
+	assert.False(t, fields["id"].Optional)
+	assert.False(t, fields["id"].GoType.IsPointer)
+}
+
+func TestAnalyze_UnmatchedConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.Mappings = []config.TypeMapping{
+		{Pattern: "^emial$", Type: "string"},
+		{Pattern: "_id$", Type: "int64"},
+	}
+	cfg.JSONTags.CustomOptions = []config.TagOption{{Pattern: "^secret$", Options: "-"}}
+	cfg.JSONTags.SkipFields = []string{"debug", "internal"}
+	cfg.Validation.Rules = []config.ValidationRule{{Pattern: "^phone$", Tag: `validate:"e164"`}}
+
+	ir, err := parser.ParseString(`{"email": "a@b.c", "items": [{"user_id": 1, "debug": true}]}`)
+	require.NoError(t, err)
+	result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+	require.NoError(t, err)
+
+	// Keys nested in array elements count; validation rules only matter when enabled
+	assert.Equal(t, []string{
+		`types.mappings pattern "^emial$" matched no field`,
+		`json_tags.custom_options pattern "^secret$" matched no field`,
+		`json_tags.skip_fields entry "internal" matched no field`,
+	}, warningMessages(result, models.WarningUnmatchedConfig))
+
+	cfg.Validation.Enabled = true
+	result, err = NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+	require.NoError(t, err)
+	assert.Contains(t, warningMessages(result, models.WarningUnmatchedConfig), `validation.rules pattern "^phone$" matched no field`)
+}
+
+// warningMessages returns the messages of a result's warnings of one type
+func warningMessages(result models.AnalysisResult, kind string) []string {
+	var messages []string
+	for _, warning := range result.Warnings {
+		if warning.Type == kind {
+			messages = append(messages, warning.Message)
+		}
+	}
+	return messages
+}
+
+func TestAnalyze_TagCase(t *testing.T) {
+	ir, err := parser.ParseString(`{"UserName": "ann", "api-Key": "k"}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		tagCase  string
+		userName string
+		apiKey   string
+	}{
+		{config.TagCaseOriginal, "UserName", "api-Key"},
+		{config.TagCaseLower, "username", "api-key"},
+		{config.TagCaseUpper, "USERNAME", "API-KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tagCase, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.JSONTags.TagCase = tt.tagCase
+			result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+			require.NoError(t, err)
+
+			fields := make(map[string]models.FieldInfo)
+			for _, field := range result.Structs[0].Fields {
+				fields[field.JSONKey] = field
+			}
+			// Only the tag changes; field names still come from the original key
+			assert.Equal(t, "UserName", fields["UserName"].GoName)
+			assert.Equal(t, "ApiKey", fields["api-Key"].GoName)
+			assert.Equal(t, fmt.Sprintf("`json:\"%s\"`", tt.userName), fields["UserName"].JSONTag)
+			assert.Equal(t, fmt.Sprintf("`json:\"%s\"`", tt.apiKey), fields["api-Key"].JSONTag)
+		})
+	}
+}
+
+func TestAnalyze_ScientificIntegers(t *testing.T) {
+	ir, err := parser.ParseString(`{"a": 1e3, "b": 1.5e3, "c": 1.5e-1, "d": 1e30, "items": [{"n": 1000}, {"n": 1E3}]}`)
+	require.NoError(t, err)
+
+	fieldTypes := func(cfg *config.Config) map[string]string {
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+		types := make(map[string]string)
+		for _, structDef := range result.Structs {
+			for _, field := range structDef.Fields {
+				types[field.JSONKey] = field.GoType.Name
+			}
+		}
+		return types
+	}
+
+	// By default exponent notation means float64, which decodes either form
+	types := fieldTypes(config.NewConfig())
+	assert.Equal(t, "float64", types["a"])
+	assert.Equal(t, "float64", types["b"])
+
+	cfg := config.NewConfig()
+	cfg.Types.ScientificIntegers = true
+	types = fieldTypes(cfg)
+	assert.Equal(t, "int64", types["a"])
+	assert.Equal(t, "int64", types["b"], "1.5e3 is 1500")
+	assert.Equal(t, "float64", types["c"])
+	assert.Equal(t, "float64", types["d"], "out of int64 range")
+	assert.Equal(t, "int64", types["n"], "1000 and 1E3 merge without a conflict")
+}
+
+func TestAnalyze_BigNumberType(t *testing.T) {
+	ir, err := parser.ParseString(`[
+		{"id": 123456789012345678901234567890, "pi": 3.14159265358979323846264, "count": 1, "price": 1.5},
+		{"id": 7, "pi": 2.5, "count": 99999999999999999999, "price": 2}
+	]`)
+	require.NoError(t, err)
+
+	analyze := func(cfg *config.Config) (map[string]models.TypeInfo, models.AnalysisResult) {
+		result, err := NewAnalyzerWithConfig(cfg).Analyze(ir, "Root")
+		require.NoError(t, err)
+		require.Len(t, result.Structs, 1)
+		types := make(map[string]models.TypeInfo)
+		for _, field := range result.Structs[0].Fields {
+			types[field.JSONKey] = field.GoType
+		}
+		return types, result
+	}
+
+	// By default out-of-range numbers are float64, losing digits
+	types, _ := analyze(config.NewConfig())
+	assert.Equal(t, "float64", types["id"].Name)
+
+	cfg := config.NewConfig()
+	cfg.Types.BigNumberType = config.BigNumberBig
+	types, result := analyze(cfg)
+	assert.Equal(t, "*big.Int", generatorTypeString(types["id"]), "a 30-digit integer")
+	assert.Equal(t, "*big.Int", generatorTypeString(types["count"]), "merged with an int64")
+	assert.Equal(t, "*big.Float", generatorTypeString(types["pi"]), "more digits than float64 keeps")
+	assert.Equal(t, "float64", generatorTypeString(types["price"]))
+	assert.Contains(t, result.Imports, "math/big")
+	assert.Equal(t, []string{"pi needs more range or precision than float64 has; *big.Float only decodes from JSON strings, so numbers need custom unmarshaling"},
+		warningMessages(result, models.WarningBigNumber))
+
+	cfg.Types.BigNumberType = config.BigNumberJSONNumber
+	types, result = analyze(cfg)
+	assert.Equal(t, "json.Number", generatorTypeString(types["id"]))
+	assert.Equal(t, "json.Number", generatorTypeString(types["pi"]))
+	assert.Equal(t, "float64", generatorTypeString(types["price"]))
+	assert.Contains(t, result.Imports, "encoding/json")
+}
+
+// generatorTypeString writes a type as the generator would, with a * for pointers
+func generatorTypeString(typeInfo models.TypeInfo) string {
+	if typeInfo.IsPointer {
+		return "*" + typeInfo.Name
+	}
+	return typeInfo.Name
+}
@@ -0,0 +1,65 @@
+// Package cache keeps fetched URL responses on disk so repeated runs don't refetch them
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores response bodies in Dir, one file per URL, named by a hash of the URL
+type Cache struct {
+	Dir string
+	TTL time.Duration // Entries older than TTL are stale; zero means nothing is fresh
+
+	now func() time.Time
+}
+
+// New creates a Cache in dir whose entries stay fresh for ttl
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, now: time.Now}
+}
+
+// Get returns the cached body for url if it was stored within the TTL
+func (c *Cache) Get(url string) ([]byte, bool) {
+	path := c.path(url)
+	info, err := os.Stat(path)
+	if err != nil || c.now().Sub(info.ModTime()) >= c.TTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores body for url, replacing any earlier entry
+func (c *Cache) Put(url string, body []byte) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a concurrent Get never sees a partial body
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(url))
+}
+
+// path returns the file holding the entry for url
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,79 @@
+package compat
+
+import (
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// generatedParagraphs start the paragraphs of struct and field doc comments that the
+// generator writes itself, which would be repeated if they were carried over
+var generatedParagraphs = []string{
+	"Source: ",
+	"Deprecated:",
+	"Required (in every sample):",
+	"Optional (missing from some samples):",
+}
+
+// PreserveComments copies the comments of existing structs onto the matching structs
+// and fields of result, replacing the ones generated for them. Structs are matched by
+// name and fields by JSON key, so a comment survives changes to the field's type or Go
+// name. Paragraphs the generator writes itself, such as "Deprecated:" notes, are left
+// for it to regenerate.
+func PreserveComments(existing []models.StructDef, result *models.AnalysisResult) {
+	old := make(map[string]models.StructDef, len(existing))
+	for _, structDef := range existing {
+		old[structDef.Name] = structDef
+	}
+
+	for i := range result.Structs {
+		structDef := &result.Structs[i]
+		previous, ok := old[structDef.Name]
+		if !ok {
+			continue
+		}
+		if comment := handWritten(previous.Comment); comment != "" {
+			structDef.Comment = comment
+		}
+
+		fields := make(map[string]models.FieldInfo, len(previous.Fields))
+		for _, field := range previous.Fields {
+			fields[field.JSONKey] = field
+		}
+		for j := range structDef.Fields {
+			field := &structDef.Fields[j]
+			previousField, ok := fields[field.JSONKey]
+			if !ok {
+				continue
+			}
+			if previousField.Comment != "" {
+				field.Comment = previousField.Comment
+			}
+			if doc := handWritten(previousField.Doc); doc != "" {
+				field.Doc = doc
+			}
+		}
+	}
+}
+
+// handWritten drops the generated paragraphs from a doc comment
+func handWritten(comment string) string {
+	var kept []string
+	for _, paragraph := range strings.Split(comment, "\n\n") {
+		if !isGenerated(paragraph) {
+			kept = append(kept, paragraph)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n\n"))
+}
+
+// isGenerated reports whether a doc comment paragraph is one the generator writes
+func isGenerated(paragraph string) bool {
+	paragraph = strings.TrimSpace(paragraph)
+	for _, prefix := range generatedParagraphs {
+		if strings.HasPrefix(paragraph, prefix) {
+			return true
+		}
+	}
+	return false
+}
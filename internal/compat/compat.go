@@ -0,0 +1,203 @@
+// Package compat compares a new analysis result against structs generated earlier,
+// reporting the differences that would break code using them, and carries the
+// comments written on those structs over to the new result
+package compat
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/generator"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// Kinds of breaking change
+const (
+	RemovedType   = "removed type"
+	RemovedField  = "removed field"
+	ChangedType   = "changed type"
+	AddedRequired = "added required field"
+)
+
+// Difference is a breaking change to one struct or field
+type Difference struct {
+	Kind    string
+	Struct  string
+	Field   string // JSON key of the field; empty for RemovedType
+	OldType string
+	NewType string
+}
+
+// String describes the difference for a report, e.g. `User.id: changed type int64 -> string`
+func (d Difference) String() string {
+	switch d.Kind {
+	case RemovedType:
+		return fmt.Sprintf("%s: %s", d.Struct, d.Kind)
+	case ChangedType:
+		return fmt.Sprintf("%s.%s: %s %s -> %s", d.Struct, d.Field, d.Kind, d.OldType, d.NewType)
+	case RemovedField:
+		return fmt.Sprintf("%s.%s: %s (was %s)", d.Struct, d.Field, d.Kind, d.OldType)
+	default:
+		return fmt.Sprintf("%s.%s: %s (%s)", d.Struct, d.Field, d.Kind, d.NewType)
+	}
+}
+
+// ParseFile reads the struct declarations of the Go source file at path
+func ParseFile(path string) ([]models.StructDef, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Go file: %w", err)
+	}
+	return ParseSource(path, src)
+}
+
+// ParseSource reads the struct declarations of Go source. Each field's type is kept as
+// written in TypeInfo.Name, with IsPointer set for pointer types; embedded fields and
+// fields tagged json:"-" are left out. Doc comments become StructDef.Comment and
+// FieldInfo.Doc, and trailing field comments FieldInfo.Comment.
+func ParseSource(filename string, src []byte) ([]models.StructDef, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution|parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Go source: %w", err)
+	}
+
+	var structs []models.StructDef
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+			structDef := models.StructDef{Name: typeSpec.Name.Name, Comment: commentText(doc)}
+			for _, field := range structType.Fields.List {
+				structDef.Fields = append(structDef.Fields, parseFields(fset, field)...)
+			}
+			structs = append(structs, structDef)
+		}
+	}
+	return structs, nil
+}
+
+// parseFields converts one field declaration, which may name several fields
+func parseFields(fset *token.FileSet, field *ast.Field) []models.FieldInfo {
+	var tag string
+	if field.Tag != nil {
+		tag = field.Tag.Value
+	}
+	key, _ := tags.Lookup(tag, "json")
+	if key == "-" {
+		return nil
+	}
+	key, _, _ = strings.Cut(key, ",")
+
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, field.Type)
+	_, isPointer := field.Type.(*ast.StarExpr)
+	typeInfo := models.TypeInfo{Name: buf.String(), IsPointer: isPointer}
+
+	var fields []models.FieldInfo
+	for _, name := range field.Names {
+		if !name.IsExported() {
+			continue
+		}
+		jsonKey := key
+		if jsonKey == "" {
+			jsonKey = name.Name
+		}
+		fields = append(fields, models.FieldInfo{
+			JSONKey: jsonKey,
+			GoName:  name.Name,
+			GoType:  typeInfo,
+			JSONTag: tag,
+			Comment: strings.ReplaceAll(commentText(field.Comment), "\n", " "),
+			Doc:     commentText(field.Doc),
+		})
+	}
+	return fields
+}
+
+// commentText returns the text of a comment group without its comment markers and
+// trailing newline, or "" for none
+func commentText(group *ast.CommentGroup) string {
+	return strings.TrimSpace(group.Text())
+}
+
+// Compare reports the breaking differences between existing structs and a new result:
+// structs or fields that were removed, fields whose Go type changed, and new fields that
+// are neither pointers nor omitempty. Structs and fields are matched by name and JSON key.
+func Compare(existing []models.StructDef, result models.AnalysisResult) []Difference {
+	current := make(map[string]models.StructDef, len(result.Structs))
+	for _, structDef := range result.Structs {
+		current[structDef.Name] = structDef
+	}
+
+	var diffs []Difference
+	for _, old := range existing {
+		updated, ok := current[old.Name]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: RemovedType, Struct: old.Name})
+			continue
+		}
+
+		fields := make(map[string]models.FieldInfo, len(updated.Fields))
+		for _, field := range updated.Fields {
+			fields[field.JSONKey] = field
+		}
+		for _, oldField := range old.Fields {
+			newField, ok := fields[oldField.JSONKey]
+			delete(fields, oldField.JSONKey)
+			switch {
+			case !ok:
+				diffs = append(diffs, Difference{Kind: RemovedField, Struct: old.Name, Field: oldField.JSONKey, OldType: oldField.GoType.Name})
+			case generator.TypeString(newField.GoType) != oldField.GoType.Name:
+				diffs = append(diffs, Difference{
+					Kind:    ChangedType,
+					Struct:  old.Name,
+					Field:   oldField.JSONKey,
+					OldType: oldField.GoType.Name,
+					NewType: generator.TypeString(newField.GoType),
+				})
+			}
+		}
+
+		// Fields left over are new; keep the result's field order for the report
+		for _, field := range updated.Fields {
+			if _, added := fields[field.JSONKey]; added && required(field) {
+				diffs = append(diffs, Difference{Kind: AddedRequired, Struct: old.Name, Field: field.JSONKey, NewType: generator.TypeString(field.GoType)})
+			}
+		}
+	}
+	return diffs
+}
+
+// required reports whether a field must be present in the JSON
+func required(field models.FieldInfo) bool {
+	if field.GoType.IsPointer {
+		return false
+	}
+	value, _ := tags.Lookup(field.JSONTag, "json")
+	_, options, _ := strings.Cut(value, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			return false
+		}
+	}
+	return true
+}
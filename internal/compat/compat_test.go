@@ -0,0 +1,107 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/mcncl/gotyper/internal/analyzer"
+	"github.com/mcncl/gotyper/internal/generator"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generate returns the Go file generated from input and its differences from the same analysis
+func generate(t *testing.T, input string) ([]byte, []Difference) {
+	t.Helper()
+	ir, err := parser.ParseString(input)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzer().Analyze(ir, "User")
+	require.NoError(t, err)
+	code, err := generator.NewGenerator().GenerateStructs(result, "models")
+	require.NoError(t, err)
+
+	existing, err := ParseSource("user.go", []byte(code))
+	require.NoError(t, err)
+	return []byte(code), Compare(existing, result)
+}
+
+func TestCompare_Unchanged(t *testing.T) {
+	_, diffs := generate(t, `{"id": 1, "name": "Ann", "address": {"city": "Oslo"}}`)
+	assert.Empty(t, diffs)
+}
+
+func TestCompare_BreakingChanges(t *testing.T) {
+	code, _ := generate(t, `{"id": 1, "name": "Ann", "address": {"city": "Oslo"}, "nickname": "A"}`)
+	existing, err := ParseSource("user.go", code)
+	require.NoError(t, err)
+
+	ir, err := parser.ParseString(`{"id": "u-1", "name": "Ann", "active": true, "tags": ["x"]}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzer().Analyze(ir, "User")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []Difference{
+		{Kind: ChangedType, Struct: "User", Field: "id", OldType: "int64", NewType: "string"},
+		{Kind: RemovedField, Struct: "User", Field: "address", OldType: "*UserAddress"},
+		{Kind: RemovedField, Struct: "User", Field: "nickname", OldType: "string"},
+		{Kind: AddedRequired, Struct: "User", Field: "active", NewType: "bool"},
+		{Kind: RemovedType, Struct: "UserAddress"},
+	}, Compare(existing, result))
+}
+
+func TestParseSource(t *testing.T) {
+	structs, err := ParseSource("models.go", []byte("package models\n\n"+
+		"import \"time\"\n\n"+
+		"type Event struct {\n"+
+		"\tAt, Until time.Time `json:\"at\"`\n"+
+		"\tNote *string `json:\"note,omitempty\"`\n"+
+		"\tSecret string `json:\"-\"`\n"+
+		"\tinternal int\n"+
+		"\tRaw []byte\n"+
+		"}\n\n"+
+		"type Kind string\n"))
+	require.NoError(t, err)
+	require.Len(t, structs, 1)
+
+	var summary []string
+	for _, field := range structs[0].Fields {
+		summary = append(summary, field.GoName+" "+field.JSONKey+" "+field.GoType.Name)
+	}
+	assert.Equal(t, []string{"At at time.Time", "Until at time.Time", "Note note *string", "Raw Raw []byte"}, summary)
+	assert.True(t, structs[0].Fields[2].GoType.IsPointer)
+	assert.Equal(t, "Event.note: changed type *string -> string", Difference{Kind: ChangedType, Struct: "Event", Field: "note", OldType: "*string", NewType: "string"}.String())
+}
+
+func TestPreserveComments(t *testing.T) {
+	existing, err := ParseSource("user.go", []byte("package models\n\n"+
+		"// User is an account holder.\n"+
+		"//\n"+
+		"// Source: $\n"+
+		"type User struct {\n"+
+		"\t// ID is assigned by billing.\n"+
+		"\t//\n"+
+		"\t// Deprecated: field is deprecated.\n"+
+		"\tID int64 `json:\"id\"`\n"+
+		"\tName string `json:\"name\"` // Display name\n"+
+		"\tOld string `json:\"old\"` // Gone\n"+
+		"}\n"))
+	require.NoError(t, err)
+
+	ir, err := parser.ParseString(`{"id": "u-1", "name": "Ann", "email": "a@example.com"}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzer().Analyze(ir, "User")
+	require.NoError(t, err)
+	PreserveComments(existing, &result)
+
+	require.Len(t, result.Structs, 1)
+	assert.Equal(t, "User is an account holder.", result.Structs[0].Comment)
+	fields := make(map[string]models.FieldInfo)
+	for _, field := range result.Structs[0].Fields {
+		fields[field.JSONKey] = field
+	}
+	assert.Equal(t, "ID is assigned by billing.", fields["id"].Doc)
+	assert.Equal(t, "Display name", fields["name"].Comment)
+	assert.Empty(t, fields["email"].Comment)
+	assert.Empty(t, fields["email"].Doc)
+}
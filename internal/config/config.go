@@ -2,9 +2,17 @@ package config
 
 import (
 	"fmt"
+	"go/version"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/iancoleman/strcase"
 	"gopkg.in/yaml.v3"
@@ -14,6 +22,7 @@ import (
 type Config struct {
 	Package    string           `yaml:"package"`
 	RootName   string           `yaml:"root_name"`
+	GoVersion  string           `yaml:"go_version"` // Target Go version, e.g. "1.22"; defaults to the toolchain gotyper was built with
 	Formatting FormattingConfig `yaml:"formatting"`
 	Types      TypesConfig      `yaml:"types"`
 	Naming     NamingConfig     `yaml:"naming"`
@@ -21,7 +30,13 @@ type Config struct {
 	Validation ValidationConfig `yaml:"validation"`
 	Output     OutputConfig     `yaml:"output"`
 	Arrays     ArraysConfig     `yaml:"arrays"`
+	Matching   MatchingConfig   `yaml:"matching"`
+	Schema     SchemaConfig     `yaml:"schema"`
 	Dev        DevConfig        `yaml:"dev"`
+
+	// FieldDirectives are per-field directives by dotted path, loaded from the
+	// input's sidecar file rather than the config file
+	FieldDirectives map[string]FieldDirective `yaml:"-"`
 }
 
 // FormattingConfig controls code formatting options
@@ -32,11 +47,73 @@ type FormattingConfig struct {
 
 // TypesConfig controls type inference and mapping
 type TypesConfig struct {
-	ForceInt64           bool          `yaml:"force_int64"`
-	OptionalAsPointers   bool          `yaml:"optional_as_pointers"`
-	UnixTimestampsAsTime bool          `yaml:"unix_timestamps_as_time"` // Convert Unix timestamps to time.Time instead of int64
-	DateFormat           string        `yaml:"date_format"`             // Preferred date format for ambiguous dates: "us" (MM/DD/YYYY) or "eu" (DD/MM/YYYY)
-	Mappings             []TypeMapping `yaml:"mappings"`
+	ForceInt64           bool            `yaml:"force_int64"`
+	OptionalAsPointers   bool            `yaml:"optional_as_pointers"`
+	UnixTimestampsAsTime bool            `yaml:"unix_timestamps_as_time"` // Convert Unix timestamps to time.Time instead of int64
+	DateFormat           string          `yaml:"date_format"`             // Preferred date format for ambiguous dates: "us" (MM/DD/YYYY) or "eu" (DD/MM/YYYY)
+	Mappings             []TypeMapping   `yaml:"mappings"`
+	DurationFields       []DurationField `yaml:"duration_fields"` // Numeric fields to generate as time.Duration
+	// PreserveNullFields types array elements that are sometimes null as pointers
+	// to the type of the other elements ([null, 5] becomes []*int64) instead of
+	// interface{}. Merged object fields that are sometimes null are always pointers.
+	PreserveNullFields bool `yaml:"preserve_null_fields"`
+	// InferMaps types nested objects with at least this many keys, all holding values of
+	// the same type, as map[string]T instead of a struct. Zero disables map inference.
+	InferMaps int `yaml:"infer_maps"`
+	// MapValuePointers makes maps of structs hold pointers (map[string]*T) rather than values
+	MapValuePointers bool `yaml:"map_value_pointers"`
+	// IDKeyedAsMap types nested objects whose keys are all numeric or UUID IDs and whose
+	// values are all objects as map[string]*T, however few keys they have
+	IDKeyedAsMap bool `yaml:"id_keyed_as_map"`
+	// OptionalThreshold is the fraction of merged objects a key must appear in for its
+	// field to be required. Rarer fields are optional and made pointers. Zero keeps the
+	// default: fields missing from any object are optional but keep their type.
+	OptionalThreshold float64 `yaml:"optional_threshold"`
+	// ScientificIntegers types numbers in exponent notation with an integral value, such
+	// as 1e3 or 1.5e3, as int64 rather than float64. encoding/json can't decode such
+	// literals into an int64, so this suits input whose producer normally writes plain integers.
+	ScientificIntegers bool `yaml:"scientific_integers"`
+	// BigNumberType is the type of numbers int64 and float64 can't hold: integers out of
+	// int64's range and numbers with more significant digits than float64 keeps.
+	// "json.Number" or "big" (*big.Int and *big.Float); empty leaves them float64.
+	BigNumberType string `yaml:"big_number_type"`
+	// Discriminator is the JSON key naming the variant of each object in an array,
+	// e.g. "type", for arrays.strategy "interface_union"
+	Discriminator string `yaml:"discriminator"`
+	// ParseFormattedNumbers types object fields holding formatted number strings, such
+	// as "12.5%" or "$1,200.00", as numbers decoded by a generated UnmarshalJSON
+	ParseFormattedNumbers bool `yaml:"parse_formatted_numbers"`
+	// InlineFields are patterns on the keys of nested objects whose fields are
+	// promoted into the parent struct instead of getting a struct of their own
+	InlineFields []string `yaml:"inline_fields"`
+	// InlinePrefix names promoted fields after the nested object's field as well,
+	// so meta.version becomes MetaVersion. Without it a promoted field keeps its own
+	// name unless that collides with another field of the parent.
+	InlinePrefix bool `yaml:"inline_prefix"`
+	// NestedJSONFields are patterns on the keys of string fields holding encoded JSON,
+	// such as "payload": "{\"a\":1}". The decoded object or array is typed in place of
+	// the string, and a generated UnmarshalJSON decodes the field twice.
+	NestedJSONFields []string `yaml:"nested_json_fields"`
+}
+
+// DurationField maps numeric fields matching a pattern to time.Duration.
+// Unit is the unit the JSON number is expressed in: "ns", "us", "ms", "s", "m" or "h".
+type DurationField struct {
+	Pattern string `yaml:"pattern"`
+	Unit    string `yaml:"unit"`
+
+	// compiled regex (not serialized)
+	regex *regexp.Regexp
+}
+
+// DurationUnits maps the supported duration_fields units to their time package constants
+var DurationUnits = map[string]string{
+	"ns": "time.Nanosecond",
+	"us": "time.Microsecond",
+	"ms": "time.Millisecond",
+	"s":  "time.Second",
+	"m":  "time.Minute",
+	"h":  "time.Hour",
 }
 
 // TypeMapping defines a pattern-based type mapping
@@ -55,15 +132,47 @@ type NamingConfig struct {
 	PascalCaseFields bool              `yaml:"pascal_case_fields"`
 	FieldMappings    map[string]string `yaml:"field_mappings"`
 	CustomSingulars  map[string]string `yaml:"custom_singulars"` // Custom plural->singular mappings (e.g., "datums": "datum")
+	// TrimKeyPrefixes and TrimKeySuffixes are removed from JSON keys before naming
+	// fields, so "attr_id" becomes "Id". JSON tags keep the original key.
+	TrimKeyPrefixes []string `yaml:"trim_key_prefixes"`
+	TrimKeySuffixes []string `yaml:"trim_key_suffixes"`
+	// PluralizeSliceFields gives slice fields plural Go names ("child": [...] becomes
+	// Children); the json tag keeps the original key
+	PluralizeSliceFields bool `yaml:"pluralize_slice_fields"`
+	// MaxNameLength truncates longer generated struct and field names, keeping them
+	// unique with a hash suffix. Zero means no limit.
+	MaxNameLength int `yaml:"max_name_length"`
+	// ASCIIOnly transliterates accented Latin letters in JSON keys (naïve becomes Naive)
+	// and drops other non-ASCII characters from field names. Tags keep the original key.
+	ASCIIOnly bool `yaml:"ascii_only"`
+	// AcronymMinLength keeps runs of at least this many capitals in JSON keys as
+	// written, so HTTPStatus becomes HTTPStatus rather than Httpstatus. Zero turns
+	// acronym detection off.
+	AcronymMinLength int `yaml:"acronym_min_length"`
 }
 
+// MinMaxNameLength is the smallest non-zero naming.max_name_length, leaving room
+// for a few characters of the original name before the hash suffix
+const MinMaxNameLength = 12
+
 // JSONTagsConfig controls JSON tag generation
 type JSONTagsConfig struct {
+	// Omitempty decides which fields get omitempty: type-based (default) follows
+	// omitempty_for_pointers and omitempty_for_slices, optional-only tags only fields
+	// missing from or null in some of the merged objects, and none tags no fields
+	Omitempty            string      `yaml:"omitempty"`
 	OmitemptyForPointers bool        `yaml:"omitempty_for_pointers"`
 	OmitemptyForSlices   bool        `yaml:"omitempty_for_slices"`
+	PreferOmitzero       bool        `yaml:"prefer_omitzero"` // Use omitzero for struct-like value fields when the target Go version supports it (1.24+)
 	AdditionalTags       []string    `yaml:"additional_tags"`
+	Mapstructure         bool        `yaml:"mapstructure"` // Add mapstructure tags (lowercased JSON keys) for Viper and other config decoders
 	CustomOptions        []TagOption `yaml:"custom_options"`
 	SkipFields           []string    `yaml:"skip_fields"`
+	// ExcludeFields drops fields entirely, matched as globs against the JSON key or,
+	// for patterns with a dot, the dotted path from the root ("*.debug_info")
+	ExcludeFields []string `yaml:"exclude_fields"`
+	TagOrder      []string `yaml:"tag_order"` // Order of tag keys on each field; unlisted keys follow alphabetically
+	TagCase       string   `yaml:"tag_case"`  // Casing of json tag names: original (default), lower or upper
 }
 
 // TagOption defines custom tag options for specific fields
@@ -96,12 +205,232 @@ type OutputConfig struct {
 	FileHeader            string `yaml:"file_header"`
 	GenerateConstructors  bool   `yaml:"generate_constructors"`
 	GenerateStringMethods bool   `yaml:"generate_string_methods"`
+	DisallowUnknownFields bool   `yaml:"disallow_unknown_fields"` // Generate UnmarshalJSON methods that reject unknown keys
+	EmbedGoGenerate       bool   `yaml:"embed_go_generate"`       // Write a //go:generate directive that reproduces the invocation
+	RootPrimitiveField    string `yaml:"root_primitive_field"`    // Field that wraps a primitive JSON root (default "Value")
+	RootPrimitiveAlias    bool   `yaml:"root_primitive_alias"`    // Emit "type Root string" for a primitive JSON root instead of a wrapper struct
+	OptionalityNotes      bool   `yaml:"optionality_notes"`       // Document which fields were missing from some merged samples
+	GenerateMerge         bool   `yaml:"generate_merge"`          // Generate Merge methods that overlay another value's non-nil pointer fields
+	StructOrder           string `yaml:"struct_order"`            // Order of structs in the output: root_first (default), declaration or name
+	FieldOrder            string `yaml:"field_order"`             // Order of fields in a struct: name (default) or required_first
+	GenerateEqual         bool   `yaml:"generate_equal"`          // Generate Equal methods comparing values field by field
+	GenerateTypeRegistry  bool   `yaml:"generate_type_registry"`  // Emit an AllTypes var holding a zero value of every struct
+	GenerateSQLJSON       bool   `yaml:"generate_sql_json"`       // Generate Scan and Value methods on nested structs so they can be stored as JSON columns
+	SourcePathComments    bool   `yaml:"source_path_comments"`    // Document each struct with the JSONPath of the sample objects it came from
+	DefaultsOnUnmarshal   bool   `yaml:"defaults_on_unmarshal"`   // Generate UnmarshalJSON methods that set fields' default values before decoding
+	SchemaRequiredFrom    string `yaml:"schema_required_from"`    // What puts a property in JSON Schema output's required lists: non-pointer (default), always-present or none
+	WrapRootArrayAs       string `yaml:"wrap_root_array_as"`      // Wrap a JSON array root in a struct with this field holding the slice
+	SeparateMethods       bool   `yaml:"separate_methods"`        // With --split-files, write types to types.go and methods to methods.go
+	UsageExample          bool   `yaml:"usage_example"`           // End the output with a comment showing how to decode JSON into the root type
+	TypesPackageAlias     string `yaml:"types_package_alias"`     // Package qualifying types in the usage example; the generated package by default
+	RootOptional          bool   `yaml:"root_optional"`           // Generate ParseRoot(data) (*Root, error), returning nil for a JSON null root
+	OmitEmptyStructs      bool   `yaml:"omit_empty_structs"`      // Generate MarshalJSON leaving out nested (non-pointer) structs that hold their zero value
+	CommentWrap           int    `yaml:"comment_wrap"`            // Wrap struct and field comment lines longer than this many characters, counting the "// "; zero leaves them
+
+	GroupFields bool         `yaml:"group_fields"` // Order struct fields by category with blank lines between groups
+	FieldGroups []FieldGroup `yaml:"field_groups"` // Categories for group_fields; DefaultFieldGroups when empty
+}
+
+// FieldGroup is a category of struct fields for output.group_fields.
+// A field belongs to the first group whose pattern matches its JSON key or
+// whose kinds include its type kind ("struct", "time.Time", ...; slices also
+// match on their element kind). Fields in no group are written last.
+type FieldGroup struct {
+	Name    string   `yaml:"name"`
+	Pattern string   `yaml:"pattern,omitempty"`
+	Kinds   []string `yaml:"kinds,omitempty"`
+
+	// compiled regex (not serialized)
+	regex *regexp.Regexp
+}
+
+// defaultFieldGroups is the precompiled result of DefaultFieldGroups
+var defaultFieldGroups = DefaultFieldGroups()
+
+// DefaultFieldGroups returns the groups used when group_fields is on and no field_groups are configured:
+// identifiers, timestamps, then nested objects. Their patterns are never anchored by matching.anchored.
+func DefaultFieldGroups() []FieldGroup {
+	groups := []FieldGroup{
+		{Name: "ids", Pattern: "^(id|ID|Id)$|_id$|_ID$|[a-z]Id$|[a-z]ID$"},
+		{Name: "timestamps", Pattern: "_at$|_time$|_date$", Kinds: []string{"time.Time"}},
+		{Name: "nested", Kinds: []string{"struct"}},
+	}
+	for i := range groups {
+		if groups[i].Pattern != "" {
+			groups[i].regex = regexp.MustCompile(groups[i].Pattern)
+		}
+	}
+	return groups
 }
 
 // ArraysConfig controls array handling
 type ArraysConfig struct {
 	MergeDifferentObjects bool `yaml:"merge_different_objects"`
 	SingularizeNames      bool `yaml:"singularize_names"`
+	// MergeStrategy decides the type of a field whose type differs between merged objects
+	MergeStrategy string `yaml:"merge_strategy"`
+	// Strategy decides how arrays of objects are typed: merged into one struct, or an
+	// interface over a struct per types.discriminator value
+	Strategy string `yaml:"strategy"`
+	// PerShapeStructs types a root array of objects with different key sets as a
+	// []interface{} with a struct per key set, instead of merging them
+	PerShapeStructs bool `yaml:"per_shape_structs"`
+	// MaxUnionMembers merges an array of objects as usual when strategy or
+	// per_shape_structs would give it more variant structs than this. Zero is no limit.
+	MaxUnionMembers int `yaml:"max_union_members"`
+}
+
+// Strategies for arrays of objects, for arrays.strategy
+const (
+	ArrayStrategyMerge          = "merge"           // merge the elements into one struct (default)
+	ArrayStrategyInterfaceUnion = "interface_union" // an interface implemented by a struct per discriminator value
+)
+
+// ValidArrayStrategy reports whether s is empty or a known array strategy
+func ValidArrayStrategy(s string) bool {
+	switch s {
+	case "", ArrayStrategyMerge, ArrayStrategyInterfaceUnion:
+		return true
+	}
+	return false
+}
+
+// Merge strategies for fields with conflicting types
+const (
+	MergeInterface = "interface" // use interface{} (default)
+	MergeFirst     = "first"     // keep the first type seen
+	MergeString    = "string"    // decode conflicting scalars into a string
+	MergeError     = "error"     // fail, listing every conflict
+)
+
+// ValidMergeStrategy reports whether s is empty or a known merge strategy
+func ValidMergeStrategy(s string) bool {
+	switch s {
+	case "", MergeInterface, MergeFirst, MergeString, MergeError:
+		return true
+	}
+	return false
+}
+
+// Struct orders for output.struct_order
+const (
+	StructOrderRootFirst   = "root_first"  // root structs first, then the rest by name (default)
+	StructOrderDeclaration = "declaration" // the order types are met walking the document from the root
+	StructOrderName        = "name"        // alphabetical by name
+)
+
+// Field orders for output.field_order
+const (
+	FieldOrderName          = "name"           // alphabetical by Go name (default)
+	FieldOrderRequiredFirst = "required_first" // non-pointer fields, then pointer fields, each by name
+)
+
+// Sources of the required lists in JSON Schema output, for output.schema_required_from
+const (
+	RequiredFromNonPointer    = "non-pointer"    // fields that are neither pointers nor omitempty (default)
+	RequiredFromAlwaysPresent = "always-present" // fields present in every object merged into their struct
+	RequiredFromNone          = "none"           // no required lists
+)
+
+// ValidRequiredFrom reports whether s is empty or a known source of required lists
+func ValidRequiredFrom(s string) bool {
+	switch s {
+	case "", RequiredFromNonPointer, RequiredFromAlwaysPresent, RequiredFromNone:
+		return true
+	}
+	return false
+}
+
+// Casings of json tag names for json_tags.tag_case
+const (
+	TagCaseOriginal = "original" // the JSON key as it appears in the input (default)
+	TagCaseLower    = "lower"
+	TagCaseUpper    = "upper"
+)
+
+// ValidTagCase reports whether s is empty or a known tag casing
+func ValidTagCase(s string) bool {
+	switch s {
+	case "", TagCaseOriginal, TagCaseLower, TagCaseUpper:
+		return true
+	}
+	return false
+}
+
+// Modes of json_tags.omitempty
+const (
+	OmitemptyTypeBased    = "type-based"    // pointers and slices, per omitempty_for_pointers and omitempty_for_slices (default)
+	OmitemptyOptionalOnly = "optional-only" // fields missing from or null in some of the merged objects
+	OmitemptyNone         = "none"          // no omitempty or omitzero
+)
+
+// ValidOmitempty reports whether s is empty or a known omitempty mode
+func ValidOmitempty(s string) bool {
+	switch s {
+	case "", OmitemptyTypeBased, OmitemptyOptionalOnly, OmitemptyNone:
+		return true
+	}
+	return false
+}
+
+// Types of out-of-range numbers for types.big_number_type
+const (
+	BigNumberJSONNumber = "json.Number" // json.Number, which keeps the literal as text
+	BigNumberBig        = "big"         // *big.Int for integers and *big.Float otherwise
+)
+
+// ValidBigNumberType reports whether s is empty or a known type for out-of-range numbers
+func ValidBigNumberType(s string) bool {
+	switch s {
+	case "", BigNumberJSONNumber, BigNumberBig:
+		return true
+	}
+	return false
+}
+
+// ValidOptionalThreshold reports whether t is a fraction between 0 (off) and 1
+func ValidOptionalThreshold(t float64) bool {
+	return t >= 0 && t <= 1
+}
+
+// ValidFieldOrder reports whether s is empty or a known field order
+func ValidFieldOrder(s string) bool {
+	switch s {
+	case "", FieldOrderName, FieldOrderRequiredFirst:
+		return true
+	}
+	return false
+}
+
+// ValidStructOrder reports whether s is empty or a known struct order
+func ValidStructOrder(s string) bool {
+	switch s {
+	case "", StructOrderRootFirst, StructOrderDeclaration, StructOrderName:
+		return true
+	}
+	return false
+}
+
+// MatchingConfig controls how field name patterns are matched.
+// Patterns are unanchored by default, so "id" also matches "video".
+// When several patterns match the same field, Strategy decides which one wins.
+type MatchingConfig struct {
+	Anchored bool   `yaml:"anchored"` // Wrap patterns in ^...$ so they must match the whole field name
+	Strategy string `yaml:"strategy"` // "first" (default) or "most_specific"
+}
+
+// Matching strategies for overlapping patterns
+const (
+	MatchFirst        = "first"
+	MatchMostSpecific = "most_specific"
+)
+
+// SchemaConfig controls JSON Schema conversion
+type SchemaConfig struct {
+	EnforceAdditionalProperties bool `yaml:"enforce_additional_properties"` // Reject unknown keys for objects with additionalProperties: false
+	ExampleComments             bool `yaml:"example_comments"`              // Add "Example: ..." to field comments from examples/example
+	RuntimeFormatValidation     bool `yaml:"runtime_format_validation"`     // Generate Validate methods checking uuid, email, uri, ipv4 and ipv6 string formats
+	FixedSizeArrays             bool `yaml:"fixed_size_arrays"`             // Type arrays with minItems equal to maxItems as Go arrays, e.g. [3]int64
 }
 
 // DevConfig contains development/debug options
@@ -125,6 +454,8 @@ func NewConfig() *Config {
 			UnixTimestampsAsTime: false, // Default: keep as int64 for flexibility
 			DateFormat:           "",    // Default: empty means "us" with a comment noting the assumption
 			Mappings:             []TypeMapping{},
+			DurationFields:       []DurationField{},
+			InlinePrefix:         true,
 		},
 		Naming: NamingConfig{
 			PascalCaseFields: true,
@@ -135,6 +466,7 @@ func NewConfig() *Config {
 			OmitemptyForPointers: true,
 			OmitemptyForSlices:   true,
 			AdditionalTags:       []string{},
+			TagCase:              TagCaseOriginal,
 		},
 		Validation: ValidationConfig{
 			Enabled: false,
@@ -143,10 +475,15 @@ func NewConfig() *Config {
 		Output: OutputConfig{
 			GenerateConstructors:  false,
 			GenerateStringMethods: false,
+			RootPrimitiveField:    "Value",
+			StructOrder:           StructOrderRootFirst,
+			FieldOrder:            FieldOrderName,
+			SchemaRequiredFrom:    RequiredFromNonPointer,
 		},
 		Arrays: ArraysConfig{
 			MergeDifferentObjects: true,
 			SingularizeNames:      true,
+			MergeStrategy:         MergeInterface,
 		},
 		Dev: DevConfig{
 			Debug:   false,
@@ -171,6 +508,68 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if !ValidGoVersion(cfg.GoVersion) {
+		return nil, fmt.Errorf("invalid go_version '%s': expected a version like \"1.22\"", cfg.GoVersion)
+	}
+
+	if cfg.Naming.AcronymMinLength < 0 {
+		return nil, fmt.Errorf("invalid naming.acronym_min_length %d: must not be negative", cfg.Naming.AcronymMinLength)
+	}
+	if cfg.Naming.MaxNameLength != 0 && cfg.Naming.MaxNameLength < MinMaxNameLength {
+		return nil, fmt.Errorf("invalid naming.max_name_length %d: must be 0 or at least %d", cfg.Naming.MaxNameLength, MinMaxNameLength)
+	}
+
+	if cfg.Types.InferMaps < 0 {
+		return nil, fmt.Errorf("invalid types.infer_maps %d: must be 0 (off) or a minimum key count", cfg.Types.InferMaps)
+	}
+
+	switch cfg.Matching.Strategy {
+	case "", MatchFirst, MatchMostSpecific:
+	default:
+		return nil, fmt.Errorf("invalid matching strategy '%s': must be %q or %q", cfg.Matching.Strategy, MatchFirst, MatchMostSpecific)
+	}
+
+	if !ValidArrayStrategy(cfg.Arrays.Strategy) {
+		return nil, fmt.Errorf("invalid arrays.strategy '%s': must be %q or %q", cfg.Arrays.Strategy, ArrayStrategyMerge, ArrayStrategyInterfaceUnion)
+	}
+	if cfg.Arrays.Strategy == ArrayStrategyInterfaceUnion && cfg.Types.Discriminator == "" {
+		return nil, fmt.Errorf("arrays.strategy %q needs types.discriminator, the key naming each object's variant", ArrayStrategyInterfaceUnion)
+	}
+	if !ValidMergeStrategy(cfg.Arrays.MergeStrategy) {
+		return nil, fmt.Errorf("invalid arrays.merge_strategy '%s': must be %q, %q, %q or %q", cfg.Arrays.MergeStrategy, MergeInterface, MergeFirst, MergeString, MergeError)
+	}
+
+	if !ValidOptionalThreshold(cfg.Types.OptionalThreshold) {
+		return nil, fmt.Errorf("invalid types.optional_threshold %g: must be between 0 and 1", cfg.Types.OptionalThreshold)
+	}
+
+	if !ValidBigNumberType(cfg.Types.BigNumberType) {
+		return nil, fmt.Errorf("invalid types.big_number_type '%s': must be %q or %q", cfg.Types.BigNumberType, BigNumberJSONNumber, BigNumberBig)
+	}
+	if !ValidOmitempty(cfg.JSONTags.Omitempty) {
+		return nil, fmt.Errorf("invalid json_tags.omitempty '%s': must be %q, %q or %q", cfg.JSONTags.Omitempty, OmitemptyTypeBased, OmitemptyOptionalOnly, OmitemptyNone)
+	}
+	if !ValidTagCase(cfg.JSONTags.TagCase) {
+		return nil, fmt.Errorf("invalid json_tags.tag_case '%s': must be %q, %q or %q", cfg.JSONTags.TagCase, TagCaseOriginal, TagCaseLower, TagCaseUpper)
+	}
+
+	if !ValidRequiredFrom(cfg.Output.SchemaRequiredFrom) {
+		return nil, fmt.Errorf("invalid output.schema_required_from '%s': must be %q, %q or %q", cfg.Output.SchemaRequiredFrom, RequiredFromNonPointer, RequiredFromAlwaysPresent, RequiredFromNone)
+	}
+
+	if !ValidStructOrder(cfg.Output.StructOrder) {
+		return nil, fmt.Errorf("invalid output.struct_order '%s': must be %q, %q or %q", cfg.Output.StructOrder, StructOrderRootFirst, StructOrderDeclaration, StructOrderName)
+	}
+	if !ValidFieldOrder(cfg.Output.FieldOrder) {
+		return nil, fmt.Errorf("invalid output.field_order '%s': must be %q or %q", cfg.Output.FieldOrder, FieldOrderName, FieldOrderRequiredFirst)
+	}
+	if cfg.Arrays.MaxUnionMembers < 0 {
+		return nil, fmt.Errorf("invalid arrays.max_union_members %d: must not be negative", cfg.Arrays.MaxUnionMembers)
+	}
+	if cfg.Output.CommentWrap < 0 {
+		return nil, fmt.Errorf("invalid output.comment_wrap %d: must not be negative", cfg.Output.CommentWrap)
+	}
+
 	// Compile regex patterns
 	if err := cfg.compilePatterns(); err != nil {
 		return nil, fmt.Errorf("failed to compile patterns: %w", err)
@@ -215,17 +614,55 @@ func (c *Config) compilePatterns() error {
 	// Compile type mapping patterns
 	for i := range c.Types.Mappings {
 		mapping := &c.Types.Mappings[i]
-		regex, err := regexp.Compile(mapping.Pattern)
+		regex, err := c.compilePattern(mapping.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid type mapping pattern '%s': %w", mapping.Pattern, err)
 		}
 		mapping.regex = regex
 	}
 
+	// Compile duration field patterns
+	for i := range c.Types.DurationFields {
+		field := &c.Types.DurationFields[i]
+		if _, ok := DurationUnits[field.Unit]; !ok {
+			return fmt.Errorf("invalid duration unit '%s' for pattern '%s': must be one of ns, us, ms, s, m, h", field.Unit, field.Pattern)
+		}
+		regex, err := c.compilePattern(field.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid duration field pattern '%s': %w", field.Pattern, err)
+		}
+		field.regex = regex
+	}
+
+	// Compile field group patterns
+	for i := range c.Output.FieldGroups {
+		group := &c.Output.FieldGroups[i]
+		if group.Pattern == "" {
+			continue
+		}
+		regex, err := c.compilePattern(group.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid field group pattern '%s': %w", group.Pattern, err)
+		}
+		group.regex = regex
+	}
+
+	// Check the inline field patterns, which are compiled when they are used
+	for _, pattern := range c.Types.InlineFields {
+		if _, err := c.compilePattern(pattern); err != nil {
+			return fmt.Errorf("invalid inline field pattern '%s': %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.Types.NestedJSONFields {
+		if _, err := c.compilePattern(pattern); err != nil {
+			return fmt.Errorf("invalid nested JSON field pattern '%s': %w", pattern, err)
+		}
+	}
+
 	// Compile validation rule patterns
 	for i := range c.Validation.Rules {
 		rule := &c.Validation.Rules[i]
-		regex, err := regexp.Compile(rule.Pattern)
+		regex, err := c.compilePattern(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid validation rule pattern '%s': %w", rule.Pattern, err)
 		}
@@ -235,7 +672,7 @@ func (c *Config) compilePatterns() error {
 	// Compile tag option patterns
 	for i := range c.JSONTags.CustomOptions {
 		option := &c.JSONTags.CustomOptions[i]
-		regex, err := regexp.Compile(option.Pattern)
+		regex, err := c.compilePattern(option.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid tag option pattern '%s': %w", option.Pattern, err)
 		}
@@ -245,15 +682,67 @@ func (c *Config) compilePatterns() error {
 	return nil
 }
 
+// compilePattern compiles a field name pattern, anchoring it when matching.anchored is set
+func (c *Config) compilePattern(pattern string) (*regexp.Regexp, error) {
+	if c.Matching.Anchored {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	return regexp.Compile(pattern)
+}
+
+// bestMatch returns the index of the pattern that wins for fieldName, or -1 if none match.
+// With the default strategy the first matching pattern in slice order wins; with
+// most_specific the winner is the matching pattern with the most anchors, then the
+// longest literal prefix, falling back to slice order on ties.
+func (c *Config) bestMatch(fieldName string, patterns []*regexp.Regexp) int {
+	best := -1
+	for i, regex := range patterns {
+		if regex == nil || !regex.MatchString(fieldName) {
+			continue
+		}
+		if c.Matching.Strategy != MatchMostSpecific {
+			return i
+		}
+		if best < 0 || moreSpecific(regex, patterns[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// moreSpecific reports whether pattern a is strictly more specific than pattern b
+func moreSpecific(a, b *regexp.Regexp) bool {
+	if anchorsA, anchorsB := countAnchors(a), countAnchors(b); anchorsA != anchorsB {
+		return anchorsA > anchorsB
+	}
+	prefixA, _ := a.LiteralPrefix()
+	prefixB, _ := b.LiteralPrefix()
+	return len(prefixA) > len(prefixB)
+}
+
+// countAnchors returns how many ends (start, end) of the pattern are anchored
+func countAnchors(regex *regexp.Regexp) int {
+	pattern := regex.String()
+	anchors := 0
+	if strings.HasPrefix(pattern, "^") {
+		anchors++
+	}
+	if strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+		anchors++
+	}
+	return anchors
+}
+
 // MatchesField checks if this type mapping matches the given field name
 func (tm *TypeMapping) MatchesField(fieldName string) bool {
 	if tm.regex == nil {
-		// Try to compile if not already compiled (fallback)
+		// Not compiled by LoadConfig. Compile without caching the result, so
+		// analyzers sharing the config can match concurrently.
 		regex, err := regexp.Compile(tm.Pattern)
 		if err != nil {
 			return false
 		}
-		tm.regex = regex
+		return regex.MatchString(fieldName)
 	}
 	return tm.regex.MatchString(fieldName)
 }
@@ -261,12 +750,12 @@ func (tm *TypeMapping) MatchesField(fieldName string) bool {
 // MatchesField checks if this validation rule matches the given field name
 func (vr *ValidationRule) MatchesField(fieldName string) bool {
 	if vr.regex == nil {
-		// Try to compile if not already compiled (fallback)
+		// Not compiled by LoadConfig; see TypeMapping.MatchesField
 		regex, err := regexp.Compile(vr.Pattern)
 		if err != nil {
 			return false
 		}
-		vr.regex = regex
+		return regex.MatchString(fieldName)
 	}
 	return vr.regex.MatchString(fieldName)
 }
@@ -274,63 +763,345 @@ func (vr *ValidationRule) MatchesField(fieldName string) bool {
 // MatchesField checks if this tag option matches the given field name
 func (to *TagOption) MatchesField(fieldName string) bool {
 	if to.regex == nil {
-		// Try to compile if not already compiled (fallback)
+		// Not compiled by LoadConfig; see TypeMapping.MatchesField
 		regex, err := regexp.Compile(to.Pattern)
 		if err != nil {
 			return false
 		}
-		to.regex = regex
+		return regex.MatchString(fieldName)
 	}
 	return to.regex.MatchString(fieldName)
 }
 
-// GetFieldName returns the Go field name for a JSON key, applying naming rules
+// GetFieldName returns the Go field name for a JSON key, applying naming rules. It is
+// empty when nothing in the key can name a field, e.g. "名前" with naming.ascii_only.
 func (c *Config) GetFieldName(jsonKey string) string {
 	// Check custom mappings first
 	if mapped, exists := c.Naming.FieldMappings[jsonKey]; exists {
 		return mapped
 	}
 
+	name := c.trimKey(jsonKey)
+	if c.Naming.ASCIIOnly {
+		name = asciiKey(name)
+		if name == "" {
+			return ""
+		}
+	}
+
 	// Apply PascalCase conversion if enabled
 	if c.Naming.PascalCaseFields {
-		return strcase.ToCamel(jsonKey)
+		return c.LimitNameLength(c.PascalCase(name))
+	}
+
+	// Keep the original key, but exported: encoding/json ignores unexported fields
+	return c.LimitNameLength(exportedName(name))
+}
+
+// PascalCase converts a JSON key to PascalCase. With naming.acronym_min_length set,
+// runs of capitals start and end words, and runs at least that long are kept as
+// written: HTTPStatus, parseXMLData and userHTTPSURL become HTTPStatus, ParseXMLData
+// and UserHTTPSURL.
+func (c *Config) PascalCase(key string) string {
+	if c.Naming.AcronymMinLength == 0 {
+		return strcase.ToCamel(key)
+	}
+
+	var b strings.Builder
+	for _, word := range splitWords(key) {
+		if isAcronym(word, c.Naming.AcronymMinLength) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(strcase.ToCamel(word))
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits a key at separators and case changes. A run of capitals
+// followed by a lowercase letter ends before its last capital, so "HTTPStatus"
+// splits into "HTTP" and "Status". Digits stay with the letters before them.
+func splitWords(key string) []string {
+	runes := []rune(key)
+	var words []string
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// isAcronym reports whether word has at least minLength letters, all of them capitals
+func isAcronym(word string, minLength int) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= minLength
+}
+
+// asciiKey transliterates accented Latin letters to their base letters and drops
+// every other non-ASCII character, keeping separators so words still split
+func asciiKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case asciiTransliterations[r] != "":
+			b.WriteString(asciiTransliterations[r])
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			b.WriteByte('_')
+		}
+	}
+	if strings.Trim(b.String(), "_- ") == "" {
+		return ""
 	}
+	return b.String()
+}
 
-	// Return original key
-	return jsonKey
+// asciiTransliterations maps the accented letters of the Latin-1 Supplement and
+// Latin Extended-A blocks to ASCII
+var asciiTransliterations = func() map[rune]string {
+	groups := map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄ", "a": "àáâãäåāăą", "AE": "Æ", "ae": "æ",
+		"C": "ÇĆĈĊČ", "c": "çćĉċč", "D": "ĎĐÐ", "d": "ďđð",
+		"E": "ÈÉÊËĒĔĖĘĚ", "e": "èéêëēĕėęě", "G": "ĜĞĠĢ", "g": "ĝğġģ",
+		"H": "ĤĦ", "h": "ĥħ", "I": "ÌÍÎÏĨĪĬĮİ", "i": "ìíîïĩīĭįı",
+		"IJ": "Ĳ", "ij": "ĳ", "J": "Ĵ", "j": "ĵ", "K": "Ķ", "k": "ķĸ",
+		"L": "ĹĻĽĿŁ", "l": "ĺļľŀł", "N": "ÑŃŅŇŊ", "n": "ñńņňŉŋ",
+		"O": "ÒÓÔÕÖØŌŎŐ", "o": "òóôõöøōŏő", "OE": "Œ", "oe": "œ",
+		"R": "ŔŖŘ", "r": "ŕŗř", "S": "ŚŜŞŠ", "s": "śŝşšſ", "ss": "ß",
+		"T": "ŢŤŦ", "t": "ţťŧ", "TH": "Þ", "th": "þ",
+		"U": "ÙÚÛÜŨŪŬŮŰŲ", "u": "ùúûüũūŭůűų", "W": "Ŵ", "w": "ŵ",
+		"Y": "ÝŶŸ", "y": "ýÿŷ", "Z": "ŹŻŽ", "z": "źżž",
+	}
+	table := make(map[rune]string)
+	for ascii, letters := range groups {
+		for _, r := range letters {
+			table[r] = ascii
+		}
+	}
+	return table
+}()
+
+// trimKey removes the first matching naming.trim_key_prefixes and
+// naming.trim_key_suffixes entries, keeping keys that would become empty
+func (c *Config) trimKey(jsonKey string) string {
+	name := jsonKey
+	for _, prefix := range c.Naming.TrimKeyPrefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	for _, suffix := range c.Naming.TrimKeySuffixes {
+		if suffix != "" && strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			name = name[:len(name)-len(suffix)]
+			break
+		}
+	}
+	return name
+}
+
+// TagOrder returns the order in which struct tag keys are written. Without
+// json_tags.tag_order, json comes first, then additional_tags as listed, then
+// mapstructure and validate.
+func (c *Config) TagOrder() []string {
+	if len(c.JSONTags.TagOrder) > 0 {
+		return c.JSONTags.TagOrder
+	}
+	order := append([]string{"json"}, c.JSONTags.AdditionalTags...)
+	return append(order, "mapstructure", "validate")
 }
 
-// FindTypeMapping finds the first type mapping that matches the field name
+// TagKey returns the json tag name for a JSON key, cased per json_tags.tag_case.
+// Only the letters' case changes; separators such as _ and - are kept.
+func (c *Config) TagKey(key string) string {
+	switch c.JSONTags.TagCase {
+	case TagCaseLower:
+		return strings.ToLower(key)
+	case TagCaseUpper:
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// LimitNameLength truncates a generated name to naming.max_name_length. The end of
+// the name is replaced with a hash of the whole name, so distinct long names stay distinct.
+func (c *Config) LimitNameLength(name string) string {
+	limit := c.Naming.MaxNameLength
+	if limit <= 0 || len(name) <= limit {
+		return name
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	suffix := fmt.Sprintf("%08x", h.Sum32())
+
+	// Cut on a rune boundary so multi-byte letters aren't split
+	prefix := name[:limit-len(suffix)]
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix + suffix
+}
+
+// exportedName uppercases the first letter of name, prefixing "X" when it doesn't
+// start with a letter or starts with one that has no upper case, such as 名
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	if size == 0 {
+		return name
+	}
+	if upper := unicode.ToUpper(r); unicode.IsUpper(upper) {
+		return string(upper) + name[size:]
+	}
+	return "X" + name
+}
+
+// FindTypeMapping finds the type mapping that matches the field name, honouring matching.strategy
 func (c *Config) FindTypeMapping(fieldName string) (TypeMapping, bool) {
-	for _, mapping := range c.Types.Mappings {
-		if mapping.MatchesField(fieldName) {
-			return mapping, true
+	patterns := make([]*regexp.Regexp, len(c.Types.Mappings))
+	for i := range c.Types.Mappings {
+		patterns[i] = c.Types.Mappings[i].regex
+		if patterns[i] == nil {
+			// Not compiled by LoadConfig; see TypeMapping.MatchesField
+			patterns[i], _ = c.compilePattern(c.Types.Mappings[i].Pattern)
 		}
 	}
+	if i := c.bestMatch(fieldName, patterns); i >= 0 {
+		return c.Types.Mappings[i], true
+	}
 	return TypeMapping{}, false
 }
 
-// FindValidationRule finds the first validation rule that matches the field name
+// FindDurationField finds the duration field rule that matches the field name, honouring matching.strategy.
+// Rules with an unknown unit never match.
+func (c *Config) FindDurationField(fieldName string) (DurationField, bool) {
+	patterns := make([]*regexp.Regexp, len(c.Types.DurationFields))
+	for i := range c.Types.DurationFields {
+		field := c.Types.DurationFields[i]
+		if _, ok := DurationUnits[field.Unit]; !ok {
+			continue
+		}
+		patterns[i] = field.regex
+		if patterns[i] == nil {
+			patterns[i], _ = c.compilePattern(field.Pattern)
+		}
+	}
+	if i := c.bestMatch(fieldName, patterns); i >= 0 {
+		return c.Types.DurationFields[i], true
+	}
+	return DurationField{}, false
+}
+
+// IsInlineField reports whether a nested object's key matches a types.inline_fields pattern
+func (c *Config) IsInlineField(key string) bool {
+	for _, pattern := range c.Types.InlineFields {
+		if regex, err := c.compilePattern(pattern); err == nil && regex.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNestedJSONField reports whether a key matches a types.nested_json_fields pattern
+func (c *Config) IsNestedJSONField(key string) bool {
+	for _, pattern := range c.Types.NestedJSONFields {
+		if regex, err := c.compilePattern(pattern); err == nil && regex.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldGroupIndex returns the index of the first field group matching the JSON key or any of the
+// given type kinds, or the number of groups when none match. Groups come from output.field_groups,
+// falling back to DefaultFieldGroups.
+func (c *Config) FieldGroupIndex(jsonKey string, kinds ...string) int {
+	groups := c.Output.FieldGroups
+	if len(groups) == 0 {
+		groups = defaultFieldGroups
+	}
+
+	for i := range groups {
+		group := groups[i]
+		if group.Pattern != "" {
+			regex := group.regex
+			if regex == nil {
+				regex, _ = c.compilePattern(group.Pattern)
+			}
+			if regex != nil && regex.MatchString(jsonKey) {
+				return i
+			}
+		}
+		for _, groupKind := range group.Kinds {
+			for _, kind := range kinds {
+				if groupKind == kind {
+					return i
+				}
+			}
+		}
+	}
+	return len(groups)
+}
+
+// FindValidationRule finds the validation rule that matches the field name, honouring matching.strategy
 func (c *Config) FindValidationRule(fieldName string) (ValidationRule, bool) {
 	if !c.Validation.Enabled {
 		return ValidationRule{}, false
 	}
 
-	for _, rule := range c.Validation.Rules {
-		if rule.MatchesField(fieldName) {
-			return rule, true
+	patterns := make([]*regexp.Regexp, len(c.Validation.Rules))
+	for i := range c.Validation.Rules {
+		patterns[i] = c.Validation.Rules[i].regex
+		if patterns[i] == nil {
+			patterns[i], _ = c.compilePattern(c.Validation.Rules[i].Pattern)
 		}
 	}
+	if i := c.bestMatch(fieldName, patterns); i >= 0 {
+		return c.Validation.Rules[i], true
+	}
 	return ValidationRule{}, false
 }
 
-// FindTagOption finds the first tag option that matches the field name
+// FindTagOption finds the tag option that matches the field name, honouring matching.strategy
 func (c *Config) FindTagOption(fieldName string) (TagOption, bool) {
-	for _, option := range c.JSONTags.CustomOptions {
-		if option.MatchesField(fieldName) {
-			return option, true
+	patterns := make([]*regexp.Regexp, len(c.JSONTags.CustomOptions))
+	for i := range c.JSONTags.CustomOptions {
+		patterns[i] = c.JSONTags.CustomOptions[i].regex
+		if patterns[i] == nil {
+			patterns[i], _ = c.compilePattern(c.JSONTags.CustomOptions[i].Pattern)
 		}
 	}
+	if i := c.bestMatch(fieldName, patterns); i >= 0 {
+		return c.JSONTags.CustomOptions[i], true
+	}
 	return TagOption{}, false
 }
 
@@ -344,6 +1115,91 @@ func (c *Config) ShouldSkipField(fieldName string) bool {
 	return false
 }
 
+// UnmatchedEntries describes each types.mappings, validation.rules (when validation
+// is enabled), json_tags.custom_options and json_tags.skip_fields entry that matches
+// none of the given JSON keys, which usually means a typo or a misplaced anchor
+func (c *Config) UnmatchedEntries(keys []string) []string {
+	matchesAny := func(pattern string, regex *regexp.Regexp) bool {
+		if regex == nil {
+			regex, _ = c.compilePattern(pattern)
+			if regex == nil {
+				return false
+			}
+		}
+		for _, key := range keys {
+			if regex.MatchString(key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var unmatched []string
+	for _, mapping := range c.Types.Mappings {
+		if !matchesAny(mapping.Pattern, mapping.regex) {
+			unmatched = append(unmatched, fmt.Sprintf("types.mappings pattern %q matched no field", mapping.Pattern))
+		}
+	}
+	if c.Validation.Enabled {
+		for _, rule := range c.Validation.Rules {
+			if !matchesAny(rule.Pattern, rule.regex) {
+				unmatched = append(unmatched, fmt.Sprintf("validation.rules pattern %q matched no field", rule.Pattern))
+			}
+		}
+	}
+	for _, option := range c.JSONTags.CustomOptions {
+		if !matchesAny(option.Pattern, option.regex) {
+			unmatched = append(unmatched, fmt.Sprintf("json_tags.custom_options pattern %q matched no field", option.Pattern))
+		}
+	}
+	for _, skip := range c.JSONTags.SkipFields {
+		if !slices.Contains(keys, skip) {
+			unmatched = append(unmatched, fmt.Sprintf("json_tags.skip_fields entry %q matched no field", skip))
+		}
+	}
+	return unmatched
+}
+
+// ValidGoVersion reports whether v is empty or a Go version such as "1.22", "1.22.3" or "go1.22"
+func ValidGoVersion(v string) bool {
+	return v == "" || normalizeGoVersion(v) != ""
+}
+
+// normalizeGoVersion converts v to the "go1.N" form used by go/version, or "" if it isn't valid
+func normalizeGoVersion(v string) string {
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if !version.IsValid(v) {
+		return ""
+	}
+	return v
+}
+
+// toolchainGoVersion returns the Go version gotyper was built with, or "" if unknown
+var toolchainGoVersion = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return normalizeGoVersion(info.GoVersion)
+	}
+	return normalizeGoVersion(runtime.Version())
+}
+
+// TargetGoVersion returns the Go version generated code targets in "go1.N" form.
+// It falls back to the toolchain version and returns "" when neither is known.
+func (c *Config) TargetGoVersion() string {
+	if v := normalizeGoVersion(c.GoVersion); c.GoVersion != "" && v != "" {
+		return v
+	}
+	return toolchainGoVersion()
+}
+
+// SupportsOmitzero reports whether the target Go version understands the omitzero
+// JSON tag option, added in Go 1.24. An unknown version is assumed to be recent.
+func (c *Config) SupportsOmitzero() bool {
+	target := c.TargetGoVersion()
+	return target == "" || version.Compare(target, "go1.24") >= 0
+}
+
 // GetDateFormat returns the effective date format preference.
 // Returns "us" or "eu". If not explicitly configured, defaults to "us".
 func (c *Config) GetDateFormat() string {
@@ -405,7 +1261,7 @@ func LoadConfigWithCLI(configPath, cliPackage, cliRootName string, cliForceInt64
 	if cliPackage != "" && cliPackage != "main" {
 		cfg.Package = cliPackage
 	}
-	if cliRootName != "" && cliRootName != "RootType" {
+	if cliRootName != "" {
 		cfg.RootName = cliRootName
 	}
 
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -227,9 +228,14 @@ func TestConfig_GetFieldNameNoPascalCase(t *testing.T) {
 		},
 	}
 
-	// Should return original field name when PascalCase is disabled
-	assert.Equal(t, "user_name", cfg.GetFieldName("user_name"))
-	assert.Equal(t, "first_name", cfg.GetFieldName("first_name"))
+	// Should keep the original casing when PascalCase is disabled, but export the field
+	assert.Equal(t, "User_name", cfg.GetFieldName("user_name"))
+	assert.Equal(t, "First_name", cfg.GetFieldName("first_name"))
+	assert.Equal(t, "UserName", cfg.GetFieldName("userName"))
+	assert.Equal(t, "X_id", cfg.GetFieldName("_id"))
+	assert.Equal(t, "X1st", cfg.GetFieldName("1st"))
+	assert.Equal(t, "Émile", cfg.GetFieldName("émile"))
+	assert.Equal(t, "X名前", cfg.GetFieldName("名前"))
 }
 
 func TestConfig_FindTypeMapping(t *testing.T) {
@@ -643,3 +649,532 @@ naming:
 	assert.Equal(t, "formula", cfg.Naming.CustomSingulars["formulae"])
 	assert.Equal(t, "alumnus", cfg.Naming.CustomSingulars["alumni"])
 }
+
+func TestConfig_MatchingAnchored(t *testing.T) {
+	newConfig := func(anchored bool) *Config {
+		cfg := NewConfig()
+		cfg.Matching.Anchored = anchored
+		cfg.Types.Mappings = []TypeMapping{{Pattern: "id", Type: "int64"}}
+		cfg.JSONTags.CustomOptions = []TagOption{{Pattern: "id", Options: "string"}}
+		cfg.Validation.Enabled = true
+		cfg.Validation.Rules = []ValidationRule{{Pattern: "id", Tag: "validate:\"required\""}}
+		require.NoError(t, cfg.compilePatterns())
+		return cfg
+	}
+
+	unanchored := newConfig(false)
+	_, found := unanchored.FindTypeMapping("video")
+	assert.True(t, found, "unanchored pattern should match a substring")
+	_, found = unanchored.FindTagOption("video")
+	assert.True(t, found)
+	_, found = unanchored.FindValidationRule("video")
+	assert.True(t, found)
+
+	anchored := newConfig(true)
+	_, found = anchored.FindTypeMapping("video")
+	assert.False(t, found, "anchored pattern should not match a substring")
+	_, found = anchored.FindTagOption("video")
+	assert.False(t, found)
+	_, found = anchored.FindValidationRule("video")
+	assert.False(t, found)
+
+	_, found = anchored.FindTypeMapping("id")
+	assert.True(t, found)
+}
+
+func TestConfig_MatchingAnchoredAlternation(t *testing.T) {
+	cfg := &Config{
+		Matching: MatchingConfig{Anchored: true},
+		Types: TypesConfig{
+			Mappings: []TypeMapping{{Pattern: "id|uuid", Type: "string"}},
+		},
+	}
+
+	// Alternations are grouped so the anchors apply to every branch
+	_, found := cfg.FindTypeMapping("uuid")
+	assert.True(t, found)
+	_, found = cfg.FindTypeMapping("video")
+	assert.False(t, found)
+	_, found = cfg.FindTypeMapping("uuids")
+	assert.False(t, found)
+}
+
+func TestLoadConfig_MatchingAnchored(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	content := `
+matching:
+  anchored: true
+types:
+  mappings:
+    - pattern: "id"
+      type: "int64"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.True(t, cfg.Matching.Anchored)
+
+	_, found := cfg.FindTypeMapping("video")
+	assert.False(t, found)
+	_, found = cfg.FindTypeMapping("id")
+	assert.True(t, found)
+}
+
+func TestConfig_OverlappingPatternsFirstMatchWins(t *testing.T) {
+	cfg := &Config{
+		Types: TypesConfig{
+			Mappings: []TypeMapping{
+				{Pattern: ".*_id$", Type: "int64"},
+				{Pattern: "^user_id$", Type: "uuid.UUID"},
+			},
+		},
+		JSONTags: JSONTagsConfig{
+			CustomOptions: []TagOption{
+				{Pattern: ".*_id$", Options: "omitempty"},
+				{Pattern: "^user_id$", Options: "string"},
+			},
+		},
+		Validation: ValidationConfig{
+			Enabled: true,
+			Rules: []ValidationRule{
+				{Pattern: ".*_id$", Tag: "validate:\"min=1\""},
+				{Pattern: "^user_id$", Tag: "validate:\"uuid\""},
+			},
+		},
+	}
+
+	// Default strategy: slice order decides, even when a later pattern is more specific
+	mapping, found := cfg.FindTypeMapping("user_id")
+	require.True(t, found)
+	assert.Equal(t, "int64", mapping.Type)
+
+	option, found := cfg.FindTagOption("user_id")
+	require.True(t, found)
+	assert.Equal(t, "omitempty", option.Options)
+
+	rule, found := cfg.FindValidationRule("user_id")
+	require.True(t, found)
+	assert.Equal(t, "validate:\"min=1\"", rule.Tag)
+
+	cfg.Matching.Strategy = MatchFirst
+	mapping, _ = cfg.FindTypeMapping("user_id")
+	assert.Equal(t, "int64", mapping.Type)
+}
+
+func TestConfig_OverlappingPatternsMostSpecific(t *testing.T) {
+	cfg := &Config{
+		Matching: MatchingConfig{Strategy: MatchMostSpecific},
+		Types: TypesConfig{
+			Mappings: []TypeMapping{
+				{Pattern: ".*_id$", Type: "int64"},
+				{Pattern: "user_id", Type: "string"},
+				{Pattern: "^user_id$", Type: "uuid.UUID"},
+			},
+		},
+		JSONTags: JSONTagsConfig{
+			CustomOptions: []TagOption{
+				{Pattern: ".*_id$", Options: "omitempty"},
+				{Pattern: "^user_id$", Options: "string"},
+			},
+		},
+		Validation: ValidationConfig{
+			Enabled: true,
+			Rules: []ValidationRule{
+				{Pattern: ".*_id$", Tag: "validate:\"min=1\""},
+				{Pattern: "^user_id$", Tag: "validate:\"uuid\""},
+			},
+		},
+	}
+
+	// Fully anchored pattern beats both the suffix-anchored and the unanchored literal
+	mapping, found := cfg.FindTypeMapping("user_id")
+	require.True(t, found)
+	assert.Equal(t, "uuid.UUID", mapping.Type)
+
+	option, found := cfg.FindTagOption("user_id")
+	require.True(t, found)
+	assert.Equal(t, "string", option.Options)
+
+	rule, found := cfg.FindValidationRule("user_id")
+	require.True(t, found)
+	assert.Equal(t, "validate:\"uuid\"", rule.Tag)
+
+	// Only the generic pattern matches other IDs
+	mapping, found = cfg.FindTypeMapping("order_id")
+	require.True(t, found)
+	assert.Equal(t, "int64", mapping.Type)
+}
+
+func TestConfig_MostSpecificLiteralPrefix(t *testing.T) {
+	cfg := &Config{
+		Matching: MatchingConfig{Strategy: MatchMostSpecific},
+		Types: TypesConfig{
+			Mappings: []TypeMapping{
+				{Pattern: "^.*_at$", Type: "string"},
+				{Pattern: "^created_.*$", Type: "time.Time"},
+				{Pattern: "^created_at$", Type: "int64"},
+				{Pattern: "^created_.+$", Type: "float64"},
+			},
+		},
+	}
+
+	// All patterns are fully anchored, so the longest literal prefix wins
+	mapping, found := cfg.FindTypeMapping("created_at")
+	require.True(t, found)
+	assert.Equal(t, "int64", mapping.Type)
+
+	// Equal specificity falls back to slice order
+	mapping, found = cfg.FindTypeMapping("created_on")
+	require.True(t, found)
+	assert.Equal(t, "time.Time", mapping.Type)
+}
+
+func TestLoadConfig_InvalidMatchingStrategy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("matching:\n  strategy: longest\n"), 0o644))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid matching strategy")
+}
+
+func TestConfig_FindDurationField(t *testing.T) {
+	cfg := &Config{
+		Types: TypesConfig{
+			DurationFields: []DurationField{
+				{Pattern: "_seconds$", Unit: "s"},
+				{Pattern: "_ms$", Unit: "ms"},
+				{Pattern: "_ticks$", Unit: "fortnight"},
+			},
+		},
+	}
+
+	field, found := cfg.FindDurationField("timeout_seconds")
+	require.True(t, found)
+	assert.Equal(t, "s", field.Unit)
+
+	field, found = cfg.FindDurationField("retry_ms")
+	require.True(t, found)
+	assert.Equal(t, "ms", field.Unit)
+
+	_, found = cfg.FindDurationField("timeout")
+	assert.False(t, found)
+
+	// Unknown units never match
+	_, found = cfg.FindDurationField("clock_ticks")
+	assert.False(t, found)
+}
+
+func TestLoadConfig_InvalidDurationUnit(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	content := `
+types:
+  duration_fields:
+    - pattern: "_seconds$"
+      unit: "sec"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid duration unit 'sec'")
+}
+
+func TestConfig_FieldGroupIndex(t *testing.T) {
+	// Defaults: ids, timestamps, nested objects
+	cfg := NewConfig()
+	assert.Equal(t, 0, cfg.FieldGroupIndex("id", "int"))
+	assert.Equal(t, 0, cfg.FieldGroupIndex("user_id", "int"))
+	assert.Equal(t, 0, cfg.FieldGroupIndex("userId", "int"))
+	assert.Equal(t, 1, cfg.FieldGroupIndex("created_at", "string"))
+	assert.Equal(t, 1, cfg.FieldGroupIndex("published", "time.Time"))
+	assert.Equal(t, 2, cfg.FieldGroupIndex("profile", "struct"))
+	assert.Equal(t, 3, cfg.FieldGroupIndex("video", "string"))
+
+	// Custom groups replace the defaults; the first matching group wins
+	cfg.Output.FieldGroups = []FieldGroup{
+		{Name: "money", Pattern: "_cents$|price", Kinds: []string{"float64"}},
+		{Name: "flags", Kinds: []string{"bool"}},
+	}
+	require.NoError(t, cfg.compilePatterns())
+	assert.Equal(t, 0, cfg.FieldGroupIndex("total_cents", "int"))
+	assert.Equal(t, 0, cfg.FieldGroupIndex("ratio", "float64"))
+	assert.Equal(t, 1, cfg.FieldGroupIndex("enabled", "bool"))
+	assert.Equal(t, 2, cfg.FieldGroupIndex("id", "int"))
+}
+
+func TestConfig_GoVersion(t *testing.T) {
+	assert.True(t, ValidGoVersion(""))
+	assert.True(t, ValidGoVersion("1.22"))
+	assert.True(t, ValidGoVersion("1.22.3"))
+	assert.True(t, ValidGoVersion("go1.24"))
+	assert.False(t, ValidGoVersion("abc"))
+	assert.False(t, ValidGoVersion("1.x"))
+
+	cfg := &Config{GoVersion: "1.21"}
+	assert.Equal(t, "go1.21", cfg.TargetGoVersion())
+	assert.False(t, cfg.SupportsOmitzero())
+
+	cfg.GoVersion = "1.24.1"
+	assert.True(t, cfg.SupportsOmitzero())
+
+	// Without an explicit version the toolchain version is used
+	originalToolchain := toolchainGoVersion
+	defer func() { toolchainGoVersion = originalToolchain }()
+
+	cfg.GoVersion = ""
+	toolchainGoVersion = func() string { return "go1.23.4" }
+	assert.Equal(t, "go1.23.4", cfg.TargetGoVersion())
+	assert.False(t, cfg.SupportsOmitzero())
+
+	toolchainGoVersion = func() string { return "" }
+	assert.True(t, cfg.SupportsOmitzero())
+}
+
+func TestLoadConfig_InvalidGoVersion(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("go_version: \"next\"\n"), 0o644))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid go_version")
+}
+
+func TestConfig_LimitNameLength(t *testing.T) {
+	cfg := NewConfig()
+	long := "RootTypeConfigEnvironmentsProductionServicesDatabaseReplica"
+	assert.Equal(t, long, cfg.LimitNameLength(long), "no limit by default")
+
+	cfg.Naming.MaxNameLength = 30
+	assert.Equal(t, "ShortName", cfg.LimitNameLength("ShortName"))
+
+	limited := cfg.LimitNameLength(long)
+	assert.Len(t, limited, 30)
+	assert.True(t, strings.HasPrefix(limited, "RootTypeConfigEnvironm"))
+	assert.Equal(t, limited, cfg.LimitNameLength(long), "truncation is deterministic")
+	assert.NotEqual(t, limited, cfg.LimitNameLength(long+"s"), "names sharing a prefix stay distinct")
+
+	// Field names are limited too, unless explicitly mapped
+	assert.Len(t, cfg.GetFieldName("a_very_long_field_name_that_keeps_going_on"), 30)
+	cfg.Naming.FieldMappings["a_very_long_field_name_that_keeps_going_on"] = "AVeryLongFieldNameThatKeepsGoingOn"
+	assert.Equal(t, "AVeryLongFieldNameThatKeepsGoingOn", cfg.GetFieldName("a_very_long_field_name_that_keeps_going_on"))
+}
+
+func TestLoadConfig_InvalidMaxNameLength(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("naming:\n  max_name_length: 5\n"), 0o644))
+
+	_, err := LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_name_length")
+}
+
+func TestLoadConfig_MergeStrategy(t *testing.T) {
+	assert.Equal(t, MergeInterface, NewConfig().Arrays.MergeStrategy)
+
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  merge_strategy: first\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, MergeFirst, cfg.Arrays.MergeStrategy)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  merge_strategy: union\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arrays.merge_strategy")
+}
+
+func TestLoadConfig_ArrayStrategy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  strategy: interface_union\ntypes:\n  discriminator: kind\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, ArrayStrategyInterfaceUnion, cfg.Arrays.Strategy)
+	assert.Equal(t, "kind", cfg.Types.Discriminator)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  strategy: interface_union\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "types.discriminator")
+
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  strategy: tagged\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arrays.strategy")
+}
+
+func TestConfig_GetFieldNameTrimsKeys(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Naming.TrimKeyPrefixes = []string{"attr_", "x_"}
+	cfg.Naming.TrimKeySuffixes = []string{"_value"}
+	cfg.Naming.FieldMappings["attr_url"] = "AttrURL"
+
+	tests := map[string]string{
+		"attr_id":          "Id",
+		"attr_name":        "Name",
+		"x_count_value":    "Count",
+		"attr_":            "Attr", // nothing would be left, so the key is kept
+		"attr_url":         "AttrURL",
+		"other_attr_field": "OtherAttrField",
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, cfg.GetFieldName(key), key)
+	}
+}
+
+func TestConfig_GetFieldNameASCIIOnly(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Naming.ASCIIOnly = true
+
+	tests := map[string]string{
+		"naïve":        "Naive",
+		"café_au_lait": "CafeAuLait",
+		"Ærø straße":   "AeroStrasse",
+		"user名前":       "User",
+		"名前":           "", // nothing to name a field after
+		"user_id":      "UserId",
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, cfg.GetFieldName(key), key)
+	}
+
+	// Without PascalCase the key's style is kept, minus the non-ASCII letters
+	cfg.Naming.PascalCaseFields = false
+	assert.Equal(t, "Naive", cfg.GetFieldName("naïve"))
+	assert.Equal(t, "Cafe_au_lait", cfg.GetFieldName("café_au_lait"))
+}
+
+func TestConfig_GetFieldNameAcronyms(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, "Httpstatus", cfg.GetFieldName("HTTPStatus"), "acronym detection is off by default")
+
+	cfg.Naming.AcronymMinLength = 2
+	tests := map[string]string{
+		"HTTPStatus":     "HTTPStatus",
+		"parseXMLData":   "ParseXMLData",
+		"userHTTPSURL":   "UserHTTPSURL",
+		"HTTPSURLConfig": "HTTPSURLConfig",
+		"user_ID":        "UserID",
+		"HTTP2Server":    "HTTP2Server",
+		"user_id":        "UserId",
+		"firstName":      "FirstName",
+		"aBTest":         "ABTest",
+	}
+	for key, expected := range tests {
+		assert.Equal(t, expected, cfg.GetFieldName(key), key)
+	}
+
+	// Shorter runs are re-cased like any other word
+	cfg.Naming.AcronymMinLength = 3
+	assert.Equal(t, "UserId", cfg.GetFieldName("userID"))
+	assert.Equal(t, "ParseXMLData", cfg.GetFieldName("parseXMLData"))
+}
+
+func TestLoadConfig_StructOrder(t *testing.T) {
+	assert.Equal(t, StructOrderRootFirst, NewConfig().Output.StructOrder)
+
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  struct_order: declaration\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, StructOrderDeclaration, cfg.Output.StructOrder)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  struct_order: schema\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output.struct_order")
+}
+
+func TestLoadConfig_FieldOrder(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  field_order: required_first\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, FieldOrderRequiredFirst, cfg.Output.FieldOrder)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  field_order: optional_first\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output.field_order")
+}
+
+func TestLoadConfig_InlineFields(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  inline_fields: [\"^meta$\"]\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.True(t, cfg.Types.InlinePrefix, "names are prefixed by default")
+	assert.True(t, cfg.IsInlineField("meta"))
+	assert.False(t, cfg.IsInlineField("metadata"))
+
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  inline_fields: [\"(meta\"]\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid inline field pattern")
+}
+
+func TestLoadConfig_CommentWrap(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  comment_wrap: 100\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Output.CommentWrap)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("output:\n  comment_wrap: -1\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output.comment_wrap")
+}
+
+func TestLoadConfig_MaxUnionMembers(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  max_union_members: 8\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.Arrays.MaxUnionMembers)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("arrays:\n  max_union_members: -1\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arrays.max_union_members")
+}
+
+func TestLoadConfig_OptionalThreshold(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  optional_threshold: 0.9\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, cfg.Types.OptionalThreshold)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  optional_threshold: 90\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid types.optional_threshold")
+}
+
+func TestLoadConfig_BigNumberType(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  big_number_type: big\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, BigNumberBig, cfg.Types.BigNumberType)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("types:\n  big_number_type: decimal\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid types.big_number_type")
+}
+
+func TestLoadConfig_TagCase(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("json_tags:\n  tag_case: lower\n"), 0o644))
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "user_name", cfg.TagKey("User_Name"))
+
+	require.NoError(t, os.WriteFile(configPath, []byte("json_tags:\n  tag_case: camel\n"), 0o644))
+	_, err = LoadConfig(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid json_tags.tag_case")
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FieldDirective controls how a single field is generated. Directives come from a
+// sidecar file next to the input and take precedence over the patterns in the
+// config file.
+type FieldDirective struct {
+	Rename  string `json:"rename,omitempty"`  // Go field name
+	Type    string `json:"type,omitempty"`    // Go type, e.g. "decimal.Decimal"; the value isn't analyzed
+	Import  string `json:"import,omitempty"`  // Import path the type needs
+	Skip    bool   `json:"skip,omitempty"`    // Leave the field out entirely
+	Comment string `json:"comment,omitempty"` // Field comment, replacing any from json_tags.custom_options
+}
+
+// sidecar is the layout of a sidecar file
+type sidecar struct {
+	// Fields maps the dotted path of a field from the root, as in
+	// json_tags.exclude_fields, to its directive, e.g. "user.address.zip"
+	Fields map[string]FieldDirective `json:"fields"`
+}
+
+// SidecarPath returns the sidecar file of an input file: data.json has data.gotyper.json
+func SidecarPath(inputPath string) string {
+	return strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".gotyper.json"
+}
+
+// LoadSidecar reads the field directives of a sidecar file. Unknown keys are
+// rejected, so a misspelled directive isn't silently ignored.
+func LoadSidecar(path string) (map[string]FieldDirective, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file sidecar
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse sidecar file '%s': %w", path, err)
+	}
+
+	for fieldPath, directive := range file.Fields {
+		if directive.Import != "" && directive.Type == "" {
+			return nil, fmt.Errorf("invalid sidecar entry '%s': import needs a type", fieldPath)
+		}
+		if directive.Skip && (directive.Rename != "" || directive.Type != "" || directive.Comment != "") {
+			return nil, fmt.Errorf("invalid sidecar entry '%s': a skipped field takes no other directives", fieldPath)
+		}
+	}
+	return file.Fields, nil
+}
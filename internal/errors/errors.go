@@ -14,6 +14,8 @@ var (
 	ErrFileEmpty       = errors.New("file is empty")
 	ErrNoInput         = errors.New("no input provided: please specify a file with -i or pipe JSON data to stdin")
 	ErrInvalidFilePath = errors.New("invalid file path")
+	ErrDuplicateKeys   = errors.New("duplicate keys found in JSON object")
+	ErrMergeConflict   = errors.New("conflicting types for merged fields")
 )
 
 // ErrorType categorizes errors
@@ -117,6 +119,11 @@ func NewOutputError(message string, err error) *AppError {
 func UserFriendlyError(err error) string {
 	var appErr *AppError
 	if errors.As(err, &appErr) {
+		// Report the innermost application error, which carries the most specific message
+		var inner *AppError
+		for errors.As(appErr.Err, &inner) {
+			appErr = inner
+		}
 		switch appErr.Type {
 		case ErrorTypeInput:
 			return fmt.Sprintf("Input error: %s", appErr.Message)
@@ -154,6 +161,9 @@ func UserFriendlyError(err error) string {
 	if errors.Is(err, ErrNoInput) {
 		return "Error: No input provided. Please specify a file with -i or pipe JSON data to stdin."
 	}
+	if errors.Is(err, ErrDuplicateKeys) {
+		return "Error: The input contains duplicate object keys. Remove the duplicates or omit --error-on-duplicate-keys."
+	}
 	if errors.Is(err, ErrInvalidFilePath) {
 		return "Error: Invalid file path. Please provide a valid file path."
 	}
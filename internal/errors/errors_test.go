@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -144,6 +145,11 @@ func TestUserFriendlyError(t *testing.T) {
 			err:      NewOutputError("failed to write output", nil),
 			expected: "Output error: failed to write output",
 		},
+		{
+			name:     "nested application error",
+			err:      NewAnalysisError("failed to analyze JSON structure", fmt.Errorf("in array: %w", NewAnalysisError("conflicting types for merged fields: Item.id is int64 and string", ErrMergeConflict))),
+			expected: "Type analysis error: conflicting types for merged fields: Item.id is int64 and string",
+		},
 		{
 			name:     "standard error - empty input",
 			err:      ErrEmptyInput,
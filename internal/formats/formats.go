@@ -0,0 +1,6 @@
+// Package formats holds the patterns of string formats gotyper recognizes, shared
+// by the analyzer and the validation code the generator writes
+package formats
+
+// UUID matches a UUID in its canonical 8-4-4-4-12 hexadecimal form, in either case
+const UUID = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
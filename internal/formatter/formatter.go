@@ -28,11 +28,21 @@ func (f *Formatter) Format(code string) (string, error) {
 		return "", fmt.Errorf("failed to parse Go code: invalid syntax in JSON tag")
 	}
 
+	// go/format needs a package clause, so give declarations without one a
+	// stand-in and remove it again afterwards
+	fragment := !packageClauseRegex.MatchString(code)
+	if fragment {
+		code = fragmentPackage + code
+	}
+
 	// Apply standard formatting using go/format
 	formatted, err := format.Source([]byte(code))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse Go code: %w", err)
 	}
+	if fragment {
+		return strings.TrimLeft(strings.TrimPrefix(string(formatted), fragmentPackage), "\n"), nil
+	}
 
 	// Format imports (keep this part as it's useful)
 	result := f.formatImports(string(formatted))
@@ -40,6 +50,12 @@ func (f *Formatter) Format(code string) (string, error) {
 	return result, nil
 }
 
+// packageClauseRegex finds a package clause, which can only follow comments and blank lines
+var packageClauseRegex = regexp.MustCompile(`(?m)^package\s+\w+`)
+
+// fragmentPackage is the package clause put before declarations to format them
+const fragmentPackage = "package fragment\n\n"
+
 // formatImports organizes import statements with standard library imports first
 func (f *Formatter) formatImports(code string) string {
 	// Use regex to find import blocks
@@ -112,6 +112,16 @@ type UserProfile struct {
 	assert.Equal(t, expectedOutput, formatted)
 }
 
+func TestFormat_DeclarationsWithoutPackage(t *testing.T) {
+	input := "type Person struct {\nName string `json:\"name\"`\nCreated time.Time `json:\"created\"`\n}\n"
+
+	formatted, err := NewFormatter().Format(input)
+	require.NoError(t, err)
+
+	// The fragment is formatted and stays a fragment
+	assert.Equal(t, "type Person struct {\n\tName    string    `json:\"name\"`\n\tCreated time.Time `json:\"created\"`\n}\n", formatted)
+}
+
 func TestFormat_InvalidCode(t *testing.T) {
 	// Test formatting invalid Go code
 	input := `package main
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/mcncl/gotyper/internal/analyzer"
+	"github.com/mcncl/gotyper/internal/config"
 	"github.com/mcncl/gotyper/internal/generator"
 	"github.com/mcncl/gotyper/internal/parser"
 	"github.com/stretchr/testify/assert"
@@ -82,3 +83,43 @@ func TestIntegration_ArrayOfObjects(t *testing.T) {
 	assert.Contains(t, formattedCode, "`json:\"price\"`")
 	assert.Contains(t, formattedCode, "// For a root array type")
 }
+
+func TestIntegration_GroupedFieldsKeepBlankLines(t *testing.T) {
+	jsonInput := `{
+		"name": "Ann",
+		"id": 1,
+		"account_id": 7,
+		"created_at": "2024-01-15T10:30:00Z",
+		"updated_at": "2024-01-16T10:30:00Z",
+		"address": {"city": "Oslo"},
+		"active": true
+	}`
+
+	ir, err := parser.ParseString(jsonInput)
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.Output.GroupFields = true
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "User")
+	require.NoError(t, err)
+
+	code, err := generator.NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+	require.NoError(t, err)
+
+	formatted, err := NewFormatter().Format(code)
+	require.NoError(t, err)
+
+	expected := "type User struct {\n" +
+		"\tAccountId int64 `json:\"account_id\"`\n" +
+		"\tId        int64 `json:\"id\"`\n" +
+		"\n" +
+		"\tCreatedAt time.Time `json:\"created_at\"`\n" +
+		"\tUpdatedAt time.Time `json:\"updated_at\"`\n" +
+		"\n" +
+		"\tAddress *UserAddress `json:\"address,omitempty\"`\n" +
+		"\n" +
+		"\tActive bool   `json:\"active\"`\n" +
+		"\tName   string `json:\"name\"`\n" +
+		"}\n"
+	assert.Contains(t, formatted, expected)
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AppendDeclarations adds the declarations of generated Go source to an existing file.
+// The existing file keeps its package clause and declarations. Generated types,
+// constants and variables whose names the file already declares are left out, as are
+// the methods of those types, and the imports the added code uses are merged into the
+// file's own. The result is formatted.
+func AppendDeclarations(existing, generated []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	oldFile, err := parser.ParseFile(fset, "existing.go", existing, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse existing file: %w", err)
+	}
+	newFile, err := parser.ParseFile(fset, "generated.go", generated, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated code: %w", err)
+	}
+
+	declared := make(map[string]bool)
+	for _, decl := range oldFile.Decls {
+		for _, name := range declNames(decl) {
+			declared[name] = true
+		}
+	}
+
+	// Render each new declaration with its doc comment, and note the packages it uses
+	var added bytes.Buffer
+	used := make(map[string]bool)
+	for _, decl := range newFile.Decls {
+		if !appendable(decl, declared) {
+			continue
+		}
+		start := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+		added.WriteString("\n")
+		added.Write(generated[fset.Position(start).Offset:fset.Position(decl.End()).Offset])
+		added.WriteString("\n")
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if selector, ok := node.(*ast.SelectorExpr); ok {
+				if pkg, ok := selector.X.(*ast.Ident); ok {
+					used[pkg.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	// Imports of the existing file, plus those of the generated code the new declarations use
+	imports := make(map[string]string)
+	for _, spec := range oldFile.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imports[path] = importName(spec)
+	}
+	for _, spec := range newFile.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if _, ok := imports[path]; !ok && used[defaultImportName(path, spec)] {
+			imports[path] = importName(spec)
+		}
+	}
+
+	// Replace the existing import declarations with a single merged block
+	var out bytes.Buffer
+	offset := 0
+	wroteImports := false
+	for _, decl := range oldFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		start, end := fset.Position(genDecl.Pos()).Offset, fset.Position(genDecl.End()).Offset
+		out.Write(existing[offset:start])
+		if !wroteImports {
+			out.WriteString(importBlock(imports))
+			wroteImports = true
+		}
+		offset = end
+	}
+	if !wroteImports && len(imports) > 0 {
+		end := fset.Position(oldFile.Name.End()).Offset
+		out.Write(existing[:end])
+		out.WriteString("\n\n" + importBlock(imports))
+		offset = end
+	}
+	out.Write(existing[offset:])
+	out.Write(added.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format merged file: %w", err)
+	}
+	return formatted, nil
+}
+
+// StripPackageClause returns generated Go source without everything up to the end of
+// its imports: the header comments, package clause and import declarations. The rest,
+// declarations and free-standing comments alike, is kept as written.
+func StripPackageClause(generated string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", generated, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse generated code: %w", err)
+	}
+	end := file.Name.End()
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			end = genDecl.End()
+		}
+	}
+	return strings.TrimLeft(generated[fset.Position(end).Offset:], "\n"), nil
+}
+
+// declNames returns the package-level names a declaration introduces. Methods are
+// named Type.Method so they can't clash with functions.
+func declNames(decl ast.Decl) []string {
+	var names []string
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if recv := receiverTypeName(d); recv != "" {
+			return []string{recv + "." + d.Name.Name}
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// appendable reports whether a generated declaration should be appended: imports never
+// are, methods only when their type is new, and other declarations when none of their
+// names are taken
+func appendable(decl ast.Decl, declared map[string]bool) bool {
+	if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+		return false
+	}
+	if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+		if recv := receiverTypeName(funcDecl); recv != "" && declared[recv] {
+			return false
+		}
+	}
+	for _, name := range declNames(decl) {
+		if declared[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// receiverTypeName returns the type a method is declared on, or "" for a function
+func receiverTypeName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return ""
+	}
+	expr := funcDecl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// declDoc returns a declaration's doc comment, if any
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// importName returns the explicit name of an import, or "" when it has none
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	return ""
+}
+
+// defaultImportName returns the name code uses to refer to an import, guessed from the
+// last element of its path when the import isn't named
+func defaultImportName(path string, spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// importBlock renders imports sorted by path, standard library first
+func importBlock(imports map[string]string) string {
+	var std, other []string
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for i, group := range [][]string{std, other} {
+		if i == 1 && len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		for _, path := range group {
+			if name := imports[path]; name != "" {
+				fmt.Fprintf(&b, "\t%s %q\n", name, path)
+			} else {
+				fmt.Fprintf(&b, "\t%q\n", path)
+			}
+		}
+	}
+	b.WriteString(")")
+	return b.String()
+}
@@ -0,0 +1,578 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// shadowField replaces a struct field with a differently typed field while
+// encoding and decoding JSON. The shadow has the same Go name and JSON key, so
+// it hides the original field from encoding/json.
+type shadowField struct {
+	name      string // Go field name shared with the shadowed field
+	jsonName  string // JSON key
+	tagValue  string // full json tag value used when marshaling, e.g. "timeout,omitempty"
+	jsonType  string // type the value has in JSON, e.g. "float64"
+	isPointer bool   // whether the shadowed field is a pointer
+
+	// decode converts a JSON value expression into a field value expression, or with
+	// fallible into an expression of the value and an error
+	decode   func(src string) string
+	fallible bool
+	// encode converts a field value expression into a JSON value expression, or with
+	// encodeFallible into an expression of the value and an error. It is nil when the
+	// field marshals as it is.
+	encode         func(src string) string
+	encodeFallible bool
+
+	imports []string
+}
+
+// fieldDefault is a value UnmarshalJSON sets on a field before decoding, so the
+// field keeps it when the key is missing
+type fieldDefault struct {
+	name      string // Go field name
+	goType    string // field type without the pointer, e.g. "int64"
+	literal   string // Go literal of the value, e.g. "10" or "\"en\""
+	isPointer bool
+}
+
+// inlineObject is a nested JSON object whose fields types.inline_fields promoted into
+// the struct. The codec decodes and encodes it through a local struct of those fields.
+type inlineObject struct {
+	key    string // JSON key of the object
+	name   string // Go name of the local struct's field, e.g. InlineMeta
+	fields []models.FieldInfo
+}
+
+// localType names the local struct holding the object's fields, e.g. inlineMeta
+func (o inlineObject) localType() string {
+	return "i" + strings.TrimPrefix(o.name, "I")
+}
+
+// structCodec collects what a struct's generated UnmarshalJSON/MarshalJSON need to do,
+// so that every feature contributes to a single pair of methods
+type structCodec struct {
+	structDef models.StructDef
+	strict    bool
+	shadows   []shadowField
+	defaults  []fieldDefault
+	inlines   []inlineObject
+	// zeroStructs are the non-pointer struct fields MarshalJSON leaves out while they
+	// hold their zero value, with output.omit_empty_structs
+	zeroStructs []models.FieldInfo
+}
+
+// newStructCodec builds the codec for a struct from its fields and the configuration
+func (g *Generator) newStructCodec(structDef models.StructDef) *structCodec {
+	codec := &structCodec{
+		structDef: structDef,
+		strict:    g.config.Output.DisallowUnknownFields || structDef.DisallowUnknownFields,
+	}
+
+	for _, field := range structDef.Fields {
+		if shadow, ok := durationShadow(field); ok {
+			codec.shadows = append(codec.shadows, shadow)
+		}
+		if shadow, ok := lenientStringShadow(field); ok {
+			codec.shadows = append(codec.shadows, shadow)
+		}
+		if shadow, ok := formattedNumberShadow(field); ok {
+			codec.shadows = append(codec.shadows, shadow)
+		}
+		if shadow, ok := nestedJSONShadow(field); ok {
+			codec.shadows = append(codec.shadows, shadow)
+		}
+		if g.config.Output.DefaultsOnUnmarshal {
+			if def, ok := defaultValue(field); ok {
+				codec.defaults = append(codec.defaults, def)
+			}
+		}
+		if field.InlinedFrom != "" {
+			codec.addInlined(field)
+		}
+		if _, ok := fieldJSONTag(field); ok && g.config.Output.OmitEmptyStructs && field.GoType.Kind == models.Struct && !field.GoType.IsPointer {
+			codec.zeroStructs = append(codec.zeroStructs, field)
+		}
+	}
+
+	return codec
+}
+
+// addInlined adds a promoted field to the inline object it came from
+func (c *structCodec) addInlined(field models.FieldInfo) {
+	for i := range c.inlines {
+		if c.inlines[i].key == field.InlinedFrom {
+			c.inlines[i].fields = append(c.inlines[i].fields, field)
+			return
+		}
+	}
+	name := "Inline" + strcase.ToCamel(field.InlinedFrom)
+	if !token.IsIdentifier(name) {
+		name = fmt.Sprintf("Inline%d", len(c.inlines)+1)
+	}
+	c.inlines = append(c.inlines, inlineObject{key: field.InlinedFrom, name: name, fields: []models.FieldInfo{field}})
+}
+
+// methods returns the UnmarshalJSON and MarshalJSON methods the struct needs, if any
+func (c *structCodec) methods() []generatedMethod {
+	var methods []generatedMethod
+	if c.strict || len(c.shadows) > 0 || len(c.defaults) > 0 || len(c.inlines) > 0 {
+		methods = append(methods, c.unmarshalMethod())
+	}
+	if len(c.encodedShadows()) > 0 || len(c.inlines) > 0 || len(c.zeroStructs) > 0 {
+		methods = append(methods, c.marshalMethod())
+	}
+	return methods
+}
+
+// encodedShadows returns the shadows that need converting when marshaling
+func (c *structCodec) encodedShadows() []shadowField {
+	var shadows []shadowField
+	for _, shadow := range c.shadows {
+		if shadow.encode != nil {
+			shadows = append(shadows, shadow)
+		}
+	}
+	return shadows
+}
+
+// unmarshalMethod generates UnmarshalJSON. Decoding goes through a local type
+// without methods to avoid recursing into UnmarshalJSON.
+func (c *structCodec) unmarshalMethod() generatedMethod {
+	name := c.structDef.Name
+	recv := receiverName(name)
+	imports := []string{"encoding/json"}
+	if c.strict {
+		imports = append(imports, "bytes")
+	}
+
+	var b strings.Builder
+	switch {
+	case c.strict:
+		fmt.Fprintf(&b, "// UnmarshalJSON decodes JSON into %s, rejecting unknown fields.\n", name)
+	case len(c.shadows) > 0:
+		fmt.Fprintf(&b, "// UnmarshalJSON decodes JSON into %s, converting fields whose JSON representation differs.\n", name)
+	case len(c.inlines) > 0:
+		fmt.Fprintf(&b, "// UnmarshalJSON decodes JSON into %s, taking inlined fields from their nested objects.\n", name)
+	default:
+		fmt.Fprintf(&b, "// UnmarshalJSON decodes JSON into %s, keeping default values for missing fields.\n", name)
+	}
+	fmt.Fprintf(&b, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, name)
+	fmt.Fprintf(&b, "\ttype plain %s\n", name)
+	c.writeInlineTypes(&b)
+
+	if len(c.shadows) == 0 && len(c.inlines) == 0 {
+		b.WriteString("\tvar value plain\n")
+		writeDefaults(&b, "value", c.defaults)
+	} else {
+		// The embedded plain points at the receiver, so defaults are set on it
+		writeDefaults(&b, recv, c.defaults)
+		// Embedding a pointer to the receiver decodes the other fields in place
+		b.WriteString("\tvalue := struct {\n\t\t*plain\n")
+		for _, shadow := range c.shadows {
+			fmt.Fprintf(&b, "\t\t%s *%s `json:%q`\n", shadow.name, shadow.jsonType, shadow.jsonName)
+			imports = append(imports, shadow.imports...)
+		}
+		for _, inline := range c.inlines {
+			fmt.Fprintf(&b, "\t\t%s *%s %s\n", inline.name, inline.localType(), tags.Build([]tags.Tag{{Key: "json", Value: inline.key}}, nil))
+		}
+		fmt.Fprintf(&b, "\t}{plain: (*plain)(%s)}\n", recv)
+	}
+
+	if c.strict {
+		b.WriteString("\tdecoder := json.NewDecoder(bytes.NewReader(data))\n")
+		b.WriteString("\tdecoder.DisallowUnknownFields()\n")
+		b.WriteString("\tif err := decoder.Decode(&value); err != nil {\n")
+	} else {
+		b.WriteString("\tif err := json.Unmarshal(data, &value); err != nil {\n")
+	}
+	b.WriteString("\t\treturn err\n")
+	b.WriteString("\t}\n")
+
+	if len(c.shadows) == 0 && len(c.inlines) == 0 {
+		fmt.Fprintf(&b, "\t*%s = %s(value)\n", recv, name)
+	}
+	for _, inline := range c.inlines {
+		fmt.Fprintf(&b, "\tif value.%s != nil {\n", inline.name)
+		for _, field := range inline.fields {
+			fmt.Fprintf(&b, "\t\t%s.%s = value.%s.%s\n", recv, field.GoName, inline.name, field.GoName)
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, shadow := range c.shadows {
+		fmt.Fprintf(&b, "\tif value.%s != nil {\n", shadow.name)
+		converted := shadow.decode("*value." + shadow.name)
+		if shadow.fallible {
+			fmt.Fprintf(&b, "\t\tconverted, err := %s\n", converted)
+			b.WriteString("\t\tif err != nil {\n")
+			fmt.Fprintf(&b, "\t\t\treturn fmt.Errorf(%s, err)\n", strconv.Quote(strings.ReplaceAll(shadow.jsonName, "%", "%%")+": %w"))
+			b.WriteString("\t\t}\n")
+			imports = append(imports, "fmt")
+		}
+		switch {
+		case shadow.fallible && shadow.isPointer:
+			fmt.Fprintf(&b, "\t\t%s.%s = &converted\n", recv, shadow.name)
+		case shadow.fallible:
+			fmt.Fprintf(&b, "\t\t%s.%s = converted\n", recv, shadow.name)
+		case shadow.isPointer:
+			fmt.Fprintf(&b, "\t\tconverted := %s\n", converted)
+			fmt.Fprintf(&b, "\t\t%s.%s = &converted\n", recv, shadow.name)
+		default:
+			fmt.Fprintf(&b, "\t\t%s.%s = %s\n", recv, shadow.name, converted)
+		}
+		b.WriteString("\t}\n")
+	}
+
+	b.WriteString("\treturn nil\n")
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "UnmarshalJSON", code: b.String(), imports: imports}
+}
+
+// writeInlineTypes declares the local struct of each inline object
+func (c *structCodec) writeInlineTypes(b *strings.Builder) {
+	for _, inline := range c.inlines {
+		fmt.Fprintf(b, "\ttype %s struct {\n", inline.localType())
+		for _, field := range inline.fields {
+			fmt.Fprintf(b, "\t\t%s %s %s\n", field.GoName, TypeString(field.GoType), tags.Build([]tags.Tag{{Key: "json", Value: field.InlinedTag}}, nil))
+		}
+		b.WriteString("\t}\n")
+	}
+}
+
+// writeDefaults writes the assignments of default values to the fields of target
+func writeDefaults(b *strings.Builder, target string, defaults []fieldDefault) {
+	for _, def := range defaults {
+		if def.isPointer {
+			fmt.Fprintf(b, "\t%s.%s = new(%s)\n", target, def.name, def.goType)
+			fmt.Fprintf(b, "\t*%s.%s = %s\n", target, def.name, def.literal)
+		} else {
+			fmt.Fprintf(b, "\t%s.%s = %s\n", target, def.name, def.literal)
+		}
+	}
+}
+
+// marshalMethod generates MarshalJSON for structs with shadowed, inlined or
+// omitted empty struct fields. It uses a value receiver so both values and
+// pointers marshal the same way.
+func (c *structCodec) marshalMethod() generatedMethod {
+	name := c.structDef.Name
+	recv := receiverName(name)
+	imports := []string{"encoding/json"}
+
+	var b strings.Builder
+	shadows := c.encodedShadows()
+	switch {
+	case len(shadows) > 0:
+		fmt.Fprintf(&b, "// MarshalJSON encodes %s, converting fields whose JSON representation differs.\n", name)
+	case len(c.inlines) > 0:
+		fmt.Fprintf(&b, "// MarshalJSON encodes %s, writing inlined fields in their nested objects.\n", name)
+	default:
+		fmt.Fprintf(&b, "// MarshalJSON encodes %s, leaving out nested structs that hold their zero value.\n", name)
+	}
+	if len(c.zeroStructs) > 0 && (len(shadows) > 0 || len(c.inlines) > 0) {
+		b.WriteString("// Nested structs holding their zero value are left out.\n")
+	}
+	fmt.Fprintf(&b, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, name)
+	fmt.Fprintf(&b, "\ttype plain %s\n", name)
+	if len(shadows) == 0 && len(c.inlines) == 0 {
+		c.writeZeroStructs(&b, "plain("+recv+")")
+		return generatedMethod{name: "MarshalJSON", code: b.String(), imports: append(imports, "reflect")}
+	}
+	c.writeInlineTypes(&b)
+	b.WriteString("\tvalue := struct {\n\t\tplain\n")
+	for _, shadow := range shadows {
+		jsonType := shadow.jsonType
+		if shadow.isPointer {
+			jsonType = "*" + jsonType
+		}
+		fmt.Fprintf(&b, "\t\t%s %s %s\n", shadow.name, jsonType, tags.Build([]tags.Tag{{Key: "json", Value: shadow.tagValue}}, nil))
+		imports = append(imports, shadow.imports...)
+	}
+	for _, inline := range c.inlines {
+		fmt.Fprintf(&b, "\t\t%s %s %s\n", inline.name, inline.localType(), tags.Build([]tags.Tag{{Key: "json", Value: inline.key}}, nil))
+	}
+	fmt.Fprintf(&b, "\t}{plain: plain(%s)}\n", recv)
+
+	for _, inline := range c.inlines {
+		fmt.Fprintf(&b, "\tvalue.%s = %s{\n", inline.name, inline.localType())
+		for _, field := range inline.fields {
+			fmt.Fprintf(&b, "\t\t%s: %s.%s,\n", field.GoName, recv, field.GoName)
+		}
+		b.WriteString("\t}\n")
+	}
+
+	for _, shadow := range shadows {
+		switch {
+		case shadow.encodeFallible:
+			indent := "\t"
+			src := recv + "." + shadow.name
+			if shadow.isPointer {
+				fmt.Fprintf(&b, "\tif %s != nil {\n", src)
+				indent, src = "\t\t", "*"+src
+			}
+			fmt.Fprintf(&b, "%sconverted, err := %s\n", indent, shadow.encode(src))
+			fmt.Fprintf(&b, "%sif err != nil {\n", indent)
+			fmt.Fprintf(&b, "%s\treturn nil, fmt.Errorf(%s, err)\n", indent, strconv.Quote(strings.ReplaceAll(shadow.jsonName, "%", "%%")+": %w"))
+			fmt.Fprintf(&b, "%s}\n", indent)
+			if shadow.isPointer {
+				fmt.Fprintf(&b, "\t\tvalue.%s = &converted\n", shadow.name)
+				b.WriteString("\t}\n")
+			} else {
+				fmt.Fprintf(&b, "\tvalue.%s = converted\n", shadow.name)
+			}
+			imports = append(imports, "fmt")
+		case shadow.isPointer:
+			fmt.Fprintf(&b, "\tif %s.%s != nil {\n", recv, shadow.name)
+			fmt.Fprintf(&b, "\t\tconverted := %s\n", shadow.encode("*"+recv+"."+shadow.name))
+			fmt.Fprintf(&b, "\t\tvalue.%s = &converted\n", shadow.name)
+			b.WriteString("\t}\n")
+		default:
+			fmt.Fprintf(&b, "\tvalue.%s = %s\n", shadow.name, shadow.encode(recv+"."+shadow.name))
+		}
+	}
+
+	if len(c.zeroStructs) > 0 {
+		c.writeZeroStructs(&b, "value")
+		return generatedMethod{name: "MarshalJSON", code: b.String(), imports: append(imports, "reflect")}
+	}
+	b.WriteString("\treturn json.Marshal(value)\n")
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "MarshalJSON", code: b.String(), imports: imports}
+}
+
+// writeZeroStructs ends MarshalJSON by encoding value, then decoding it into a map to
+// delete the keys of zero-valued struct fields, which omitempty can't leave out, and
+// encoding the map. The map writes the keys in sorted order.
+func (c *structCodec) writeZeroStructs(b *strings.Builder, value string) {
+	recv := receiverName(c.structDef.Name)
+	fmt.Fprintf(b, "\tdata, err := json.Marshal(%s)\n", value)
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\tvar object map[string]json.RawMessage\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &object); err != nil {\n\t\treturn nil, err\n\t}\n")
+	for _, field := range c.zeroStructs {
+		tagValue, _ := fieldJSONTag(field)
+		key, _, _ := strings.Cut(tagValue, ",")
+		fmt.Fprintf(b, "\tif reflect.ValueOf(%s.%s).IsZero() {\n", recv, field.GoName)
+		fmt.Fprintf(b, "\t\tdelete(object, %s)\n", strconv.Quote(key))
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn json.Marshal(object)\n")
+	b.WriteString("}\n")
+}
+
+// durationShadow converts a time.Duration field to and from a number in its configured unit
+func durationShadow(field models.FieldInfo) (shadowField, bool) {
+	unit, ok := config.DurationUnits[field.DurationUnit]
+	if !ok || field.GoType.Kind != models.Duration {
+		return shadowField{}, false
+	}
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return shadowField{}, false
+	}
+
+	return shadowField{
+		name:      field.GoName,
+		jsonName:  strings.Split(tagValue, ",")[0],
+		tagValue:  tagValue,
+		jsonType:  "float64",
+		isPointer: field.GoType.IsPointer,
+		decode: func(src string) string {
+			return fmt.Sprintf("time.Duration(%s * float64(%s))", src, unit)
+		},
+		encode: func(src string) string {
+			return fmt.Sprintf("float64(%s) / float64(%s)", src, unit)
+		},
+		imports: []string{"time"},
+	}, true
+}
+
+// lenientStringShadow decodes any JSON scalar into a string field, keeping the text of
+// numbers and booleans. Such fields come from merging objects that disagree on the type.
+func lenientStringShadow(field models.FieldInfo) (shadowField, bool) {
+	if !field.LenientString || field.GoType.Kind != models.String {
+		return shadowField{}, false
+	}
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return shadowField{}, false
+	}
+
+	return shadowField{
+		name:      field.GoName,
+		jsonName:  strings.Split(tagValue, ",")[0],
+		tagValue:  tagValue,
+		jsonType:  "json.RawMessage",
+		isPointer: field.GoType.IsPointer,
+		decode: func(src string) string {
+			return "func(raw json.RawMessage) string {\n" +
+				"\t\t\tvar text string\n" +
+				"\t\t\tif json.Unmarshal(raw, &text) != nil {\n" +
+				"\t\t\t\treturn string(raw)\n" +
+				"\t\t\t}\n" +
+				"\t\t\treturn text\n" +
+				"\t\t}(" + src + ")"
+		},
+	}, true
+}
+
+// formattedNumberShadow decodes a number field from JSON numbers and from strings
+// formatted with a currency symbol, thousands separators or a percent sign, which are
+// stripped: "12.5%" decodes as 12.5 and "$1,200.00" as 1200. The field marshals as a
+// plain number.
+func formattedNumberShadow(field models.FieldInfo) (shadowField, bool) {
+	if !field.FormattedNumber {
+		return shadowField{}, false
+	}
+	var parse string
+	switch field.GoType.Name {
+	case "int64":
+		parse = "strconv.ParseInt(text, 10, 64)"
+	case "float64":
+		parse = "strconv.ParseFloat(text, 64)"
+	default:
+		return shadowField{}, false
+	}
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return shadowField{}, false
+	}
+
+	return shadowField{
+		name:      field.GoName,
+		jsonName:  strings.Split(tagValue, ",")[0],
+		tagValue:  tagValue,
+		jsonType:  "json.RawMessage",
+		isPointer: field.GoType.IsPointer,
+		fallible:  true,
+		decode: func(src string) string {
+			return "func(raw json.RawMessage) (" + field.GoType.Name + ", error) {\n" +
+				"\t\t\tvar text string\n" +
+				"\t\t\tif json.Unmarshal(raw, &text) != nil {\n" +
+				"\t\t\t\ttext = string(raw)\n" +
+				"\t\t\t}\n" +
+				"\t\t\ttext = strings.NewReplacer(\"$\", \"\", \"€\", \"\", \"£\", \"\", \"¥\", \"\", \",\", \"\", \"%\", \"\").Replace(text)\n" +
+				"\t\t\treturn " + parse + "\n" +
+				"\t\t}(" + src + ")"
+		},
+		imports: []string{"strconv", "strings"},
+	}, true
+}
+
+// nestedJSONShadow decodes a types.nested_json_fields field from a JSON string holding
+// the encoded value, and from the value itself, and marshals it back into a string
+func nestedJSONShadow(field models.FieldInfo) (shadowField, bool) {
+	if !field.NestedJSON {
+		return shadowField{}, false
+	}
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return shadowField{}, false
+	}
+	goType := field.GoType
+	goType.IsPointer = false
+	typeName := TypeString(goType)
+
+	return shadowField{
+		name:      field.GoName,
+		jsonName:  strings.Split(tagValue, ",")[0],
+		tagValue:  tagValue,
+		jsonType:  "json.RawMessage",
+		isPointer: field.GoType.IsPointer,
+		fallible:  true,
+		decode: func(src string) string {
+			return "func(raw json.RawMessage) (" + typeName + ", error) {\n" +
+				"\t\t\tvar text string\n" +
+				"\t\t\tif json.Unmarshal(raw, &text) == nil {\n" +
+				"\t\t\t\traw = json.RawMessage(text)\n" +
+				"\t\t\t}\n" +
+				"\t\t\tvar decoded " + typeName + "\n" +
+				"\t\t\terr := json.Unmarshal(raw, &decoded)\n" +
+				"\t\t\treturn decoded, err\n" +
+				"\t\t}(" + src + ")"
+		},
+		encodeFallible: true,
+		encode: func(src string) string {
+			return "func(nested " + typeName + ") (json.RawMessage, error) {\n" +
+				"\t\tdata, err := json.Marshal(nested)\n" +
+				"\t\tif err != nil {\n" +
+				"\t\t\treturn nil, err\n" +
+				"\t\t}\n" +
+				"\t\treturn json.Marshal(string(data))\n" +
+				"\t}(" + src + ")"
+		},
+	}, true
+}
+
+// defaultValue returns the default of a string, number or boolean field as a Go
+// literal. Defaults of other types, or that don't fit the field's type, are ignored.
+func defaultValue(field models.FieldInfo) (fieldDefault, bool) {
+	if field.Default == nil || strings.Contains(field.GoType.Name, ".") {
+		return fieldDefault{}, false
+	}
+	if _, ok := fieldJSONTag(field); !ok {
+		return fieldDefault{}, false
+	}
+
+	var literal string
+	switch value := field.Default.(type) {
+	case string:
+		if field.GoType.Kind != models.String {
+			return fieldDefault{}, false
+		}
+		literal = strconv.Quote(value)
+	case bool:
+		if field.GoType.Kind != models.Bool {
+			return fieldDefault{}, false
+		}
+		literal = strconv.FormatBool(value)
+	case float64:
+		switch {
+		case field.GoType.Kind == models.Int && value == math.Trunc(value):
+			literal = strconv.FormatFloat(value, 'f', -1, 64)
+		case field.GoType.Kind == models.Float:
+			literal = strconv.FormatFloat(value, 'g', -1, 64)
+		default:
+			return fieldDefault{}, false
+		}
+	default:
+		return fieldDefault{}, false
+	}
+
+	goType := field.GoType
+	goType.IsPointer = false
+	return fieldDefault{
+		name:      field.GoName,
+		goType:    TypeString(goType),
+		literal:   literal,
+		isPointer: field.GoType.IsPointer,
+	}, true
+}
+
+// fieldJSONTag returns the json tag value of a field. It reports false for
+// fields excluded from JSON with "-".
+func fieldJSONTag(field models.FieldInfo) (string, bool) {
+	tagValue, ok := tags.Lookup(field.JSONTag, "json")
+	if !ok {
+		tagValue = field.JSONKey
+	}
+	if tagValue == "-" {
+		return "", false
+	}
+	if strings.HasPrefix(tagValue, ",") {
+		tagValue = field.JSONKey + tagValue
+	}
+	return tagValue, true
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// enumConstants renders the const block declaring the members of an enum type
+func enumConstants(namedType models.NamedType) string {
+	nameWidth := 0
+	for _, value := range namedType.Enum {
+		if len(value.Name) > nameWidth {
+			nameWidth = len(value.Name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Allowed values of %s.\n", namedType.Name)
+	b.WriteString("const (\n")
+	for _, value := range namedType.Enum {
+		fmt.Fprintf(&b, "\t%-*s %s = %s\n", nameWidth, value.Name, namedType.Name, value.Value)
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// enumMethods returns String for every enum, plus MarshalJSON and UnmarshalJSON for
+// numeric enums so that decoding accepts a member's symbolic name as well as its value
+func enumMethods(namedType models.NamedType) []generatedMethod {
+	if len(namedType.Enum) == 0 {
+		return nil
+	}
+	if namedType.Type.Kind == models.String {
+		return []generatedMethod{stringEnumString(namedType)}
+	}
+	return []generatedMethod{
+		numericEnumString(namedType),
+		numericEnumMarshal(namedType),
+		numericEnumUnmarshal(namedType),
+	}
+}
+
+// stringEnumString generates String for a string enum, whose values are already readable
+func stringEnumString(namedType models.NamedType) generatedMethod {
+	name := namedType.Name
+	recv := receiverName(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// String returns the string value of %s.\n", name)
+	fmt.Fprintf(&b, "func (%s %s) String() string {\n", recv, name)
+	fmt.Fprintf(&b, "\treturn string(%s)\n", recv)
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "String", code: b.String()}
+}
+
+// numericEnumString generates String mapping each member to its symbolic name
+func numericEnumString(namedType models.NamedType) generatedMethod {
+	name := namedType.Name
+	recv := receiverName(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// String returns the symbolic name of %s.\n", name)
+	fmt.Fprintf(&b, "func (%s %s) String() string {\n", recv, name)
+	fmt.Fprintf(&b, "\tswitch %s {\n", recv)
+	for _, value := range namedType.Enum {
+		fmt.Fprintf(&b, "\tcase %s:\n", value.Name)
+		fmt.Fprintf(&b, "\t\treturn %q\n", value.Label)
+	}
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\treturn \"%s(\" + strconv.FormatInt(int64(%s), 10) + \")\"\n", name, recv)
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "String", code: b.String(), imports: []string{"strconv"}}
+}
+
+// numericEnumMarshal generates MarshalJSON writing the numeric value, so the JSON
+// stays valid against the schema, and rejecting values outside the enum
+func numericEnumMarshal(namedType models.NamedType) generatedMethod {
+	name := namedType.Name
+	recv := receiverName(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// MarshalJSON encodes %s as its numeric value.\n", recv)
+	fmt.Fprintf(&b, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, name)
+	fmt.Fprintf(&b, "\tswitch %s {\n", recv)
+	fmt.Fprintf(&b, "\tcase %s:\n", enumMemberList(namedType))
+	fmt.Fprintf(&b, "\t\treturn strconv.AppendInt(nil, int64(%s), 10), nil\n", recv)
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\treturn nil, fmt.Errorf(\"invalid %s %%d\", int64(%s))\n", name, recv)
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "MarshalJSON", code: b.String(), imports: []string{"fmt", "strconv"}}
+}
+
+// numericEnumUnmarshal generates UnmarshalJSON accepting either a member's value or its symbolic name
+func numericEnumUnmarshal(namedType models.NamedType) generatedMethod {
+	name := namedType.Name
+	recv := receiverName(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// UnmarshalJSON decodes %s from its numeric value or symbolic name.\n", recv)
+	fmt.Fprintf(&b, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, name)
+	b.WriteString("\tif string(data) == \"null\" {\n")
+	b.WriteString("\t\treturn nil\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tvar label string\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &label); err == nil {\n")
+	b.WriteString("\t\tswitch label {\n")
+	for _, value := range namedType.Enum {
+		fmt.Fprintf(&b, "\t\tcase %q:\n", value.Label)
+		fmt.Fprintf(&b, "\t\t\t*%s = %s\n", recv, value.Name)
+		b.WriteString("\t\t\treturn nil\n")
+	}
+	b.WriteString("\t\t}\n")
+	fmt.Fprintf(&b, "\t\treturn fmt.Errorf(\"invalid %s %%q\", label)\n", name)
+	b.WriteString("\t}\n")
+	b.WriteString("\tvar value int64\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &value); err != nil {\n")
+	b.WriteString("\t\treturn err\n")
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\tswitch %s(value) {\n", name)
+	fmt.Fprintf(&b, "\tcase %s:\n", enumMemberList(namedType))
+	fmt.Fprintf(&b, "\t\t*%s = %s(value)\n", recv, name)
+	b.WriteString("\t\treturn nil\n")
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\treturn fmt.Errorf(\"invalid %s %%d\", value)\n", name)
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "UnmarshalJSON", code: b.String(), imports: []string{"encoding/json", "fmt"}}
+}
+
+// enumMemberList joins the constant names of an enum for a switch case
+func enumMemberList(namedType models.NamedType) string {
+	names := make([]string, len(namedType.Enum))
+	for i, value := range namedType.Enum {
+		names[i] = value.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -3,76 +3,164 @@ package generator
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/mcncl/gotyper/internal/config"
 	"github.com/mcncl/gotyper/internal/models"
 )
 
 // Generator creates Go struct definitions from analysis results
-type Generator struct{}
+type Generator struct {
+	// config holds configuration settings for generation
+	config *config.Config
+	// goGenerateArgs is the command written as a //go:generate directive, if any
+	goGenerateArgs []string
+	// warnings describes suspect input noticed by the last GenerateStructs call
+	warnings []models.Warning
+}
 
 // NewGenerator creates a new Generator
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{
+		config: config.NewConfig(), // Use default config if none provided
+	}
 }
 
-// GenerateStructs creates Go code from analysis results
-func (g *Generator) GenerateStructs(result models.AnalysisResult, packageName string) (string, error) {
-	var buf bytes.Buffer
-
-	// Write package declaration
-	buf.WriteString(fmt.Sprintf("package %s\n", packageName))
+// NewGeneratorWithConfig creates a new Generator with custom configuration.
+func NewGeneratorWithConfig(cfg *config.Config) *Generator {
+	return &Generator{
+		config: cfg,
+	}
+}
 
-	// Write imports if any
-	if len(result.Imports) > 0 {
-		buf.WriteString("\nimport (\n")
+// SetGoGenerateArgs makes generated files include a //go:generate directive
+// running the given command, e.g. []string{"gotyper", "-i", "user.json"}.
+func (g *Generator) SetGoGenerateArgs(args []string) {
+	g.goGenerateArgs = args
+}
 
-		// Sort imports for consistent output
-		imports := make([]string, 0, len(result.Imports))
-		stdLibImports := make([]string, 0)
-		thirdPartyImports := make([]string, 0)
+// Warnings returns problems with the analysis result noticed by the last GenerateStructs
+// call that didn't stop generation, such as slices without an element type
+func (g *Generator) Warnings() []models.Warning {
+	return g.warnings
+}
 
-		for imp := range result.Imports {
-			imports = append(imports, imp)
+// checkTypes records a warning for each slice without an element type, which is written
+// as []interface{} but usually means the analysis result was built incorrectly
+func (g *Generator) checkTypes(result models.AnalysisResult) {
+	g.warnings = nil
+	var check func(owner string, typeInfo models.TypeInfo)
+	check = func(owner string, typeInfo models.TypeInfo) {
+		switch {
+		case typeInfo.Kind == models.Slice && typeInfo.SliceElementType == nil:
+			g.warnings = append(g.warnings, models.Warning{
+				Type:    models.WarningUntypedSlice,
+				Message: fmt.Sprintf("%s is a slice without an element type; generating []interface{}", owner),
+			})
+		case typeInfo.SliceElementType != nil:
+			check(owner, *typeInfo.SliceElementType)
+		case typeInfo.MapValueType != nil:
+			check(owner, *typeInfo.MapValueType)
 		}
-		sort.Strings(imports)
+	}
 
-		// Separate standard library imports from third-party imports
-		for _, imp := range imports {
-			if !strings.Contains(imp, ".") { // Standard library imports don't have dots
-				stdLibImports = append(stdLibImports, imp)
-			} else {
-				thirdPartyImports = append(thirdPartyImports, imp)
-			}
+	for _, structDef := range result.Structs {
+		for _, field := range structDef.Fields {
+			check(structDef.Name+"."+field.GoName, field.GoType)
 		}
+	}
+	for _, namedType := range result.NamedTypes {
+		check(namedType.Name, namedType.Type)
+	}
+}
 
-		// Write standard library imports first
-		for _, imp := range stdLibImports {
-			buf.WriteString(fmt.Sprintf("\t\"%s\"\n", imp))
+// goGenerateDirective renders a //go:generate line. Arguments with spaces or quotes
+// are written as Go string literals, and "$" is escaped because go generate
+// expands environment variables before splitting the line.
+func goGenerateDirective(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = strconv.Quote(arg)
 		}
+		words[i] = strings.ReplaceAll(arg, "$", "$DOLLAR")
+	}
+	return "//go:generate " + strings.Join(words, " ")
+}
 
-		// Add a blank line between standard library and third-party imports if both exist
-		if len(stdLibImports) > 0 && len(thirdPartyImports) > 0 {
-			buf.WriteString("\n")
-		}
+// GenerateStructs creates Go code from analysis results
+func (g *Generator) GenerateStructs(result models.AnalysisResult, packageName string) (string, error) {
+	var buf bytes.Buffer
+	if err := g.GenerateStructsTo(&buf, result, packageName); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
 
-		// Write third-party imports
-		for _, imp := range thirdPartyImports {
-			buf.WriteString(fmt.Sprintf("\t\"%s\"\n", imp))
-		}
+// GenerateStructsTo writes the code of GenerateStructs to w as it is generated, a
+// declaration at a time, so the whole file is never held in memory. The output is
+// unformatted; gofmt needs the complete file.
+func (g *Generator) GenerateStructsTo(w io.Writer, result models.AnalysisResult, packageName string) error {
+	var buf bytes.Buffer
+	flush := func() error {
+		_, err := w.Write(buf.Bytes())
+		buf.Reset()
+		return err
+	}
+	g.checkTypes(result)
+	result = g.sortDeclarations(result)
+
+	// Collect generated methods up front so their imports can be written
+	methodsByStruct, requiredImports := g.collectMethods(result)
+
+	// Mapped types from packages with the same name would clash, so alias them
+	aliases := importAliases(result, requiredImports)
+	result = withImportAliases(result, aliases)
+
+	// Write package declaration
+	buf.WriteString(fmt.Sprintf("package %s\n", packageName))
 
-		buf.WriteString(")\n")
+	if len(g.goGenerateArgs) > 0 {
+		buf.WriteString("\n" + goGenerateDirective(g.goGenerateArgs) + "\n")
 	}
 
+	// Write imports if any
+	writeImports(&buf, requiredImports, aliases)
+
 	// Add a note if ambiguous dates were detected using the default US format
 	if result.UsedDefaultDateFormat {
 		buf.WriteString("\n// Note: Ambiguous date fields detected using US format (MM/DD/YYYY).\n")
 		buf.WriteString("// To use European format (DD/MM/YYYY), set date_format: \"eu\" in .gotyper.yml\n")
 	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// Write named non-struct types
+	for _, namedType := range result.NamedTypes {
+		buf.WriteString("\n")
+		writeNamedType(&buf, namedType, namedTypeMethods(namedType))
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	// Write package-level constants before the types that use them
+	for _, constant := range result.Constants {
+		buf.WriteString("\n")
+		writeConstant(&buf, constant)
+	}
+
+	if usesUUIDFormat(methodsByStruct) {
+		buf.WriteString("\n" + uuidFormatVar)
+	}
 
 	// Sort structs to ensure root structs come first
-	sortedStructs := sortStructs(result.Structs)
+	sortedStructs := g.sortStructs(result.Structs)
 
 	// Write struct definitions
 	for i, structDef := range sortedStructs {
@@ -81,53 +169,19 @@ func (g *Generator) GenerateStructs(result models.AnalysisResult, packageName st
 			buf.WriteString("\n")
 		}
 
-		// Write struct definition
-		buf.WriteString(fmt.Sprintf("type %s struct {\n", structDef.Name))
-
-		// Sort fields alphabetically by GoName for consistent output
-		sortedFields := make([]models.FieldInfo, len(structDef.Fields))
-		copy(sortedFields, structDef.Fields)
-		sort.Slice(sortedFields, func(i, j int) bool {
-			return sortedFields[i].GoName < sortedFields[j].GoName
-		})
-
-		// Calculate the maximum width for field names and types for proper alignment
-		maxNameWidth := 0
-		maxTypeWidth := 0
-		for _, field := range sortedFields {
-			nameWidth := len(field.GoName)
-			typeWidth := len(getTypeString(field.GoType))
-			if nameWidth > maxNameWidth {
-				maxNameWidth = nameWidth
-			}
-			if typeWidth > maxTypeWidth {
-				maxTypeWidth = typeWidth
-			}
-		}
-
-		// Write fields
-		for _, field := range sortedFields {
-			typeStr := getTypeString(field.GoType)
-			if field.Comment != "" {
-				buf.WriteString(fmt.Sprintf("\t%-*s %-*s %s // %s\n",
-					maxNameWidth, field.GoName,
-					maxTypeWidth, typeStr,
-					field.JSONTag,
-					field.Comment))
-			} else {
-				buf.WriteString(fmt.Sprintf("\t%-*s %-*s %s\n",
-					maxNameWidth, field.GoName,
-					maxTypeWidth, typeStr,
-					field.JSONTag))
-			}
-		}
-
-		buf.WriteString("}\n")
+		g.writeStruct(&buf, structDef, methodsByStruct[structDef.Name])
 
 		// Add a newline between structs
 		if i < len(sortedStructs)-1 {
 			buf.WriteString("\n")
 		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if g.config.Output.GenerateTypeRegistry && len(sortedStructs) > 0 {
+		buf.WriteString("\n" + typeRegistry(sortedStructs))
 	}
 
 	// If the result includes a struct that's not marked as root, it might be an array element type
@@ -147,14 +201,331 @@ func (g *Generator) GenerateStructs(result models.AnalysisResult, packageName st
 		buf.WriteString(fmt.Sprintf("// type %ss []%s\n", structDef.Name, structDef.Name))
 	}
 
-	return buf.String(), nil
+	if example := g.usageExample(result, packageName); example != "" {
+		buf.WriteString("\n" + example)
+	}
+
+	return flush()
 }
 
-// sortStructs puts root structs first, then nested structs
-func sortStructs(structs []models.StructDef) []models.StructDef {
+// usageExample returns the output.usage_example comment, showing how to decode JSON
+// into the root type as code in another package would: qualified by
+// output.types_package_alias, or the generated package unless that is main. It is
+// empty when the option is off or there is no root type, as for a root array.
+func (g *Generator) usageExample(result models.AnalysisResult, packageName string) string {
+	if !g.config.Output.UsageExample {
+		return ""
+	}
+	var root string
+	for _, structDef := range result.Structs {
+		if structDef.IsRoot {
+			root = structDef.Name
+			break
+		}
+	}
+	if root == "" {
+		for _, namedType := range result.NamedTypes {
+			if namedType.Name == g.config.RootName {
+				root = namedType.Name
+				break
+			}
+		}
+	}
+	if root == "" {
+		return ""
+	}
+
+	qualifier := g.config.Output.TypesPackageAlias
+	if qualifier == "" {
+		qualifier = packageName
+	}
+	if qualifier != "main" {
+		root = qualifier + "." + root
+	}
+
+	var b strings.Builder
+	b.WriteString("// Example:\n")
+	b.WriteString("//\n")
+	fmt.Fprintf(&b, "//\tvar value %s\n", root)
+	b.WriteString("//\tif err := json.Unmarshal(data, &value); err != nil {\n")
+	b.WriteString("//\t\treturn err\n")
+	b.WriteString("//\t}\n")
+	return b.String()
+}
+
+// collectMethods generates the methods of every struct, returned by struct name, and
+// gathers the imports the result and all generated methods need
+func (g *Generator) collectMethods(result models.AnalysisResult) (map[string][]generatedMethod, map[string]struct{}) {
+	requiredImports := make(map[string]struct{}, len(result.Imports))
+	for imp := range result.Imports {
+		requiredImports[imp] = struct{}{}
+	}
+	methodsByStruct := make(map[string][]generatedMethod)
+	markers := unionMarkerMethods(result.NamedTypes)
+	for _, structDef := range result.Structs {
+		methods := append(g.structMethods(structDef), markers[structDef.Name]...)
+		for _, method := range methods {
+			for _, imp := range method.imports {
+				requiredImports[imp] = struct{}{}
+			}
+		}
+		methodsByStruct[structDef.Name] = methods
+	}
+	for _, namedType := range result.NamedTypes {
+		for _, method := range namedTypeMethods(namedType) {
+			for _, imp := range method.imports {
+				requiredImports[imp] = struct{}{}
+			}
+		}
+	}
+	return methodsByStruct, requiredImports
+}
+
+// writeImports writes an import block, standard library first, naming the imports in
+// aliases. Nothing is written without imports.
+func writeImports(buf *bytes.Buffer, requiredImports map[string]struct{}, aliases map[string]string) {
+	if len(requiredImports) == 0 {
+		return
+	}
+	buf.WriteString("\nimport (\n")
+
+	// Sort imports for consistent output
+	imports := make([]string, 0, len(requiredImports))
+	stdLibImports := make([]string, 0)
+	thirdPartyImports := make([]string, 0)
+
+	for imp := range requiredImports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	// Separate standard library imports from third-party imports
+	for _, imp := range imports {
+		if !strings.Contains(imp, ".") { // Standard library imports don't have dots
+			stdLibImports = append(stdLibImports, imp)
+		} else {
+			thirdPartyImports = append(thirdPartyImports, imp)
+		}
+	}
+
+	writeImport := func(imp string) {
+		if alias, ok := aliases[imp]; ok {
+			buf.WriteString(fmt.Sprintf("\t%s \"%s\"\n", alias, imp))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t\"%s\"\n", imp))
+		}
+	}
+
+	// Write standard library imports first
+	for _, imp := range stdLibImports {
+		writeImport(imp)
+	}
+
+	// Add a blank line between standard library and third-party imports if both exist
+	if len(stdLibImports) > 0 && len(thirdPartyImports) > 0 {
+		buf.WriteString("\n")
+	}
+
+	// Write third-party imports
+	for _, imp := range thirdPartyImports {
+		writeImport(imp)
+	}
+
+	buf.WriteString(")\n")
+}
+
+// writeNamedType writes a named non-struct type with its enum constants and methods
+func writeNamedType(buf *bytes.Buffer, namedType models.NamedType, methods []generatedMethod) {
+	if namedType.Comment != "" {
+		buf.WriteString(fmt.Sprintf("// %s\n", namedType.Comment))
+	}
+	switch {
+	case namedType.Union != nil:
+		buf.WriteString(unionDeclarations(namedType))
+	case namedType.IsAlias:
+		buf.WriteString(fmt.Sprintf("type %s = %s\n", namedType.Name, TypeString(namedType.Type)))
+	default:
+		buf.WriteString(fmt.Sprintf("type %s %s\n", namedType.Name, TypeString(namedType.Type)))
+	}
+	if len(namedType.Enum) > 0 {
+		buf.WriteString("\n" + enumConstants(namedType))
+	}
+	for _, method := range methods {
+		buf.WriteString("\n" + method.code)
+	}
+}
+
+// namedTypeMethods returns the methods generated for a named type: those of enums,
+// and the decoding of a union's slice
+func namedTypeMethods(namedType models.NamedType) []generatedMethod {
+	if namedType.Union != nil {
+		return []generatedMethod{unionUnmarshalMethod(namedType)}
+	}
+	return enumMethods(namedType)
+}
+
+// writeConstant writes a package-level constant with its doc comment
+func writeConstant(buf *bytes.Buffer, constant models.ConstantDef) {
+	if constant.Comment != "" {
+		buf.WriteString(fmt.Sprintf("// %s\n", constant.Comment))
+	}
+	buf.WriteString(fmt.Sprintf("const %s = %s\n", constant.Name, constant.Value))
+}
+
+// writeStruct writes a struct definition, with its doc comment, followed by its methods
+func (g *Generator) writeStruct(buf *bytes.Buffer, structDef models.StructDef, methods []generatedMethod) {
+	comment := structDef.Comment
+	if g.config.Output.SourcePathComments && structDef.SourcePath != "" {
+		comment = strings.TrimSpace(comment + "\n\nSource: " + structDef.SourcePath)
+	}
+	if g.config.Output.OptionalityNotes {
+		comment = strings.TrimSpace(comment + "\n\n" + optionalityNote(structDef))
+	}
+	if structDef.Deprecated {
+		// A paragraph starting "Deprecated:" is what go vet, staticcheck and gopls look for
+		comment = strings.TrimSpace(comment + "\n\nDeprecated: type is deprecated.")
+	}
+	for _, line := range g.wrapComment(commentLines(comment)) {
+		buf.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", structDef.Name))
+
+	// Sort fields alphabetically by GoName for consistent output. With output.field_order
+	// "required_first", required (non-pointer) fields come before optional ones.
+	requiredFirst := g.config.Output.FieldOrder == config.FieldOrderRequiredFirst
+	sortedFields := make([]models.FieldInfo, len(structDef.Fields))
+	copy(sortedFields, structDef.Fields)
+	sort.Slice(sortedFields, func(i, j int) bool {
+		a, b := sortedFields[i], sortedFields[j]
+		if requiredFirst && a.GoType.IsPointer != b.GoType.IsPointer {
+			return !a.GoType.IsPointer
+		}
+		return a.GoName < b.GoName
+	})
+
+	// Calculate the maximum width for field names and types for proper alignment
+	maxNameWidth := 0
+	maxTypeWidth := 0
+	for _, field := range sortedFields {
+		nameWidth := len(field.GoName)
+		typeWidth := len(TypeString(field.GoType))
+		if nameWidth > maxNameWidth {
+			maxNameWidth = nameWidth
+		}
+		if typeWidth > maxTypeWidth {
+			maxTypeWidth = typeWidth
+		}
+	}
+
+	// Group fields by category when requested, keeping alphabetical order within each group
+	var fieldGroups []int
+	if g.config.Output.GroupFields {
+		sortedFields, fieldGroups = g.groupFields(sortedFields)
+	}
+
+	// Write fields
+	for fieldIndex, field := range sortedFields {
+		if fieldIndex > 0 && fieldGroups != nil && fieldGroups[fieldIndex] != fieldGroups[fieldIndex-1] {
+			buf.WriteString("\n")
+		}
+		typeStr := TypeString(field.GoType)
+		docLines := commentLines(field.Doc)
+		if field.Comment != "" && g.config.Output.CommentWrap > 0 && commentWidth(field.Comment) > g.config.Output.CommentWrap {
+			// A trailing comment can't span lines, so a long one moves above the field
+			if len(docLines) > 0 {
+				docLines = append(docLines, "")
+			}
+			docLines = append(docLines, commentLines(field.Comment)...)
+			field.Comment = ""
+		}
+		for _, line := range g.wrapComment(docLines) {
+			buf.WriteString(strings.TrimRight("\t// "+line, " ") + "\n")
+		}
+		if field.Deprecated {
+			if len(docLines) > 0 {
+				buf.WriteString("\t//\n")
+			}
+			// Tools only recognise deprecation in a field's doc comment, not a trailing one
+			buf.WriteString("\t// Deprecated: field is deprecated.\n")
+		}
+		if field.Comment != "" {
+			buf.WriteString(fmt.Sprintf("\t%-*s %-*s %s // %s\n",
+				maxNameWidth, field.GoName,
+				maxTypeWidth, typeStr,
+				field.JSONTag,
+				field.Comment))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t%-*s %-*s %s\n",
+				maxNameWidth, field.GoName,
+				maxTypeWidth, typeStr,
+				field.JSONTag))
+		}
+	}
+
+	buf.WriteString("}\n")
+
+	// Write any methods generated for this struct
+	for _, method := range methods {
+		buf.WriteString("\n")
+		buf.WriteString(method.code)
+	}
+}
+
+// typeRegistry declares AllTypes, a zero value of each struct in output order, for code
+// that registers models with a router or serializer
+func typeRegistry(structs []models.StructDef) string {
+	var buf strings.Builder
+	buf.WriteString("// AllTypes holds a zero value of every generated struct.\n")
+	buf.WriteString("var AllTypes = []interface{}{\n")
+	for _, structDef := range structs {
+		buf.WriteString(fmt.Sprintf("\t%s{},\n", structDef.Name))
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// groupFields stably orders fields by their output.field_groups category and
+// returns the group index of each field in the new order
+func (g *Generator) groupFields(fields []models.FieldInfo) ([]models.FieldInfo, []int) {
+	groupOf := make(map[string]int, len(fields))
+	for _, field := range fields {
+		kinds := []string{string(field.GoType.Kind)}
+		if field.GoType.SliceElementType != nil {
+			kinds = append(kinds, string(field.GoType.SliceElementType.Kind))
+		}
+		groupOf[field.GoName] = g.config.FieldGroupIndex(field.JSONKey, kinds...)
+	}
+
+	grouped := make([]models.FieldInfo, len(fields))
+	copy(grouped, fields)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return groupOf[grouped[i].GoName] < groupOf[grouped[j].GoName]
+	})
+
+	groups := make([]int, len(grouped))
+	for i, field := range grouped {
+		groups[i] = groupOf[field.GoName]
+	}
+	return grouped, groups
+}
+
+// sortStructs orders structs for output according to output.struct_order.
+// By default root structs come first, then nested structs by name.
+func (g *Generator) sortStructs(structs []models.StructDef) []models.StructDef {
 	sorted := make([]models.StructDef, len(structs))
 	copy(sorted, structs)
 
+	switch g.config.Output.StructOrder {
+	case config.StructOrderDeclaration:
+		// The analyzer already returns structs in discovery order
+		return sorted
+	case config.StructOrderName:
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+		return sorted
+	}
+
 	sort.Slice(sorted, func(i, j int) bool {
 		// If one is root and the other is not, root comes first
 		if sorted[i].IsRoot != sorted[j].IsRoot {
@@ -167,20 +538,165 @@ func sortStructs(structs []models.StructDef) []models.StructDef {
 	return sorted
 }
 
-// getTypeString converts TypeInfo to Go type string
-func getTypeString(typeInfo models.TypeInfo) string {
+// sortDeclarations orders the named types and constants of result for output, by
+// name and then value, so their order doesn't depend on how they were collected.
+// With output.struct_order declaration they are left in the order they were found,
+// like structs.
+func (g *Generator) sortDeclarations(result models.AnalysisResult) models.AnalysisResult {
+	if g.config.Output.StructOrder == config.StructOrderDeclaration {
+		return result
+	}
+
+	namedTypes := make([]models.NamedType, len(result.NamedTypes))
+	copy(namedTypes, result.NamedTypes)
+	sort.SliceStable(namedTypes, func(i, j int) bool {
+		return namedTypes[i].Name < namedTypes[j].Name
+	})
+	constants := make([]models.ConstantDef, len(result.Constants))
+	copy(constants, result.Constants)
+	sort.SliceStable(constants, func(i, j int) bool {
+		if constants[i].Name != constants[j].Name {
+			return constants[i].Name < constants[j].Name
+		}
+		return constants[i].Value < constants[j].Value
+	})
+
+	result.NamedTypes = namedTypes
+	result.Constants = constants
+	return result
+}
+
+// optionalityNote lists the JSON keys of a struct by whether every sample had them.
+// Keys inside an inlined object are written as its key and theirs, e.g. meta.version.
+func optionalityNote(structDef models.StructDef) string {
+	var required, optional []string
+	for _, field := range structDef.Fields {
+		key := field.JSONKey
+		if field.InlinedFrom != "" {
+			// A field promoted by types.inline_fields is still under its nested object
+			key = field.InlinedFrom + "." + key
+		}
+		if field.Optional {
+			optional = append(optional, key)
+		} else {
+			required = append(required, key)
+		}
+	}
+	sort.Strings(required)
+	sort.Strings(optional)
+
+	var lines []string
+	if len(required) > 0 {
+		lines = append(lines, "Required (in every sample): "+strings.Join(required, ", "))
+	}
+	if len(optional) > 0 {
+		lines = append(lines, "Optional (missing from some samples): "+strings.Join(optional, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentLines splits a multi-line comment, dropping surrounding blank lines
+func commentLines(comment string) []string {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return nil
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// wrapComment breaks comment lines longer than output.comment_wrap at spaces outside
+// backtick-quoted text. Indented lines, which go doc shows as preformatted, and words
+// too long to break are kept whole.
+func (g *Generator) wrapComment(lines []string) []string {
+	width := g.config.Output.CommentWrap
+	if width <= 0 {
+		return lines
+	}
+	var wrapped []string
+	for _, line := range lines {
+		if commentWidth(line) <= width || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		current := ""
+		for _, word := range commentWords(line) {
+			switch {
+			case current == "":
+				current = word
+			case commentWidth(current+" "+word) > width:
+				wrapped = append(wrapped, current)
+				current = word
+			default:
+				current += " " + word
+			}
+		}
+		wrapped = append(wrapped, current)
+	}
+	return wrapped
+}
+
+// commentWidth is the length in characters of a comment line with its "// " prefix
+func commentWidth(text string) int {
+	return utf8.RuneCountInString("// " + text)
+}
+
+// commentWords splits a comment line at spaces, keeping backtick-quoted text in one word
+func commentWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '`':
+			quoted = !quoted
+			word.WriteRune(r)
+		case r == ' ' && !quoted:
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// TypeString renders a TypeInfo as the Go type expression the generator writes
+func TypeString(typeInfo models.TypeInfo) string {
 	var typeStr string
 
 	switch typeInfo.Kind {
 	case models.Struct:
 		typeStr = typeInfo.StructName
 	case models.Slice:
-		if typeInfo.SliceElementType != nil {
-			elementType := getTypeString(*typeInfo.SliceElementType)
+		if typeInfo.ArrayLength > 0 && typeInfo.SliceElementType != nil {
+			typeStr = fmt.Sprintf("[%d]%s", typeInfo.ArrayLength, TypeString(*typeInfo.SliceElementType))
+		} else if typeInfo.Name != "" && !strings.HasPrefix(typeInfo.Name, "[]") {
+			// A named slice type, such as a union's Shapes
+			typeStr = typeInfo.Name
+		} else if typeInfo.SliceElementType != nil {
+			elementType := TypeString(*typeInfo.SliceElementType)
 			typeStr = "[]" + elementType
 		} else {
 			typeStr = "[]interface{}"
 		}
+	case models.Map:
+		if typeInfo.MapValueType != nil {
+			typeStr = "map[string]" + TypeString(*typeInfo.MapValueType)
+		} else {
+			typeStr = "map[string]interface{}"
+		}
+	case models.Interface:
+		// Name may be unset on hand-built TypeInfo; an empty type would not compile
+		typeStr = "interface{}"
 	default:
 		typeStr = typeInfo.Name
 	}
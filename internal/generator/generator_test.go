@@ -1,9 +1,21 @@
 package generator
 
 import (
+	"errors"
+	"go/format"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/formats"
 	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,16 +58,7 @@ func TestGenerateStructs_SimpleObject(t *testing.T) {
 	result, err := generator.GenerateStructs(analysisResult, "main")
 
 	require.NoError(t, err)
-	expectedCode := `package main
-
-type Person struct {
-	Age      int64  ` + "`json:\"age\"`" + `
-	IsActive bool   ` + "`json:\"is_active\"`" + `
-	Name     string ` + "`json:\"name\"`" + `
-}
-`
-
-	assert.Equal(t, expectedCode, result)
+	testutil.Golden(t, "simple_object", result)
 }
 
 func TestGenerateStructs_NestedStructs(t *testing.T) {
@@ -113,20 +116,7 @@ func TestGenerateStructs_NestedStructs(t *testing.T) {
 	result, err := generator.GenerateStructs(analysisResult, "main")
 
 	require.NoError(t, err)
-	expectedCode := `package main
-
-type User struct {
-	Profile *UserProfile ` + "`json:\"profile,omitempty\"`" + `
-	UserId  int64        ` + "`json:\"user_id\"`" + `
-}
-
-type UserProfile struct {
-	Email    string ` + "`json:\"email\"`" + `
-	FullName string ` + "`json:\"full_name\"`" + `
-}
-`
-
-	assert.Equal(t, expectedCode, result)
+	testutil.Golden(t, "nested_structs", result)
 }
 
 func TestGenerateStructs_WithImports(t *testing.T) {
@@ -168,22 +158,7 @@ func TestGenerateStructs_WithImports(t *testing.T) {
 	result, err := generator.GenerateStructs(analysisResult, "main")
 
 	require.NoError(t, err)
-	expectedCode := `package main
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type Event struct {
-	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
-	EventId   uuid.UUID ` + "`json:\"event_id\"`" + `
-	Name      string    ` + "`json:\"name\"`" + `
-}
-`
-
-	assert.Equal(t, expectedCode, result)
+	testutil.Golden(t, "with_imports", result)
 }
 
 func TestGenerateStructs_ArrayType(t *testing.T) {
@@ -216,18 +191,7 @@ func TestGenerateStructs_ArrayType(t *testing.T) {
 	result, err := generator.GenerateStructs(analysisResult, "main")
 
 	require.NoError(t, err)
-	expectedCode := `package main
-
-type Product struct {
-	Id   int64  ` + "`json:\"id\"`" + `
-	Name string ` + "`json:\"name\"`" + `
-}
-
-// For a root array type, you would typically define a type alias like:
-// type Products []Product
-`
-
-	assert.Equal(t, expectedCode, result)
+	testutil.Golden(t, "array_type", result)
 }
 
 func TestGenerateStructs_EmptyResult(t *testing.T) {
@@ -307,3 +271,1682 @@ func TestGenerateStructs_NoDateFormatComment(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotContains(t, result, "Ambiguous date fields")
 }
+
+func TestGenerateStructs_DisallowUnknownFields(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Person",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{
+						JSONKey: "name",
+						GoName:  "Name",
+						GoType:  models.TypeInfo{Kind: models.String, Name: "string"},
+						JSONTag: "`json:\"name\"`",
+					},
+				},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.DisallowUnknownFields = true
+	generator := NewGeneratorWithConfig(cfg)
+	code, err := generator.GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "func (p *Person) UnmarshalJSON(data []byte) error {")
+	assert.Contains(t, code, "decoder.DisallowUnknownFields()")
+	assert.Contains(t, code, "\"bytes\"")
+	assert.Contains(t, code, "\"encoding/json\"")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var exact Person
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"name":"Ada"}`+"`"+`), &exact), exact.Name)
+
+	var extra Person
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"name":"Ada","age":36}`+"`"+`), &extra) != nil)
+}
+`)
+	assert.Equal(t, "<nil> Ada\ntrue\n", output)
+}
+
+func TestGenerateStructs_PerStructDisallowUnknownFields(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Person", IsRoot: true, DisallowUnknownFields: true},
+			{Name: "Address"},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (p *Person) UnmarshalJSON(data []byte) error {")
+	assert.NotContains(t, code, "func (a *Address) UnmarshalJSON")
+}
+
+func TestGenerateStructs_Constants(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Person",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "kind", GoName: "Kind", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"kind\"`"},
+				},
+			},
+		},
+		Constants: []models.ConstantDef{
+			{Name: "PersonKind", Value: `"person"`, Comment: "PersonKind is the fixed value of Person.Kind."},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// PersonKind is the fixed value of Person.Kind.\nconst PersonKind = \"person\"\n")
+	assert.Less(t, strings.Index(code, "const PersonKind"), strings.Index(code, "type Person struct"))
+}
+
+func TestGenerateStructs_DurationFields(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Config",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+					{JSONKey: "timeout_seconds", GoName: "TimeoutSeconds", GoType: models.TypeInfo{Kind: models.Duration, Name: "time.Duration"}, JSONTag: "`json:\"timeout_seconds\"`", DurationUnit: "s"},
+					{JSONKey: "retry_ms", GoName: "RetryMs", GoType: models.TypeInfo{Kind: models.Duration, Name: "time.Duration", IsPointer: true}, JSONTag: "`json:\"retry_ms,omitempty\"`", DurationUnit: "ms"},
+				},
+			},
+		},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (c *Config) UnmarshalJSON(data []byte) error {")
+	assert.Contains(t, code, "func (c Config) MarshalJSON() ([]byte, error) {")
+	assert.NotContains(t, code, "DisallowUnknownFields")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var cfg Config
+	err := json.Unmarshal([]byte(`+"`"+`{"name":"worker","timeout_seconds":1.5,"retry_ms":250}`+"`"+`), &cfg)
+	fmt.Println(err, cfg.Name, cfg.TimeoutSeconds, *cfg.RetryMs)
+
+	data, err := json.Marshal(cfg)
+	fmt.Println(err, string(data))
+
+	cfg.RetryMs = nil
+	data, _ = json.Marshal(&cfg)
+	fmt.Println(string(data))
+}
+`)
+	assert.Equal(t, "<nil> worker 1.5s 250ms\n"+
+		`<nil> {"name":"worker","timeout_seconds":1.5,"retry_ms":250}`+"\n"+
+		`{"name":"worker","timeout_seconds":1.5}`+"\n", output)
+}
+
+func TestGenerateStructs_DurationFieldsStrict(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.DisallowUnknownFields = true
+
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Config",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "timeout_seconds", GoName: "TimeoutSeconds", GoType: models.TypeInfo{Kind: models.Duration, Name: "time.Duration"}, JSONTag: "`json:\"timeout_seconds\"`", DurationUnit: "s"},
+				},
+			},
+		},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	// Strict decoding and duration conversion share a single UnmarshalJSON
+	assert.Equal(t, 1, strings.Count(code, "UnmarshalJSON("))
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var ok Config
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"timeout_seconds":2}`+"`"+`), &ok), ok.TimeoutSeconds)
+
+	var extra Config
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"timeout_seconds":2,"other":1}`+"`"+`), &extra) != nil)
+}
+`)
+	assert.Equal(t, "<nil> 2s\ntrue\n", output)
+}
+
+func TestGenerateStructs_NumericEnum(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Task",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "priority", GoName: "Priority", GoType: models.TypeInfo{Kind: models.Int, Name: "TaskPriority"}, JSONTag: "`json:\"priority\"`"},
+				},
+			},
+		},
+		NamedTypes: []models.NamedType{
+			{
+				Name: "TaskPriority",
+				Type: models.TypeInfo{Kind: models.Int, Name: "int64"},
+				Enum: []models.EnumValue{
+					{Name: "TaskPriorityLow", Label: "Low", Value: "1"},
+					{Name: "TaskPriorityMedium", Label: "Medium", Value: "2"},
+					{Name: "TaskPriorityHigh", Label: "High", Value: "3"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "const (\n\tTaskPriorityLow    TaskPriority = 1\n")
+	assert.Contains(t, code, "// String returns the symbolic name of TaskPriority.\nfunc (t TaskPriority) String() string {")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriority(7))
+
+	for _, input := range []string{`+"`"+`{"priority":3}`+"`"+`, `+"`"+`{"priority":"Medium"}`+"`"+`, `+"`"+`{"priority":4}`+"`"+`, `+"`"+`{"priority":"Urgent"}`+"`"+`} {
+		var task Task
+		err := json.Unmarshal([]byte(input), &task)
+		fmt.Println(task.Priority, err)
+	}
+
+	data, err := json.Marshal(Task{Priority: TaskPriorityHigh})
+	fmt.Println(string(data), err)
+	_, err = json.Marshal(Task{Priority: 9})
+	fmt.Println(err != nil)
+}
+`)
+	assert.Equal(t, "Low Medium High TaskPriority(7)\n"+
+		"High <nil>\n"+
+		"Medium <nil>\n"+
+		"TaskPriority(0) invalid TaskPriority 4\n"+
+		"TaskPriority(0) invalid TaskPriority \"Urgent\"\n"+
+		`{"priority":3} <nil>`+"\n"+
+		"true\n", output)
+}
+
+func TestGenerateStructs_StringEnum(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		NamedTypes: []models.NamedType{
+			{
+				Name:    "Status",
+				Type:    models.TypeInfo{Kind: models.String, Name: "string"},
+				Comment: "Status is a JSON Schema enum; its values are declared below.",
+				Enum: []models.EnumValue{
+					{Name: "StatusInProgress", Label: "in-progress", Value: `"in-progress"`},
+					{Name: "StatusDone", Label: "done", Value: `"done"`},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "models")
+	require.NoError(t, err)
+	assert.Contains(t, code, "type Status string\n\n"+
+		"// Allowed values of Status.\n"+
+		"const (\n"+
+		"\tStatusInProgress Status = \"in-progress\"\n"+
+		"\tStatusDone       Status = \"done\"\n"+
+		")\n\n"+
+		"// String returns the string value of Status.\n"+
+		"func (s Status) String() string {\n"+
+		"\treturn string(s)\n"+
+		"}\n")
+	assert.NotContains(t, code, "MarshalJSON")
+	assert.NotContains(t, code, "import")
+}
+
+func TestGenerateStructs_LenientStringFields(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Item",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "ID", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"id,omitempty\"`", LenientString: true},
+					{JSONKey: "code", GoName: "Code", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"code\"`", LenientString: true},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (i *Item) UnmarshalJSON(data []byte) error {")
+	assert.NotContains(t, code, "MarshalJSON", "strings marshal as they are")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{`+"`"+`{"id":42,"code":true}`+"`"+`, `+"`"+`{"id":"abc","code":"x"}`+"`"+`, `+"`"+`{"id":null,"code":1.5}`+"`"+`} {
+		var item Item
+		err := json.Unmarshal([]byte(input), &item)
+		id := "<nil>"
+		if item.ID != nil {
+			id = *item.ID
+		}
+		data, _ := json.Marshal(item)
+		fmt.Println(err, id, item.Code, string(data))
+	}
+}
+`)
+	assert.Equal(t, `<nil> 42 true {"code":"true","id":"42"}`+"\n"+
+		`<nil> abc x {"code":"x","id":"abc"}`+"\n"+
+		`<nil> <nil> 1.5 {"code":"1.5"}`+"\n", output)
+}
+
+func TestGenerateStructs_GoGenerateDirective(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{Name: "User", IsRoot: true}},
+	}
+
+	generator := NewGenerator()
+	generator.SetGoGenerateArgs([]string{"gotyper", "-i", "my data/user.json", "-o", "user.go", "-r", `Say"Hi"`, "-c", "$HOME.yml"})
+	code, err := generator.GenerateStructs(analysisResult, "models")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(code, "package models\n\n"+
+		`//go:generate gotyper -i "my data/user.json" -o user.go -r "Say\"Hi\"" -c $DOLLARHOME.yml`+"\n"))
+
+	// No directive unless requested
+	code, err = NewGenerator().GenerateStructs(analysisResult, "models")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "go:generate")
+}
+
+func TestGenerateStructs_NamedTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		namedType models.NamedType
+		expected  string
+	}{
+		{name: "string", namedType: models.NamedType{Name: "RootType", Type: models.TypeInfo{Kind: models.String, Name: "string"}}, expected: "type RootType string\n"},
+		{name: "int64", namedType: models.NamedType{Name: "RootType", Type: models.TypeInfo{Kind: models.Int, Name: "int64"}}, expected: "type RootType int64\n"},
+		{name: "float64", namedType: models.NamedType{Name: "RootType", Type: models.TypeInfo{Kind: models.Float, Name: "float64"}}, expected: "type RootType float64\n"},
+		{name: "bool", namedType: models.NamedType{Name: "RootType", Type: models.TypeInfo{Kind: models.Bool, Name: "bool"}}, expected: "type RootType bool\n"},
+		{name: "alias", namedType: models.NamedType{Name: "RootType", Type: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, IsAlias: true}, expected: "type RootType = time.Time\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := NewGenerator().GenerateStructs(models.AnalysisResult{NamedTypes: []models.NamedType{tt.namedType}}, "main")
+			require.NoError(t, err)
+			assert.Equal(t, "package main\n\n"+tt.expected, code)
+		})
+	}
+}
+
+func TestGenerateStructs_NoUnmarshalByDefault(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Person", IsRoot: true},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "UnmarshalJSON")
+	assert.NotContains(t, code, "import")
+}
+
+func TestGenerateStructs_InterfaceStubs(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Person",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+				},
+				Methods: []models.MethodDef{
+					{Name: "Validate", Params: "ctx context.Context", Results: "error", Imports: []string{"context"}},
+				},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "\"context\"")
+	assert.Contains(t, code, "func (*Person) Validate(ctx context.Context) error {\n\tpanic(\"not implemented\")\n}")
+
+	// The stub must satisfy the interface it was generated from
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+func main() {
+	var v Validator = &Person{}
+	fmt.Println(v != nil)
+}
+`)
+	assert.Equal(t, "true\n", output)
+}
+
+func TestGenerateStructs_InterfaceStubFieldClash(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Person",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+				},
+				Methods: []models.MethodDef{
+					{Name: "Name", Results: "string"},
+					{Name: "Close", Results: "error"},
+				},
+			},
+		},
+	}
+
+	generatorInst := NewGenerator()
+	code, err := generatorInst.GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "func (*Person) Name()")
+	assert.Contains(t, code, "func (*Person) Close() error {")
+	assert.Equal(t, []models.Warning{{
+		Type:    models.WarningMethodClash,
+		Message: "Person has a field named Name, so it gets no Name stub and doesn't implement the interface",
+	}}, generatorInst.Warnings())
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Person{Name: "Ann"}.Name)
+}
+`)
+	assert.Equal(t, "Ann\n", output)
+}
+
+func TestGenerateStructs_InterfaceStubsKeepGeneratedMethods(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.DisallowUnknownFields = true
+
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Person",
+				IsRoot: true,
+				Methods: []models.MethodDef{
+					{Name: "UnmarshalJSON", Params: "data []byte", Results: "error"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(code, "UnmarshalJSON(data []byte) error"))
+	assert.NotContains(t, code, "not implemented")
+}
+
+// runGeneratedProgram compiles generated code alongside mainSource in a
+// temporary module and returns the program's output
+func runGeneratedProgram(t *testing.T, generated, mainSource string) string {
+	t.Helper()
+	return runGeneratedFiles(t, map[string]string{"generated.go": generated}, mainSource)
+}
+
+// runGeneratedFiles is runGeneratedProgram for code generated as several files
+func runGeneratedFiles(t *testing.T, generated map[string]string, mainSource string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module generated\n\ngo 1.21\n",
+		"main.go": mainSource,
+	}
+	for name, content := range generated {
+		files[name] = content
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "generated program failed: %s", string(output))
+	return string(output)
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:    "Event",
+				IsRoot:  true,
+				Comment: "An audit event. Ends with */ on purpose.",
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "ID", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+					{JSONKey: "at", GoName: "At", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time", IsPointer: true}, JSONTag: "`json:\"at\"`"},
+					{JSONKey: "tags", GoName: "Tags", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]interface{}", IsPointer: true, SliceElementType: &models.TypeInfo{Kind: models.Interface, Name: "interface{}"}}, JSONTag: "`json:\"tags,omitempty\"`"},
+					{JSONKey: "secret", GoName: "Secret", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"-\"`"},
+					{JSONKey: "2fa", GoName: "X2fa", GoType: models.TypeInfo{Kind: models.Bool, Name: "bool"}, JSONTag: "`json:\"2fa\"`", Comment: "Two-factor enabled"},
+				},
+			},
+		},
+	}
+
+	declarations, err := NewGenerator().GenerateTypeScript(analysisResult)
+	require.NoError(t, err)
+	assert.Contains(t, declarations, "/** An audit event. Ends with *\\/ on purpose. */\n"+
+		"export interface Event {\n"+
+		"  at: string | null;\n"+
+		"  id: number;\n"+
+		"  tags?: unknown[];\n"+
+		"  /** Two-factor enabled */\n"+
+		"  \"2fa\": boolean;\n"+
+		"}\n")
+	assert.NotContains(t, declarations, "secret")
+
+	// The Go output carries the same struct comment
+	code, err := NewGenerator().GenerateStructs(analysisResult, "models")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// An audit event. Ends with */ on purpose.\ntype Event struct {\n")
+}
+
+func TestGenerateStructs_MapFields(t *testing.T) {
+	user := models.TypeInfo{Kind: models.Struct, Name: "User", StructName: "User"}
+	userPointer := user
+	userPointer.IsPointer = true
+
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Root",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "by_id", GoName: "ByID", GoType: models.TypeInfo{Kind: models.Map, Name: "map[string]User", MapValueType: &user}, JSONTag: "`json:\"by_id\"`"},
+					{JSONKey: "by_name", GoName: "ByName", GoType: models.TypeInfo{Kind: models.Map, Name: "map[string]*User", MapValueType: &userPointer}, JSONTag: "`json:\"by_name\"`"},
+				},
+			},
+			{
+				Name:   "User",
+				Fields: []models.FieldInfo{{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"}},
+			},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Regexp(t, `ByID\s+map\[string\]User\s+`+"`", code)
+	assert.Regexp(t, `ByName\s+map\[string\]\*User\s+`+"`", code)
+}
+
+func TestGenerateFromTemplate(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name: "UserAddress",
+				Fields: []models.FieldInfo{
+					{JSONKey: "zip_code", GoName: "ZipCode", GoType: models.TypeInfo{Kind: models.String, Name: "string"}},
+				},
+			},
+			{
+				Name:   "User",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "user_id", GoName: "UserId", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}},
+					{JSONKey: "address", GoName: "Address", GoType: models.TypeInfo{Kind: models.Struct, Name: "UserAddress", StructName: "UserAddress", IsPointer: true}},
+				},
+			},
+		},
+	}
+	text := `package {{.Package}}
+{{range .Structs}}
+{{snake .Name}}:{{range .Fields}} {{pascal .JSONKey}} {{typeString .GoType}};{{end}}
+{{- end}}
+`
+
+	output, err := NewGenerator().GenerateFromTemplate(analysisResult, "models", text)
+	require.NoError(t, err)
+	assert.Equal(t, "package models\n\n"+
+		"user: UserId int64; Address *UserAddress;\n"+
+		"user_address: ZipCode string;\n", output)
+
+	_, err = NewGenerator().GenerateFromTemplate(analysisResult, "models", "{{.Missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid template")
+}
+
+func TestGenerateFromTemplate_Helpers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Naming.CustomSingulars = map[string]string{"cacti": "cactus"}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Garden",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{
+						JSONKey: "planted_at",
+						GoName:  "PlantedAt",
+						GoType:  models.TypeInfo{Kind: models.Time, Name: "time.Time", IsPointer: true},
+						JSONTag: "`json:\"planted_at,omitempty\" yaml:\"planted_at\"`",
+					},
+					{
+						JSONKey: "cacti",
+						GoName:  "Cacti",
+						GoType:  models.TypeInfo{Kind: models.Slice, Name: "[]string", SliceElementType: &models.TypeInfo{Kind: models.String, Name: "string"}},
+						JSONTag: "`json:\"cacti\"`",
+					},
+				},
+			},
+		},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"goType", `{{range (index .Structs 0).Fields}}{{goType .GoType}} {{end}}`, "*time.Time []string "},
+		{"jsonTag", `{{range (index .Structs 0).Fields}}{{jsonTag .}} {{end}}`, "planted_at,omitempty cacti "},
+		{"isPointer", `{{range (index .Structs 0).Fields}}{{isPointer .GoType}} {{end}}`, "true false "},
+		{"pascal", `{{pascal "planted_at"}}`, "PlantedAt"},
+		{"snake", `{{snake "PlantedAt"}}`, "planted_at"},
+		{"singular", `{{singular "Gardens"}} {{singular "cacti"}}`, "Garden cactus"},
+		{"plural", `{{plural "Garden"}} {{plural "category"}}`, "Gardens categories"},
+		{"imports", `{{range $path, $_ := .Imports}}{{$path}}{{end}}`, "time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NewGeneratorWithConfig(cfg).GenerateFromTemplate(analysisResult, "main", tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, output)
+		})
+	}
+}
+
+func TestGenerateStructs_Merge(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Patch",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"name,omitempty\"`"},
+					{JSONKey: "age", GoName: "Age", GoType: models.TypeInfo{Kind: models.Int, Name: "int64", IsPointer: true}, JSONTag: "`json:\"age,omitempty\"`"},
+				},
+			},
+			{
+				Name: "Plain",
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+				},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateMerge = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (p Patch) Merge(other Patch) Patch {")
+	assert.NotContains(t, code, "func (p Plain) Merge")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	name, age, newAge := "Ada", int64(36), int64(37)
+	merged := Patch{Name: &name, Age: &age}.Merge(Patch{Age: &newAge})
+	fmt.Println(*merged.Name, *merged.Age, *Patch{}.Merge(Patch{Name: &name}).Name)
+}
+`)
+	assert.Equal(t, "Ada 37 Ada\n", output)
+
+	code, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "Merge")
+}
+
+func TestGenerateStructs_TypeRegistry(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Profile", Fields: []models.FieldInfo{{JSONKey: "bio", GoName: "Bio", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"bio\"`"}}},
+			{Name: "Address", Fields: []models.FieldInfo{{JSONKey: "city", GoName: "City", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"city\"`"}}},
+			{Name: "User", IsRoot: true, Fields: []models.FieldInfo{{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"}}},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateTypeRegistry = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "var AllTypes = []interface{}{\n\tUser{},\n\tAddress{},\n\tProfile{},\n}")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func main() {
+	for _, v := range AllTypes {
+		fmt.Println(reflect.TypeOf(v).Name())
+	}
+}
+`)
+	assert.Equal(t, "User\nAddress\nProfile\n", output)
+
+	code, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "AllTypes")
+}
+
+func TestTypeString_EdgeCases(t *testing.T) {
+	tests := map[string]struct {
+		typeInfo models.TypeInfo
+		expected string
+	}{
+		"interface without name":     {models.TypeInfo{Kind: models.Interface}, "interface{}"},
+		"interface pointer":          {models.TypeInfo{Kind: models.Interface, IsPointer: true}, "*interface{}"},
+		"slice of unnamed interface": {models.TypeInfo{Kind: models.Slice, SliceElementType: &models.TypeInfo{Kind: models.Interface}}, "[]interface{}"},
+		"slice without element":      {models.TypeInfo{Kind: models.Slice, Name: "[]string"}, "[]interface{}"},
+		"map without value":          {models.TypeInfo{Kind: models.Map}, "map[string]interface{}"},
+	}
+	for name, tt := range tests {
+		assert.Equal(t, tt.expected, TypeString(tt.typeInfo), name)
+	}
+}
+
+func TestGenerateStructs_WarnsOnSliceWithoutElementType(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Root",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "items", GoName: "Items", GoType: models.TypeInfo{Kind: models.Slice, IsPointer: true}, JSONTag: "`json:\"items\"`"},
+				{JSONKey: "groups", GoName: "Groups", GoType: models.TypeInfo{Kind: models.Map, MapValueType: &models.TypeInfo{Kind: models.Slice}}, JSONTag: "`json:\"groups\"`"},
+				{JSONKey: "names", GoName: "Names", GoType: models.TypeInfo{Kind: models.Slice, SliceElementType: &models.TypeInfo{Kind: models.String, Name: "string"}}, JSONTag: "`json:\"names\"`"},
+				{JSONKey: "extra", GoName: "Extra", GoType: models.TypeInfo{Kind: models.Interface}, JSONTag: "`json:\"extra\"`"},
+			},
+		}},
+		Imports: map[string]struct{}{},
+	}
+
+	generatorInst := NewGenerator()
+	code, err := generatorInst.GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	_, err = format.Source([]byte(code))
+	require.NoError(t, err, code)
+	assert.Contains(t, code, "Extra  interface{}")
+	assert.Equal(t, []models.Warning{
+		{Type: models.WarningUntypedSlice, Message: "Root.Items is a slice without an element type; generating []interface{}"},
+		{Type: models.WarningUntypedSlice, Message: "Root.Groups is a slice without an element type; generating []interface{}"},
+	}, generatorInst.Warnings())
+
+	analysisResult.Structs[0].Fields = analysisResult.Structs[0].Fields[2:]
+	_, err = generatorInst.GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Empty(t, generatorInst.Warnings())
+}
+
+func TestGenerateStructs_Equal(t *testing.T) {
+	stringType := models.TypeInfo{Kind: models.String, Name: "string"}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "User",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+					{JSONKey: "nickname", GoName: "Nickname", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"nickname,omitempty\"`"},
+					{JSONKey: "joined", GoName: "Joined", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, JSONTag: "`json:\"joined\"`"},
+					{JSONKey: "address", GoName: "Address", GoType: models.TypeInfo{Kind: models.Struct, Name: "Address", StructName: "Address", IsPointer: true}, JSONTag: "`json:\"address,omitempty\"`"},
+					{JSONKey: "tags", GoName: "Tags", GoType: models.TypeInfo{Kind: models.Slice, IsPointer: true, SliceElementType: &stringType}, JSONTag: "`json:\"tags,omitempty\"`"},
+				},
+			},
+			{
+				Name:   "Address",
+				Fields: []models.FieldInfo{{JSONKey: "city", GoName: "City", GoType: stringType, JSONTag: "`json:\"city\"`"}},
+			},
+		},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateEqual = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (u User) Equal(other User) bool {")
+	assert.Contains(t, code, "func (a Address) Equal(other Address) bool {")
+	assert.Contains(t, code, `"reflect"`)
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func main() {
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	nick, sameNick, otherNick := "ann", "ann", "bob"
+	tags, sameTags := []string{"a"}, []string{"a"}
+	base := User{Id: 1, Nickname: &nick, Joined: joined, Address: &Address{City: "Oslo"}, Tags: &tags}
+
+	same := base
+	same.Nickname = &sameNick
+	same.Joined = joined.In(time.FixedZone("CET", 3600))
+	same.Address = &Address{City: "Oslo"}
+	same.Tags = &sameTags
+	fmt.Println(base.Equal(same), base.Equal(base))
+
+	for _, change := range []func(*User){
+		func(u *User) { u.Id = 2 },
+		func(u *User) { u.Nickname = &otherNick },
+		func(u *User) { u.Nickname = nil },
+		func(u *User) { u.Joined = joined.Add(time.Second) },
+		func(u *User) { u.Address = &Address{City: "Bergen"} },
+		func(u *User) { u.Address = nil },
+		func(u *User) { u.Tags = &[]string{"b"} },
+	} {
+		changed := base
+		change(&changed)
+		fmt.Print(base.Equal(changed), changed.Equal(base), " ")
+	}
+	fmt.Println(User{}.Equal(User{}))
+}
+`)
+	assert.Equal(t, "true true\n"+strings.Repeat("false false ", 7)+"true\n", output)
+}
+
+func TestAppendDeclarations(t *testing.T) {
+	existing := `package models
+
+import "fmt"
+
+// User is maintained by hand
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+func (u User) String() string { return fmt.Sprint(u.Name) }
+`
+	generated := `package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	Name  string    ` + "`json:\"name\"`" + `
+	Since time.Time ` + "`json:\"since\"`" + `
+}
+
+// UnmarshalJSON is generated for User
+func (u *User) UnmarshalJSON(data []byte) error { return json.Unmarshal(data, u) }
+
+// Order is new
+type Order struct {
+	At time.Time ` + "`json:\"at\"`" + `
+	Id uuid.UUID ` + "`json:\"id\"`" + `
+}
+
+// MarshalJSON is generated for Order
+func (o Order) MarshalJSON() ([]byte, error) { return json.Marshal(o.At) }
+`
+
+	merged, err := AppendDeclarations([]byte(existing), []byte(generated))
+	require.NoError(t, err)
+	code := string(merged)
+
+	// The existing User and its method stay; the generated User and its method don't come along
+	assert.Equal(t, 1, strings.Count(code, "type User struct"))
+	assert.Contains(t, code, "// User is maintained by hand\ntype User struct {\n\tName string `json:\"name\"`\n}")
+	assert.NotContains(t, code, "Since")
+	assert.NotContains(t, code, "func (u *User) UnmarshalJSON")
+	assert.Contains(t, code, "// Order is new\ntype Order struct {")
+	assert.Contains(t, code, "// MarshalJSON is generated for Order\nfunc (o Order) MarshalJSON()")
+
+	// Imports are merged and deduplicated, standard library first
+	assert.Contains(t, code, "import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"time\"\n\n\t\"github.com/google/uuid\"\n)\n")
+
+	// Appending the same code again changes nothing
+	again, err := AppendDeclarations(merged, []byte(generated))
+	require.NoError(t, err)
+	assert.Equal(t, code, string(again))
+}
+
+func TestGenerateStructs_AliasesCollidingImports(t *testing.T) {
+	result := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Root",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "owner", GoName: "Owner", GoType: models.TypeInfo{Kind: models.String, Name: "util.UserID", Import: "github.com/a/util"}, JSONTag: "`json:\"owner\"`"},
+				{JSONKey: "region", GoName: "Region", GoType: models.TypeInfo{Kind: models.String, Name: "*util.Region", Import: "github.com/b/util/v2"}, JSONTag: "`json:\"region\"`"},
+				{JSONKey: "created", GoName: "Created", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, JSONTag: "`json:\"created\"`"},
+			},
+		}},
+		Imports: map[string]struct{}{
+			"github.com/a/util":    {},
+			"github.com/b/util/v2": {},
+			"time":                 {},
+		},
+	}
+
+	code, err := NewGenerator().GenerateStructs(result, "main")
+	require.NoError(t, err)
+	_, err = format.Source([]byte(code))
+	require.NoError(t, err, code)
+
+	assert.Contains(t, code, "\tutil1 \"github.com/a/util\"\n")
+	assert.Contains(t, code, "\tutil2 \"github.com/b/util/v2\"\n")
+	assert.Contains(t, code, "\t\"time\"\n")
+	assert.Contains(t, code, "Owner   util1.UserID")
+	assert.Contains(t, code, "Region  *util2.Region")
+	assert.Contains(t, code, "Created time.Time")
+
+	// The caller's result is left as it was
+	assert.Equal(t, "util.UserID", result.Structs[0].Fields[0].GoType.Name)
+
+	// Without a collision imports keep their own names
+	delete(result.Imports, "github.com/b/util/v2")
+	result.Structs[0].Fields = result.Structs[0].Fields[:1]
+	code, err = NewGenerator().GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "\t\"github.com/a/util\"\n")
+	assert.Contains(t, code, "util.UserID")
+}
+
+func TestGenerateStructs_SQLJSON(t *testing.T) {
+	stringType := models.TypeInfo{Kind: models.String, Name: "string"}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Order",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+					{JSONKey: "address", GoName: "Address", GoType: models.TypeInfo{Kind: models.Struct, Name: "Address", StructName: "Address", IsPointer: true}, JSONTag: "`json:\"address,omitempty\"`"},
+					{JSONKey: "amount", GoName: "Amount", GoType: models.TypeInfo{Kind: models.Struct, Name: "Amount", StructName: "Amount"}, JSONTag: "`json:\"amount\"`"},
+				},
+			},
+			{
+				Name:   "Address",
+				Fields: []models.FieldInfo{{JSONKey: "city", GoName: "City", GoType: stringType, JSONTag: "`json:\"city\"`"}},
+			},
+			{
+				Name:   "Amount",
+				Fields: []models.FieldInfo{{JSONKey: "value", GoName: "Value", GoType: models.TypeInfo{Kind: models.Float, Name: "float64"}, JSONTag: "`json:\"value\"`"}},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateSQLJSON = true
+	generatorInst := NewGeneratorWithConfig(cfg)
+	code, err := generatorInst.GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, `"database/sql/driver"`)
+	assert.Contains(t, code, "func (a *Address) Scan(src interface{}) error {")
+	assert.Contains(t, code, "func (a Address) Value() (driver.Value, error) {")
+	assert.NotContains(t, code, "func (o *Order) Scan", "root structs are rows, not columns")
+	assert.NotContains(t, code, "func (a *Amount) Scan", "a Value field rules out the methods")
+	assert.Equal(t, []models.Warning{{
+		Type:    models.WarningMethodClash,
+		Message: "Amount has a field named Value, so it gets no Scan and Value methods",
+	}}, generatorInst.Warnings())
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+var (
+	_ sql.Scanner   = (*Address)(nil)
+	_ driver.Valuer = Address{}
+)
+
+func main() {
+	stored, err := Address{City: "Oslo"}.Value()
+	fmt.Println(string(stored.([]byte)), err)
+
+	var fromBytes, fromString Address
+	fmt.Println(fromBytes.Scan(stored), fromBytes.City)
+	fmt.Println(fromString.Scan(`+"`"+`{"city":"Bergen"}`+"`"+`), fromString.City)
+	fmt.Println(fromString.Scan(nil), fromString.City == "")
+	fmt.Println(fromString.Scan(42))
+}
+`)
+	assert.Equal(t, `{"city":"Oslo"} <nil>
+<nil> Oslo
+<nil> Bergen
+<nil> true
+cannot scan int into Address
+`, output)
+}
+
+func TestGenerateStructs_InterfaceUnion(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Drawing",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "shapes", GoName: "Shapes", GoType: models.TypeInfo{Kind: models.Slice, Name: "Shapes", IsPointer: true, SliceElementType: &models.TypeInfo{Kind: models.Interface, Name: "Shape"}}, JSONTag: "`json:\"shapes,omitempty\"`"},
+				},
+			},
+			{
+				Name: "CircleShape",
+				Fields: []models.FieldInfo{
+					{JSONKey: "radius", GoName: "Radius", GoType: models.TypeInfo{Kind: models.Float, Name: "float64"}, JSONTag: "`json:\"radius\"`"},
+					{JSONKey: "type", GoName: "Type", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"type\"`"},
+				},
+			},
+			{
+				Name: "SquareShape",
+				Fields: []models.FieldInfo{
+					{JSONKey: "side", GoName: "Side", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"side\"`"},
+					{JSONKey: "type", GoName: "Type", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"type\"`"},
+				},
+			},
+		},
+		NamedTypes: []models.NamedType{
+			{
+				Name: "Shape",
+				Type: models.TypeInfo{Kind: models.Interface, Name: "Shape"},
+				Union: &models.Union{
+					Discriminator: "type",
+					SliceName:     "Shapes",
+					Variants:      []models.UnionVariant{{Value: "circle", Struct: "CircleShape"}, {Value: "square", Struct: "SquareShape"}},
+				},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "type Shape interface {\n\tisShape()\n}")
+	assert.Contains(t, code, "type Shapes []Shape")
+	assert.Contains(t, code, "Shapes *Shapes `json:\"shapes,omitempty\"`")
+	assert.Contains(t, code, "func (CircleShape) isShape() {}")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var drawing Drawing
+	err := json.Unmarshal([]byte(`+"`"+`{"shapes":[{"type":"square","side":2},null,{"type":"circle","radius":1.5}]}`+"`"+`), &drawing)
+	fmt.Println(err)
+	for _, shape := range *drawing.Shapes {
+		fmt.Printf("%T %v\n", shape, shape)
+	}
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"shapes":[{"type":"star"}]}`+"`"+`), &drawing))
+}
+`)
+	assert.Equal(t, "<nil>\n*main.SquareShape &{2 square}\n<nil> <nil>\n*main.CircleShape &{1.5 circle}\nelement 0: unknown type \"star\"\n", output)
+}
+
+func TestGenerateStructs_FormattedNumbers(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Offer",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "rate", GoName: "Rate", GoType: models.TypeInfo{Kind: models.Float, Name: "float64"}, JSONTag: "`json:\"rate\"`", FormattedNumber: true},
+				{JSONKey: "price", GoName: "Price", GoType: models.TypeInfo{Kind: models.Float, Name: "float64", IsPointer: true}, JSONTag: "`json:\"price,omitempty\"`", FormattedNumber: true},
+				{JSONKey: "count", GoName: "Count", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"count\"`", FormattedNumber: true},
+			},
+		}},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func (o *Offer) UnmarshalJSON(data []byte) error {")
+	assert.NotContains(t, code, "MarshalJSON()", "values marshal as plain numbers")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var offer Offer
+	err := json.Unmarshal([]byte(`+"`"+`{"rate": "12.5%", "price": "$1,200.00", "count": "1,024"}`+"`"+`), &offer)
+	fmt.Println(err, offer.Rate, *offer.Price, offer.Count)
+	err = json.Unmarshal([]byte(`+"`"+`{"rate": 3, "price": null, "count": 7}`+"`"+`), &offer)
+	fmt.Println(err, offer.Rate, offer.Count)
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"count": "12.5%"}`+"`"+`), &offer) != nil)
+	data, _ := json.Marshal(Offer{Rate: 12.5, Count: 3})
+	fmt.Println(string(data))
+}
+`)
+	assert.Equal(t, "<nil> 12.5 1200 1024\n<nil> 3 7\ntrue\n{\"count\":3,\"rate\":12.5}\n", output)
+}
+
+func TestGenerateStructs_InlineFields(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Doc",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+				{JSONKey: "version", GoName: "MetaVersion", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"-\"`", InlinedFrom: "meta", InlinedTag: "version"},
+				{JSONKey: "created_by", GoName: "MetaCreatedBy", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"-\"`", InlinedFrom: "meta", InlinedTag: "created_by,omitempty"},
+			},
+		}},
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "InlineMeta *inlineMeta `json:\"meta\"`")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var doc Doc
+	err := json.Unmarshal([]byte(`+"`"+`{"id": 7, "meta": {"version": 3, "created_by": "bob"}}`+"`"+`), &doc)
+	fmt.Println(err, doc.Id, doc.MetaVersion, *doc.MetaCreatedBy)
+	data, _ := json.Marshal(Doc{Id: 1, MetaVersion: 2})
+	fmt.Println(string(data))
+}
+`)
+	assert.Equal(t, "<nil> 7 3 bob\n{\"id\":1,\"meta\":{\"version\":2}}\n", output)
+}
+
+func TestGenerateStructs_DeclarationOrder(t *testing.T) {
+	enum := func(name string, values ...string) models.NamedType {
+		namedType := models.NamedType{Name: name, Type: models.TypeInfo{Kind: models.String, Name: "string"}}
+		for _, value := range values {
+			namedType.Enum = append(namedType.Enum, models.EnumValue{Name: name + strings.ToUpper(value[:1]) + value[1:], Label: value, Value: strconv.Quote(value)})
+		}
+		return namedType
+	}
+	namedTypes := []models.NamedType{
+		enum("Status", "open", "done"),
+		enum("Priority", "low", "high"),
+		enum("Color", "red", "green"),
+	}
+	constants := []models.ConstantDef{
+		{Name: "OrderKind", Value: `"order"`},
+		{Name: "ApiVersion", Value: `"v2"`},
+	}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Ticket",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "status", GoName: "Status", GoType: models.TypeInfo{Kind: models.String, Name: "Status"}, JSONTag: "`json:\"status\"`"},
+			},
+		}},
+	}
+
+	var first string
+	random := rand.New(rand.NewPCG(1, 2))
+	for run := range 10 {
+		// Collections are filled from maps in places, so any order can come in
+		analysisResult.NamedTypes = slices.Clone(namedTypes)
+		analysisResult.Constants = slices.Clone(constants)
+		random.Shuffle(len(analysisResult.NamedTypes), func(i, j int) {
+			analysisResult.NamedTypes[i], analysisResult.NamedTypes[j] = analysisResult.NamedTypes[j], analysisResult.NamedTypes[i]
+		})
+		random.Shuffle(len(analysisResult.Constants), func(i, j int) {
+			analysisResult.Constants[i], analysisResult.Constants[j] = analysisResult.Constants[j], analysisResult.Constants[i]
+		})
+
+		code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+		require.NoError(t, err)
+		if run == 0 {
+			first = code
+			continue
+		}
+		require.Equal(t, first, code, "run %d", run)
+	}
+
+	color := strings.Index(first, "type Color string")
+	priority := strings.Index(first, "type Priority string")
+	status := strings.Index(first, "type Status string")
+	assert.True(t, color >= 0 && color < priority && priority < status, first)
+	assert.Less(t, strings.Index(first, "const ApiVersion"), strings.Index(first, "const OrderKind"))
+	// Members keep their declared order
+	assert.Less(t, strings.Index(first, "StatusOpen"), strings.Index(first, "StatusDone"))
+
+	// Declaration order keeps them as given
+	cfg := config.NewConfig()
+	cfg.Output.StructOrder = config.StructOrderDeclaration
+	analysisResult.NamedTypes = namedTypes
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Less(t, strings.Index(code, "type Status string"), strings.Index(code, "type Priority string"))
+}
+
+func TestGenerateStructs_OmitEmptyStructs(t *testing.T) {
+	address := models.TypeInfo{Kind: models.Struct, Name: "Address", StructName: "Address"}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "User",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+					{JSONKey: "home", GoName: "Home", GoType: address, JSONTag: "`json:\"home,omitempty\"`"},
+					{JSONKey: "work", GoName: "Work", GoType: address, JSONTag: "`json:\"work\"`"},
+				},
+			},
+			{
+				Name: "Address",
+				Fields: []models.FieldInfo{
+					{JSONKey: "city", GoName: "City", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"city\"`"},
+					{JSONKey: "lines", GoName: "Lines", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]string", SliceElementType: &models.TypeInfo{Kind: models.String, Name: "string"}}, JSONTag: "`json:\"lines\"`"},
+				},
+			},
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.OmitEmptyStructs = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// MarshalJSON encodes User, leaving out nested structs that hold their zero value.\n")
+	assert.Equal(t, 1, strings.Count(code, "MarshalJSON()"), "Address has no nested structs")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, err := json.Marshal(User{Name: "Ann", Work: Address{City: "Oslo"}})
+	fmt.Println(string(data), err)
+	data, err = json.Marshal(&User{Name: "Bob"})
+	fmt.Println(string(data), err)
+}
+`)
+	assert.Equal(t, `{"name":"Ann","work":{"city":"Oslo","lines":null}} <nil>
+{"name":"Bob"} <nil>
+`, output)
+
+	// Off by default
+	code, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "MarshalJSON")
+}
+
+func TestGenerateStructs_Constructors(t *testing.T) {
+	item := models.TypeInfo{Kind: models.Struct, Name: "Item", StructName: "Item", IsPointer: true}
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Cart",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "items", GoName: "Items", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]*Item", SliceElementType: &item}, JSONTag: "`json:\"items\"`"},
+					{JSONKey: "tags", GoName: "Tags", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]string", SliceElementType: &models.TypeInfo{Kind: models.String, Name: "string"}}, JSONTag: "`json:\"tags\"`"},
+					{JSONKey: "coupons", GoName: "Coupons", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]string", IsPointer: true, SliceElementType: &models.TypeInfo{Kind: models.String, Name: "string"}}, JSONTag: "`json:\"coupons,omitempty\"`"},
+				},
+			},
+			{
+				Name: "Item",
+				Fields: []models.FieldInfo{
+					{JSONKey: "sku", GoName: "Sku", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"sku\"`"},
+				},
+			},
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateConstructors = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "Items: make([]*Item, 0),")
+	assert.Contains(t, code, "Coupons: &[]string{},")
+	assert.Contains(t, code, "// NewItem returns a pointer to a new, zero-valued Item.\nfunc NewItem() *Item {\n\treturn &Item{}\n}")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	cart := NewCart()
+	var items []*Item = cart.Items
+	fmt.Println(items != nil, len(items))
+	data, err := json.Marshal(cart)
+	fmt.Println(string(data), err)
+	data, err = json.Marshal(Cart{})
+	fmt.Println(string(data), err)
+}
+`)
+	assert.Equal(t, `true 0
+{"coupons":[],"items":[],"tags":[]} <nil>
+{"items":null,"tags":null} <nil>
+`, output)
+
+	// Off by default
+	code, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "func NewCart")
+}
+
+func TestGenerateStructs_CommentWrap(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:    "Payment",
+			IsRoot:  true,
+			Comment: "A payment made by a customer, settled through the configured provider once `settlement_mode` allows it.",
+			Fields: []models.FieldInfo{
+				{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`", Comment: "Short"},
+				{JSONKey: "status", GoName: "Status", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"status\"`", Comment: "Current state of the payment, one of `pending approval` or `settled`. Example: \"pending\""},
+			},
+		}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.CommentWrap = 40
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "// A payment made by a customer, settled\n"+
+		"// through the configured provider once\n"+
+		"// `settlement_mode` allows it.\n"+
+		"type Payment struct {\n")
+	assert.Contains(t, code, "\t// Current state of the payment, one of\n"+
+		"\t// `pending approval` or `settled`.\n"+
+		"\t// Example: \"pending\"\n"+
+		"\tStatus string `json:\"status\"`\n")
+	assert.Contains(t, code, "`json:\"id\"` // Short\n", "short trailing comments stay in place")
+	for _, line := range strings.Split(code, "\n") {
+		if comment := strings.TrimLeft(line, "\t"); strings.HasPrefix(comment, "//") {
+			assert.LessOrEqual(t, len(comment), 40, comment)
+		}
+	}
+
+	// Off by default
+	code, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// A payment made by a customer, settled through the configured provider once `settlement_mode` allows it.\n")
+}
+
+func TestGenerateStructs_UsageExample(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Account",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+			},
+		}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.UsageExample = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "billing")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(code, "}\n\n// Example:\n//\n//\tvar value billing.Account\n//\tif err := json.Unmarshal(data, &value); err != nil {\n//\t\treturn err\n//\t}\n"), code)
+
+	cfg.Output.TypesPackageAlias = "api"
+	code, err = NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "billing")
+	require.NoError(t, err)
+	assert.Contains(t, code, "//\tvar value api.Account\n")
+
+	// Code in package main refers to its types unqualified
+	cfg.Output.TypesPackageAlias = ""
+	code, err = NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "//\tvar value Account\n")
+
+	// A root array has no root type to decode into
+	analysisResult.Structs[0].IsRoot = false
+	code, err = NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "billing")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "// Example:")
+}
+
+func TestGenerateStructs_DefaultsOnUnmarshal(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Settings",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+					{JSONKey: "limit", GoName: "Limit", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"limit\"`", Default: float64(10)},
+					{JSONKey: "locale", GoName: "Locale", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"locale,omitempty\"`", Default: "en"},
+					{JSONKey: "ratio", GoName: "Ratio", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"ratio\"`", Default: 0.5},
+				},
+			},
+			{
+				Name: "Retry",
+				Fields: []models.FieldInfo{
+					{JSONKey: "enabled", GoName: "Enabled", GoType: models.TypeInfo{Kind: models.Bool, Name: "bool"}, JSONTag: "`json:\"enabled\"`", Default: true},
+					{JSONKey: "delay", GoName: "Delay", GoType: models.TypeInfo{Kind: models.Duration, Name: "time.Duration"}, JSONTag: "`json:\"delay\"`", DurationUnit: "s"},
+				},
+			},
+		},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.DefaultsOnUnmarshal = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// UnmarshalJSON decodes JSON into Settings, keeping default values for missing fields.")
+	assert.NotContains(t, code, "Ratio =", "a fractional default doesn't fit an integer field")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var missing, present Settings
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"name":"a"}`+"`"+`), &missing), missing.Limit, *missing.Locale)
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"name":"b","limit":5,"locale":"nb"}`+"`"+`), &present), present.Limit, *present.Locale)
+
+	var retry Retry
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"delay":2}`+"`"+`), &retry), retry.Enabled, retry.Delay)
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`{"enabled":false}`+"`"+`), &retry), retry.Enabled)
+}
+`)
+	assert.Equal(t, "<nil> 10 en\n<nil> 5 nb\n<nil> true 2s\n<nil> false\n", output)
+}
+
+func TestGenerateStructs_RuntimeFormatValidation(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{
+				Name:   "Device",
+				IsRoot: true,
+				Fields: []models.FieldInfo{
+					{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"id\"`", Format: "uuid"},
+					{JSONKey: "owner", GoName: "Owner", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"owner,omitempty\"`", Format: "uuid"},
+					{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`", Format: "hostname"},
+				},
+			},
+			{
+				Name:   "Label",
+				Fields: []models.FieldInfo{{JSONKey: "text", GoName: "Text", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"text\"`"}},
+			},
+		},
+		Imports: map[string]struct{}{},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Schema.RuntimeFormatValidation = true
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "var uuidFormat = regexp.MustCompile(`"+formats.UUID+"`)")
+	assert.Contains(t, code, "func (d Device) Validate() error {")
+	assert.NotContains(t, code, "func (l Label) Validate", "structs without checked formats get no method")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	owner := "not-a-uuid"
+	fmt.Println(Device{Id: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"}.Validate())
+	fmt.Println(Device{}.Validate())
+	fmt.Println(Device{Id: "42"}.Validate())
+	fmt.Println(Device{Id: "3f2504e0-4f89-11d3-9a0c-0305e82c3301", Owner: &owner}.Validate())
+}
+`)
+	assert.Equal(t, "<nil>\n<nil>\nid: \"42\" is not a valid uuid\nowner: \"not-a-uuid\" is not a valid uuid\n", output)
+}
+
+// chunkWriter records each write, failing once it has accepted limit of them
+type chunkWriter struct {
+	chunks []string
+	limit  int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if w.limit > 0 && len(w.chunks) == w.limit {
+		return 0, errors.New("disk full")
+	}
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func TestGenerateStructsTo(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Order", IsRoot: true, Fields: []models.FieldInfo{
+				{JSONKey: "status", GoName: "Status", GoType: models.TypeInfo{Kind: models.String, Name: "Status"}, JSONTag: "`json:\"status\"`"},
+				{JSONKey: "placed", GoName: "Placed", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, JSONTag: "`json:\"placed\"`"},
+				{JSONKey: "items", GoName: "Items", GoType: models.TypeInfo{Kind: models.Slice, Name: "[]Item", SliceElementType: &models.TypeInfo{Kind: models.Struct, StructName: "Item"}}, JSONTag: "`json:\"items\"`"},
+			}},
+			{Name: "Item", Fields: []models.FieldInfo{
+				{JSONKey: "sku", GoName: "Sku", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"sku\"`"},
+			}},
+		},
+		NamedTypes: []models.NamedType{{Name: "Status", Type: models.TypeInfo{Kind: models.String, Name: "string"}}},
+		Constants:  []models.ConstantDef{{Name: "OrderKind", Value: `"order"`}},
+		Imports:    map[string]struct{}{"time": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateEqual = true
+	cfg.Output.GenerateTypeRegistry = true
+	buffered, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "shop")
+	require.NoError(t, err)
+
+	var w chunkWriter
+	require.NoError(t, NewGeneratorWithConfig(cfg).GenerateStructsTo(&w, analysisResult, "shop"))
+	assert.Equal(t, buffered, strings.Join(w.chunks, ""))
+	// The header, the named type, each struct and the type registry are written separately
+	assert.Len(t, w.chunks, 5)
+
+	// A failed write stops generation and is returned
+	failing := chunkWriter{limit: 2}
+	err = NewGeneratorWithConfig(cfg).GenerateStructsTo(&failing, analysisResult, "shop")
+	assert.EqualError(t, err, "disk full")
+	assert.Len(t, failing.chunks, 2)
+}
+
+func TestGenerateStructs_FieldOrderRequiredFirst(t *testing.T) {
+	analysisResult := models.AnalysisResult{
+		Structs: []models.StructDef{{
+			Name:   "Account",
+			IsRoot: true,
+			Fields: []models.FieldInfo{
+				{JSONKey: "nickname", GoName: "Nickname", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"nickname,omitempty\"`"},
+				{JSONKey: "name", GoName: "Name", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"name\"`"},
+				{JSONKey: "avatar", GoName: "Avatar", GoType: models.TypeInfo{Kind: models.String, Name: "string", IsPointer: true}, JSONTag: "`json:\"avatar,omitempty\"`"},
+				{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.Int, Name: "int64"}, JSONTag: "`json:\"id\"`"},
+			},
+		}},
+	}
+
+	fieldOrder := func(code string) []string {
+		var names []string
+		for _, line := range strings.Split(code, "\n") {
+			if strings.HasPrefix(line, "\t") {
+				names = append(names, strings.Fields(line)[0])
+			}
+		}
+		return names
+	}
+
+	code, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Avatar", "Id", "Name", "Nickname"}, fieldOrder(code))
+
+	cfg := config.NewConfig()
+	cfg.Output.FieldOrder = config.FieldOrderRequiredFirst
+	code, err = NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Id", "Name", "Avatar", "Nickname"}, fieldOrder(code))
+}
+
+func TestGenerateFiles(t *testing.T) {
+	result := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Order", IsRoot: true, Fields: []models.FieldInfo{
+				{JSONKey: "status", GoName: "Status", GoType: models.TypeInfo{Kind: models.String, Name: "Status"}, JSONTag: "`json:\"status\"`"},
+				{JSONKey: "placed", GoName: "Placed", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, JSONTag: "`json:\"placed\"`"},
+			}},
+			{Name: "ItemLinux", Fields: []models.FieldInfo{
+				{JSONKey: "sku", GoName: "Sku", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"sku\"`"},
+			}},
+		},
+		NamedTypes: []models.NamedType{{Name: "Status", Type: models.TypeInfo{Kind: models.String, Name: "string"}}},
+		Constants:  []models.ConstantDef{{Name: "OrderKind", Value: `"order"`}},
+		Imports:    map[string]struct{}{"time": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.GenerateTypeRegistry = true
+	files, err := NewGeneratorWithConfig(cfg).GenerateFiles(result, "shop")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	for name, code := range files {
+		names = append(names, name)
+		_, err := format.Source([]byte(code))
+		require.NoError(t, err, code)
+		assert.True(t, strings.HasPrefix(code, "package shop\n"), name)
+	}
+	// ItemLinux isn't written to item_linux.go, which only builds on Linux
+	assert.ElementsMatch(t, []string{"order.go", "item_linux_type.go", "status.go", "shop.go"}, names)
+
+	assert.Contains(t, files["order.go"], "import (\n\t\"time\"\n)")
+	assert.NotContains(t, files["item_linux_type.go"], "import")
+	assert.Contains(t, files["status.go"], "type Status string")
+	assert.Contains(t, files["shop.go"], `const OrderKind = "order"`)
+	assert.Contains(t, files["shop.go"], "var AllTypes = []interface{}{")
+
+	// Types that would share a file are an error rather than one overwriting the other
+	result.NamedTypes = append(result.NamedTypes, models.NamedType{Name: "order", Type: models.TypeInfo{Kind: models.Int, Name: "int64"}})
+	_, err = NewGenerator().GenerateFiles(result, "shop")
+	assert.ErrorContains(t, err, "would both be written to order.go")
+}
+
+func TestGenerateFiles_SeparateMethods(t *testing.T) {
+	result := models.AnalysisResult{
+		Structs: []models.StructDef{
+			{Name: "Order", IsRoot: true, Fields: []models.FieldInfo{
+				{JSONKey: "id", GoName: "Id", GoType: models.TypeInfo{Kind: models.String, Name: "string"}, JSONTag: "`json:\"id\"`", Format: "uuid"},
+				{JSONKey: "status", GoName: "Status", GoType: models.TypeInfo{Kind: models.String, Name: "Status"}, JSONTag: "`json:\"status\"`"},
+				{JSONKey: "placed", GoName: "Placed", GoType: models.TypeInfo{Kind: models.Time, Name: "time.Time"}, JSONTag: "`json:\"placed\"`"},
+			}},
+		},
+		NamedTypes: []models.NamedType{{
+			Name: "Status",
+			Type: models.TypeInfo{Kind: models.String, Name: "string"},
+			Enum: []models.EnumValue{{Name: "StatusOpen", Label: "open", Value: `"open"`}, {Name: "StatusDone", Label: "done", Value: `"done"`}},
+		}},
+		Imports: map[string]struct{}{"time": {}},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Output.SeparateMethods = true
+	cfg.Output.GenerateEqual = true
+	cfg.Schema.RuntimeFormatValidation = true
+	files, err := NewGeneratorWithConfig(cfg).GenerateFiles(result, "main")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	types, methods := files["types.go"], files["methods.go"]
+	assert.Contains(t, types, "type Order struct {")
+	assert.Contains(t, types, "type Status string")
+	assert.Contains(t, types, "StatusOpen Status = \"open\"")
+	assert.Contains(t, types, "import (\n\t\"time\"\n)")
+	assert.NotContains(t, types, "func ")
+	assert.Contains(t, methods, "func (o Order) Equal(")
+	assert.Contains(t, methods, "func (o Order) Validate() error {")
+	assert.Contains(t, methods, "var uuidFormat = regexp.MustCompile(")
+	assert.NotContains(t, methods, "type ")
+	assert.NotContains(t, methods, "\"time\"")
+
+	output := runGeneratedFiles(t, files, `package main
+
+import "fmt"
+
+func main() {
+	order := Order{Id: "42", Status: StatusOpen}
+	fmt.Println(order.Equal(order), order.Validate())
+}
+`)
+	assert.Equal(t, "true id: \"42\" is not a valid uuid\n", output)
+}
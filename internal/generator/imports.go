@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// importAliases names the imports of mapped types whose package names collide, such
+// as github.com/a/util and github.com/b/util. Each gets the package name plus a number
+// (util1, util2) in path order. Imports that no field type records, like "time", keep
+// their own name. The result maps import paths to aliases.
+func importAliases(result models.AnalysisResult, imports map[string]struct{}) map[string]string {
+	mapped := make(map[string]bool)
+	var record func(typeInfo models.TypeInfo)
+	record = func(typeInfo models.TypeInfo) {
+		if typeInfo.Import != "" {
+			mapped[typeInfo.Import] = true
+		}
+		if typeInfo.SliceElementType != nil {
+			record(*typeInfo.SliceElementType)
+		}
+		if typeInfo.MapValueType != nil {
+			record(*typeInfo.MapValueType)
+		}
+	}
+	for _, structDef := range result.Structs {
+		for _, field := range structDef.Fields {
+			record(field.GoType)
+		}
+	}
+
+	byName := make(map[string][]string)
+	for path := range imports {
+		name := packageName(path)
+		byName[name] = append(byName[name], path)
+	}
+
+	aliases := make(map[string]string)
+	for name, paths := range byName {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		n := 0
+		for _, path := range paths {
+			if mapped[path] {
+				n++
+				aliases[path] = fmt.Sprintf("%s%d", name, n)
+			}
+		}
+	}
+	return aliases
+}
+
+// packageName guesses the name a package is referred to by from its import path: the
+// last element, skipping a major version suffix such as /v2
+func packageName(path string) string {
+	elements := strings.Split(path, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && majorVersionRegexp.MatchString(name) {
+		name = elements[len(elements)-2]
+	}
+	return name
+}
+
+var majorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// withImportAliases returns result with the type names of fields whose import is
+// aliased rewritten to use the alias, e.g. util.ID becoming util2.ID. The caller's
+// structs are left untouched.
+func withImportAliases(result models.AnalysisResult, aliases map[string]string) models.AnalysisResult {
+	if len(aliases) == 0 {
+		return result
+	}
+	structs := make([]models.StructDef, len(result.Structs))
+	for i, structDef := range result.Structs {
+		fields := make([]models.FieldInfo, len(structDef.Fields))
+		for j, field := range structDef.Fields {
+			field.GoType = aliasType(field.GoType, aliases)
+			fields[j] = field
+		}
+		structDef.Fields = fields
+		structs[i] = structDef
+	}
+	result.Structs = structs
+	return result
+}
+
+// aliasType rewrites the package qualifier in a type's name, and in those of its
+// element and value types, when its import is aliased
+func aliasType(typeInfo models.TypeInfo, aliases map[string]string) models.TypeInfo {
+	if alias, ok := aliases[typeInfo.Import]; ok {
+		qualifier := regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(packageName(typeInfo.Import)) + `\.`)
+		typeInfo.Name = qualifier.ReplaceAllString(typeInfo.Name, "${1}"+alias+".")
+	}
+	if typeInfo.SliceElementType != nil {
+		element := aliasType(*typeInfo.SliceElementType, aliases)
+		typeInfo.SliceElementType = &element
+	}
+	if typeInfo.MapValueType != nil {
+		value := aliasType(*typeInfo.MapValueType, aliases)
+		typeInfo.MapValueType = &value
+	}
+	return typeInfo
+}
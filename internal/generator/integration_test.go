@@ -1,12 +1,19 @@
 package generator
 
 import (
+	"encoding/json"
+	"go/format"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/mcncl/gotyper/internal/analyzer"
 	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/models"
 	"github.com/mcncl/gotyper/internal/parser"
+	"github.com/mcncl/gotyper/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -217,3 +224,527 @@ json_tags:
 	assert.Contains(t, generatedCode, `json:"view_count,omitempty,string"`, "view_count should have omitempty,string")
 	assert.Contains(t, generatedCode, `json:"-"`, "api_secret should be excluded")
 }
+
+func TestIntegration_OptionalityNotes(t *testing.T) {
+	jsonInput := `{"users": [{"id": 1, "name": "Ann", "email": "ann@example.com"}, {"id": 2, "name": "Bob"}]}`
+
+	ir, err := parser.ParseString(jsonInput)
+	require.NoError(t, err)
+	analysisResult, err := analyzer.NewAnalyzer().Analyze(ir, "Directory")
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.Output.OptionalityNotes = true
+	generatedCode, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	assert.Contains(t, generatedCode, "// Required (in every sample): id, name\n"+
+		"// Optional (missing from some samples): email\n"+
+		"type DirectoryUser struct {\n")
+
+	// Notes are off by default
+	generatedCode, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, generatedCode, "Optional (missing")
+}
+
+func TestIntegration_SourcePathComments(t *testing.T) {
+	jsonInput := `{"config": {"rate_limits": {"rps": 10}}, "orders": [{"items": [{"sku": "a"}]}]}`
+
+	ir, err := parser.ParseString(jsonInput)
+	require.NoError(t, err)
+	analysisResult, err := analyzer.NewAnalyzer().Analyze(ir, "Service")
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.Output.SourcePathComments = true
+	generatedCode, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	assert.Contains(t, generatedCode, "// Source: $\ntype Service struct {\n")
+	assert.Contains(t, generatedCode, "// Source: $.config.rate_limits\ntype ServiceConfigRateLimits struct {\n")
+	assert.Contains(t, generatedCode, "// Source: $.orders[*].items[*]\ntype ServiceOrderItem struct {\n")
+
+	// Comments are off by default
+	generatedCode, err = NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, generatedCode, "// Source:")
+}
+
+func TestIntegration_SchemaDeprecated(t *testing.T) {
+	schemaInput := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"nickname": {"type": "string", "deprecated": true, "description": "Use name instead."},
+			"legacy": {"$ref": "#/$defs/Legacy"}
+		},
+		"$defs": {
+			"Legacy": {"type": "object", "deprecated": true, "properties": {"id": {"type": "integer"}}}
+		}
+	}`
+
+	s, err := schema.ParseString(schemaInput)
+	require.NoError(t, err)
+	assert.True(t, s.Properties["nickname"].Deprecated)
+	assert.False(t, s.Properties["name"].Deprecated)
+
+	analysisResult, err := schema.NewConverter(s).Convert("Profile")
+	require.NoError(t, err)
+	generatedCode, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+
+	// The doc comment goes above the field so that tooling flags its use
+	assert.Contains(t, generatedCode, "\t// Deprecated: field is deprecated.\n\tNickname *string")
+	assert.Contains(t, generatedCode, "// Use name instead.\n")
+	assert.Contains(t, generatedCode, "// Deprecated: type is deprecated.\ntype Legacy struct {")
+	assert.Equal(t, 2, strings.Count(generatedCode, "Deprecated:"))
+}
+
+func TestIntegration_StructOrder(t *testing.T) {
+	s, err := schema.ParseString(`{
+		"type": "object",
+		"properties": {
+			"zone": {"$ref": "#/$defs/Zone"},
+			"book": {"$ref": "#/$defs/Book"}
+		},
+		"$defs": {
+			"Zone": {"type": "object", "properties": {"area": {"$ref": "#/$defs/Area"}}},
+			"Area": {"type": "object", "properties": {"code": {"type": "string"}}},
+			"Book": {"type": "object", "properties": {"title": {"type": "string"}}}
+		}
+	}`)
+	require.NoError(t, err)
+	result, err := schema.NewConverter(s).Convert("Shelf")
+	require.NoError(t, err)
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{config.StructOrderRootFirst, []string{"Shelf", "Area", "Book", "Zone"}},
+		{config.StructOrderDeclaration, []string{"Shelf", "Book", "Zone", "Area"}},
+		{config.StructOrderName, []string{"Area", "Book", "Shelf", "Zone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Output.StructOrder = tt.order
+			code, err := NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+			require.NoError(t, err)
+
+			var names []string
+			for _, line := range strings.Split(code, "\n") {
+				if name, ok := strings.CutPrefix(line, "type "); ok {
+					names = append(names, strings.Fields(name)[0])
+				}
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestIntegration_ConcatenatedJSON(t *testing.T) {
+	ir, err := parser.ParseStringWithOptions(`{"id": 1}{"id": 2, "ok": true}`, parser.Options{AllowTrailingData: true})
+	require.NoError(t, err)
+
+	analysisResult, err := analyzer.NewAnalyzer().Analyze(ir, "Event")
+	require.NoError(t, err)
+	require.Len(t, analysisResult.Structs, 1)
+
+	generatedCode, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, generatedCode, "type Event struct {\n"+
+		"\tId int64 `json:\"id\"`\n"+
+		"\tOk bool  `json:\"ok\"`\n"+
+		"}\n")
+}
+
+func TestIntegration_EscapedTagKeys(t *testing.T) {
+	ir, err := parser.ParseString(`{"say \"hi\"": 1, "C:\\temp": "x", "tick` + "`" + `s": true, "plain": 2}`)
+	require.NoError(t, err)
+
+	analysisResult, err := analyzer.NewAnalyzer().Analyze(ir, "Root")
+	require.NoError(t, err)
+	assert.Len(t, analysisResult.Warnings, 3)
+
+	generatedCode, err := NewGenerator().GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	_, err = format.Source([]byte(generatedCode))
+	require.NoError(t, err, generatedCode)
+
+	// Every tag, whichever literal it is written as, holds the original key
+	for _, field := range analysisResult.Structs[0].Fields {
+		literal, err := strconv.Unquote(field.JSONTag)
+		require.NoError(t, err, field.JSONTag)
+		assert.Equal(t, field.JSONKey, reflect.StructTag(literal).Get("json"))
+		assert.Contains(t, generatedCode, field.JSONTag)
+	}
+	assert.Contains(t, generatedCode, "`json:\"say \\\"hi\\\"\"`")
+	assert.Contains(t, generatedCode, "`json:\"C:\\\\temp\"`")
+	assert.Contains(t, generatedCode, "\"json:\\\"tick`s\\\"\"")
+}
+
+func TestIntegration_WrapRootArray(t *testing.T) {
+	ir, err := parser.ParseString(`[{"id": 1, "name": "a"}, {"id": 2}]`)
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.Output.WrapRootArrayAs = "items"
+	analysisResult, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Response")
+	require.NoError(t, err)
+	require.Len(t, analysisResult.Structs, 2)
+	assert.Equal(t, "Response", analysisResult.Structs[0].Name)
+	assert.True(t, analysisResult.Structs[0].IsRoot)
+	assert.False(t, analysisResult.Structs[1].IsRoot)
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(analysisResult, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "type Response struct {\n\tItems []*Item `json:\"items\"`\n}")
+	assert.Contains(t, code, "type Item struct {")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var resp Response
+	if err := json.Unmarshal([]byte(`+"`"+`[{"id": 1, "name": "a"}, {"id": 2}]`+"`"+`), &resp); err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(resp.Items), resp.Items[0].Name, string(data))
+}
+`)
+	assert.Equal(t, `2 a [{"id":1,"name":"a"},{"id":2,"name":""}]`+"\n", output)
+}
+
+func TestIntegration_JSONSchemaRequiredFrom(t *testing.T) {
+	// name is missing from the second element and score is null in the first
+	ir, err := parser.ParseString(`[{"id": 1, "name": "a", "score": null}, {"id": 2, "score": 1.5}]`)
+	require.NoError(t, err)
+	analysisResult, err := analyzer.NewAnalyzer().Analyze(ir, "Item")
+	require.NoError(t, err)
+
+	tests := []struct {
+		requiredFrom string
+		want         []string
+	}{
+		{config.RequiredFromNonPointer, []string{"id", "name"}},
+		{config.RequiredFromAlwaysPresent, []string{"id", "score"}},
+		{config.RequiredFromNone, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requiredFrom, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Output.SchemaRequiredFrom = tt.requiredFrom
+			document, err := NewGeneratorWithConfig(cfg).GenerateJSONSchema(analysisResult)
+			require.NoError(t, err)
+
+			assert.Equal(t, "array", document.Type)
+			require.NotNil(t, document.Items)
+			assert.Equal(t, "#/$defs/Item", document.Items.Ref)
+			require.Contains(t, document.Defs, "Item")
+			item := document.Defs["Item"]
+			assert.Len(t, item.Properties, 3)
+			assert.Equal(t, "number", item.Properties["score"].Type)
+			assert.Equal(t, tt.want, item.Required)
+		})
+	}
+}
+
+func TestIntegration_RootOptional(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.RootOptional = true
+
+	// A null sample only tells us the root may be null
+	ir, err := parser.ParseString(`null`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Account")
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings, "root_optional already handles the null root")
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "func ParseAccount(data []byte) (*Account, error) {")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	account, err := ParseAccount([]byte(" null\n"))
+	fmt.Println(account == nil, err)
+	account, err = ParseAccount([]byte(`+"`"+`{"value": 5}`+"`"+`))
+	fmt.Println(account != nil && *account.Value == 5.0, err)
+	_, err = ParseAccount([]byte("{"))
+	fmt.Println(err != nil)
+}
+`)
+	assert.Equal(t, "true <nil>\ntrue <nil>\ntrue\n", output)
+
+	// Without the option a null root is warned about and there is no helper
+	result, err = analyzer.NewAnalyzer().Analyze(ir, "Account")
+	require.NoError(t, err)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0].Message, "--root-optional")
+	code, err = NewGenerator().GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "ParseAccount")
+}
+
+func TestIntegration_NestedJSONFields(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.NestedJSONFields = []string{"^payload$"}
+
+	ir, err := parser.ParseString(`{"id": 1, "payload": "{\"event\":\"signup\",\"score\":2}"}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Message")
+	require.NoError(t, err)
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "Payload *MessagePayload `json:\"payload,omitempty\"`")
+	assert.Contains(t, code, "type MessagePayload struct {")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var message Message
+	err := json.Unmarshal([]byte(`+"`"+`{"id": 2, "payload": "{\"event\":\"login\",\"score\":7}"}`+"`"+`), &message)
+	fmt.Println(message.Payload.Event, message.Payload.Score, err)
+
+	// A payload that isn't encoded decodes too
+	err = json.Unmarshal([]byte(`+"`"+`{"id": 3, "payload": {"event": "logout", "score": 1}}`+"`"+`), &message)
+	fmt.Println(message.Payload.Event, err)
+
+	data, err := json.Marshal(message)
+	fmt.Println(string(data), err)
+
+	err = json.Unmarshal([]byte(`+"`"+`{"id": 4, "payload": "{"}`+"`"+`), &message)
+	fmt.Println(err != nil)
+}
+`)
+	assert.Equal(t, `login 7 <nil>
+logout <nil>
+{"id":3,"payload":"{\"event\":\"logout\",\"score\":1}"} <nil>
+true
+`, output)
+}
+
+func TestIntegration_NestedJSONFieldsNotDeduplicated(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.NestedJSONFields = []string{"payload"}
+
+	// x and y have the same shape, but only y's payload is encoded
+	sample := `{"x": {"payload": {"a": 1}}, "y": {"payload": "{\"a\":1}"}}`
+	ir, err := parser.ParseString(sample)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "RootType")
+	require.NoError(t, err)
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "Y *RootTypeY")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var root RootType
+	err := json.Unmarshal([]byte(`+"`"+sample+"`"+`), &root)
+	fmt.Println(root.X.Payload.A, root.Y.Payload.A, err)
+}
+`)
+	assert.Equal(t, "1 1 <nil>\n", output)
+}
+
+func TestIntegration_MergeFieldClash(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.GenerateMerge = true
+
+	ir, err := parser.ParseString(`{"merge": "fast-forward", "options": {"squash": true}}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Request")
+	require.NoError(t, err)
+
+	generatorInst := NewGeneratorWithConfig(cfg)
+	code, err := generatorInst.GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "func (r Request) Merge(")
+	assert.Equal(t, []models.Warning{{
+		Type:    models.WarningMethodClash,
+		Message: "Request has a field named Merge, so it gets no Merge method",
+	}}, generatorInst.Warnings())
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Request{Merge: "squash"}.Merge)
+}
+`)
+	assert.Equal(t, "squash\n", output)
+}
+
+func TestIntegration_EqualFieldClash(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.GenerateEqual = true
+
+	ir, err := parser.ParseString(`{"equal": true, "left": {"value": 1}}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Comparison")
+	require.NoError(t, err)
+
+	generatorInst := NewGeneratorWithConfig(cfg)
+	code, err := generatorInst.GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.NotContains(t, code, "func (c Comparison) Equal(")
+	assert.Contains(t, code, "func (c ComparisonLeft) Equal(", "structs without the field keep the method")
+	assert.Equal(t, []models.Warning{{
+		Type:    models.WarningMethodClash,
+		Message: "Comparison has a field named Equal, so it gets no Equal method",
+	}}, generatorInst.Warnings())
+
+	output := runGeneratedProgram(t, code, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Comparison{Equal: true}.Equal, ComparisonLeft{Value: 1}.Equal(ComparisonLeft{Value: 1}))
+}
+`)
+	assert.Equal(t, "true true\n", output)
+}
+
+func TestIntegration_InlineFieldsTypeScriptAndSchema(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.InlineFields = []string{"^meta$"}
+	cfg.Output.OptionalityNotes = true
+
+	ir, err := parser.ParseString(`[{"id": 1, "meta": {"version": 2, "source": "api"}}, {"id": 2, "meta": {"version": 3}}]`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Doc")
+	require.NoError(t, err)
+	generatorInst := NewGeneratorWithConfig(cfg)
+
+	code, err := generatorInst.GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// Required (in every sample): id, meta.version\n// Optional (missing from some samples): meta.source\n")
+
+	// The Go codec writes the promoted fields inside meta, so the declarations must too
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, err := json.Marshal(Doc{Id: 1, MetaSource: "api", MetaVersion: 2})
+	fmt.Println(string(data), err)
+}
+`)
+	assert.Equal(t, `{"id":1,"meta":{"source":"api","version":2}} <nil>`+"\n", output)
+
+	declarations, err := generatorInst.GenerateTypeScript(result)
+	require.NoError(t, err)
+	assert.Contains(t, declarations, "export interface Doc {\n  id: number;\n  meta: {\n    source: string;\n    version: number;\n  };\n}\n")
+
+	document, err := generatorInst.GenerateJSONSchema(result)
+	require.NoError(t, err)
+	doc := document.Defs["Doc"]
+	require.NotNil(t, doc)
+	assert.Equal(t, []string{"id", "meta"}, doc.Required)
+	require.Contains(t, doc.Properties, "meta")
+	meta := doc.Properties["meta"]
+	assert.Equal(t, "object", meta.Type)
+	assert.Equal(t, "integer", meta.Properties["version"].Type)
+	assert.Equal(t, "string", meta.Properties["source"].Type)
+	assert.NotContains(t, doc.Properties, "version")
+}
+
+func TestIntegration_NestedJSONFieldsTypeScriptAndSchema(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Types.NestedJSONFields = []string{"^payload$"}
+
+	ir, err := parser.ParseString(`{"id": 1, "payload": "{\"event\":\"signup\"}"}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Message")
+	require.NoError(t, err)
+	generatorInst := NewGeneratorWithConfig(cfg)
+
+	code, err := generatorInst.GenerateStructs(result, "main")
+	require.NoError(t, err)
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, _ := json.Marshal(Message{Id: 1, Payload: &MessagePayload{Event: "signup"}})
+	fmt.Print(string(data))
+}
+`)
+	var wire map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(output), &wire))
+	assert.IsType(t, "", wire["payload"], "the payload is written as an encoded string")
+
+	declarations, err := generatorInst.GenerateTypeScript(result)
+	require.NoError(t, err)
+	assert.Contains(t, declarations, "  payload?: string;\n")
+
+	document, err := generatorInst.GenerateJSONSchema(result)
+	require.NoError(t, err)
+	assert.Equal(t, &JSONSchema{Type: "string"}, document.Defs["Message"].Properties["payload"])
+}
+
+func TestIntegration_ConstructorsOnAnalyzerOutput(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Output.GenerateConstructors = true
+
+	ir, err := parser.ParseString(`{"id": 1, "items": [{"sku": "A-1"}], "tags": ["new"]}`)
+	require.NoError(t, err)
+	result, err := analyzer.NewAnalyzerWithConfig(cfg).Analyze(ir, "Cart")
+	require.NoError(t, err)
+
+	code, err := NewGeneratorWithConfig(cfg).GenerateStructs(result, "main")
+	require.NoError(t, err)
+	assert.Contains(t, code, "// NewCart returns a pointer to a new Cart value whose slices are empty rather than nil.\n")
+	assert.Contains(t, code, "Items: &[]*CartItem{},")
+
+	output := runGeneratedProgram(t, code, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	cart := NewCart()
+	var items []*CartItem = *cart.Items
+	fmt.Println(items != nil, len(items))
+	data, err := json.Marshal(cart)
+	fmt.Println(string(data), err)
+}
+`)
+	assert.Equal(t, "true 0\n"+`{"id":0,"items":[],"tags":[]} <nil>`+"\n", output)
+}
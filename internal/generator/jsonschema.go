@@ -0,0 +1,219 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12) document or subschema. Its fields are
+// in the order they are written; the empty value accepts any JSON.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // false or a *JSONSchema
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// GenerateJSONSchema describes the JSON the generated types accept as a JSON Schema.
+// Every struct and named type becomes an entry in $defs, and the document itself
+// describes the root: a reference to the root struct, or e.g. an array of them. A
+// result without a root, such as OpenAPI components, gives a document of only $defs.
+// Which properties are listed as required follows output.schema_required_from.
+func (g *Generator) GenerateJSONSchema(result models.AnalysisResult) (*JSONSchema, error) {
+	named := make(map[string]bool, len(result.NamedTypes))
+	for _, namedType := range result.NamedTypes {
+		named[namedType.Name] = true
+	}
+
+	defs := make(map[string]*JSONSchema, len(result.Structs)+len(result.NamedTypes))
+	for _, namedType := range result.NamedTypes {
+		def, err := namedTypeSchema(namedType, named)
+		if err != nil {
+			return nil, err
+		}
+		defs[namedType.Name] = def
+	}
+
+	document := &JSONSchema{}
+	for _, structDef := range g.sortStructs(result.Structs) {
+		if result.Root == nil && structDef.IsRoot && document.Ref == "" {
+			document.Ref = "#/$defs/" + structDef.Name
+		}
+		defs[structDef.Name] = g.structSchema(structDef, named)
+	}
+	if result.Root != nil {
+		// The root is never encoded as null, whether or not it is a pointer
+		document = typeSchema(*result.Root, named)
+	}
+
+	document.Schema = "https://json-schema.org/draft/2020-12/schema"
+	document.Defs = defs
+	return document, nil
+}
+
+// structSchema describes a struct as an object schema keyed by the fields' JSON names
+func (g *Generator) structSchema(structDef models.StructDef, named map[string]bool) *JSONSchema {
+	schema := &JSONSchema{
+		Type:        "object",
+		Description: structDef.Comment,
+		Deprecated:  structDef.Deprecated,
+		Properties:  make(map[string]*JSONSchema, len(structDef.Fields)),
+	}
+	if structDef.DisallowUnknownFields {
+		schema.AdditionalProperties = false
+	}
+
+	for _, field := range structDef.Fields {
+		if field.InlinedFrom == "" {
+			g.addProperty(schema, field, named)
+			continue
+		}
+		// Fields types.inline_fields promoted out of a nested object are still
+		// encoded inside it, which MarshalJSON always writes
+		object, ok := schema.Properties[field.InlinedFrom]
+		if !ok {
+			object = &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+			schema.Properties[field.InlinedFrom] = object
+			if g.requiredInSchema(models.FieldInfo{}, false) {
+				schema.Required = append(schema.Required, field.InlinedFrom)
+			}
+		}
+		field.JSONTag = tags.Build([]tags.Tag{{Key: "json", Value: field.InlinedTag}}, nil)
+		g.addProperty(object, field, named)
+		sort.Strings(object.Required)
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// addProperty adds a field to an object schema, unless it is left out of JSON
+func (g *Generator) addProperty(schema *JSONSchema, field models.FieldInfo, named map[string]bool) {
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return
+	}
+	options := strings.Split(tagValue, ",")
+	omitted := hasTagOption(options[1:], "omitempty") || hasTagOption(options[1:], "omitzero")
+
+	property := typeSchema(field.GoType, named)
+	if field.NestedJSON {
+		// MarshalJSON writes types.nested_json_fields values as encoded strings
+		property = &JSONSchema{Type: "string"}
+	}
+	if field.GoType.IsPointer && !omitted {
+		// A nil pointer without omitempty is written as null
+		property = &JSONSchema{AnyOf: []*JSONSchema{property, {Type: "null"}}}
+	}
+	property.Description = field.Comment
+	property.Deprecated = field.Deprecated
+	schema.Properties[options[0]] = property
+
+	if g.requiredInSchema(field, omitted) {
+		schema.Required = append(schema.Required, options[0])
+	}
+}
+
+// requiredInSchema reports whether a field belongs in its object's required list
+func (g *Generator) requiredInSchema(field models.FieldInfo, omitted bool) bool {
+	switch g.config.Output.SchemaRequiredFrom {
+	case config.RequiredFromNone:
+		return false
+	case config.RequiredFromAlwaysPresent:
+		return !field.Optional
+	default:
+		return !field.GoType.IsPointer && !omitted
+	}
+}
+
+// typeSchema maps a Go type to the schema of its JSON encoding
+func typeSchema(typeInfo models.TypeInfo, named map[string]bool) *JSONSchema {
+	if named[typeInfo.Name] {
+		return &JSONSchema{Ref: "#/$defs/" + typeInfo.Name}
+	}
+
+	switch typeInfo.Kind {
+	case models.Struct:
+		return &JSONSchema{Ref: "#/$defs/" + typeInfo.StructName}
+	case models.Slice:
+		items := &JSONSchema{}
+		if typeInfo.SliceElementType != nil {
+			items = typeSchema(*typeInfo.SliceElementType, named)
+		}
+		schema := &JSONSchema{Type: "array", Items: items}
+		if typeInfo.ArrayLength > 0 {
+			// A Go array always holds exactly its length of elements
+			schema.MinItems, schema.MaxItems = &typeInfo.ArrayLength, &typeInfo.ArrayLength
+		}
+		return schema
+	case models.Map:
+		values := &JSONSchema{}
+		if typeInfo.MapValueType != nil {
+			values = typeSchema(*typeInfo.MapValueType, named)
+		}
+		return &JSONSchema{Type: "object", AdditionalProperties: values}
+	case models.String:
+		return &JSONSchema{Type: "string"}
+	case models.Time:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case models.UUID:
+		return &JSONSchema{Type: "string", Format: "uuid"}
+	case models.Int:
+		return &JSONSchema{Type: "integer"}
+	case models.Float, models.Duration:
+		return &JSONSchema{Type: "number"}
+	case models.Bool:
+		return &JSONSchema{Type: "boolean"}
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// namedTypeSchema describes a named type by its underlying type, restricted to its
+// members' values when it is an enum
+func namedTypeSchema(namedType models.NamedType, named map[string]bool) (*JSONSchema, error) {
+	// The type itself is in named, so look through it to the underlying type
+	underlying := make(map[string]bool, len(named))
+	for name := range named {
+		if name != namedType.Name {
+			underlying[name] = true
+		}
+	}
+	schema := typeSchema(namedType.Type, underlying)
+	schema.Description = namedType.Comment
+	if namedType.Union != nil {
+		for _, variant := range namedType.Union.Variants {
+			schema.AnyOf = append(schema.AnyOf, &JSONSchema{Ref: "#/$defs/" + variant.Struct})
+		}
+	}
+
+	for _, member := range namedType.Enum {
+		var value interface{} = json.Number(member.Value)
+		if strings.HasPrefix(member.Value, `"`) {
+			unquoted, err := strconv.Unquote(member.Value)
+			if err != nil {
+				return nil, fmt.Errorf("enum %s has an invalid value %s: %w", namedType.Name, member.Value, err)
+			}
+			value = unquoted
+		}
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema, nil
+}
@@ -0,0 +1,366 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// generatedMethod is a method emitted alongside a struct, with the imports it needs
+type generatedMethod struct {
+	name    string
+	code    string
+	imports []string
+}
+
+// structMethods returns the methods to generate for a struct based on configuration
+func (g *Generator) structMethods(structDef models.StructDef) []generatedMethod {
+	var methods []generatedMethod
+	if structDef.WrapsArray && len(structDef.Fields) == 1 {
+		methods = arrayWrapperMethods(structDef)
+	} else {
+		methods = g.newStructCodec(structDef).methods()
+	}
+	if g.config.Output.GenerateMerge {
+		if method, ok := mergeMethod(structDef); ok {
+			if field, clash := fieldNamed(structDef, "Merge"); clash {
+				g.warnings = append(g.warnings, models.Warning{
+					Type:    models.WarningMethodClash,
+					Message: fmt.Sprintf("%s has a field named %s, so it gets no Merge method", structDef.Name, field),
+				})
+			} else {
+				methods = append(methods, method)
+			}
+		}
+	}
+	if g.config.Output.GenerateEqual {
+		if field, clash := fieldNamed(structDef, "Equal"); clash {
+			g.warnings = append(g.warnings, models.Warning{
+				Type:    models.WarningMethodClash,
+				Message: fmt.Sprintf("%s has a field named %s, so it gets no Equal method", structDef.Name, field),
+			})
+		} else {
+			methods = append(methods, equalMethod(structDef))
+		}
+	}
+	if g.config.Output.GenerateSQLJSON && !structDef.IsRoot && !structDef.WrapsArray {
+		if field, clash := fieldNamed(structDef, "Scan", "Value"); clash {
+			g.warnings = append(g.warnings, models.Warning{
+				Type:    models.WarningMethodClash,
+				Message: fmt.Sprintf("%s has a field named %s, so it gets no Scan and Value methods", structDef.Name, field),
+			})
+		} else {
+			methods = append(methods, sqlJSONMethods(structDef)...)
+		}
+	}
+
+	if g.config.Schema.RuntimeFormatValidation {
+		if method, ok := validateMethod(structDef); ok {
+			if field, clash := fieldNamed(structDef, "Validate"); clash {
+				g.warnings = append(g.warnings, models.Warning{
+					Type:    models.WarningMethodClash,
+					Message: fmt.Sprintf("%s has a field named %s, so it gets no Validate method", structDef.Name, field),
+				})
+			} else {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	if g.config.Output.RootOptional && structDef.IsRoot {
+		methods = append(methods, parseFunc(structDef))
+	}
+	if g.config.Output.GenerateConstructors {
+		methods = append(methods, constructorFunc(structDef))
+	}
+
+	// Stubs come last and never replace a method generated above
+	for _, method := range structDef.Methods {
+		if hasMethod(methods, method.Name) {
+			continue
+		}
+		if field, clash := fieldNamed(structDef, method.Name); clash {
+			g.warnings = append(g.warnings, models.Warning{
+				Type:    models.WarningMethodClash,
+				Message: fmt.Sprintf("%s has a field named %s, so it gets no %s stub and doesn't implement the interface", structDef.Name, field, method.Name),
+			})
+			continue
+		}
+		methods = append(methods, stubMethod(structDef, method))
+	}
+
+	return methods
+}
+
+// parseFunc generates the output.root_optional helper ParseT, which decodes a root T
+// and returns nil rather than a zero T when the JSON is a literal null
+func parseFunc(structDef models.StructDef) generatedMethod {
+	name := structDef.Name
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Parse%s decodes data into a %s. JSON null gives a nil %s and no error,\n", name, name, name)
+	b.WriteString("// telling an absent value apart from an empty one.\n")
+	fmt.Fprintf(&b, "func Parse%s(data []byte) (*%s, error) {\n", name, name)
+	b.WriteString("\tif bytes.Equal(bytes.TrimSpace(data), []byte(\"null\")) {\n\t\treturn nil, nil\n\t}\n")
+	fmt.Fprintf(&b, "\tvar value %s\n", name)
+	b.WriteString("\tif err := json.Unmarshal(data, &value); err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\treturn &value, nil\n")
+	b.WriteString("}\n")
+	return generatedMethod{name: "Parse" + name, code: b.String(), imports: []string{"bytes", "encoding/json"}}
+}
+
+// constructorFunc generates the output.generate_constructors function NewT. Slice
+// fields start as empty slices of their element type rather than nil, so a T built
+// with it encodes them as [] instead of null or a missing key: make([]T, 0) for a
+// slice and &[]T{} for a pointer to one. Fixed-size arrays need no initializing.
+func constructorFunc(structDef models.StructDef) generatedMethod {
+	name := structDef.Name
+	var inits []string
+	for _, field := range structDef.Fields {
+		typeInfo := field.GoType
+		if typeInfo.Kind != models.Slice || typeInfo.ArrayLength > 0 {
+			continue
+		}
+		if typeInfo.IsPointer {
+			typeInfo.IsPointer = false
+			inits = append(inits, fmt.Sprintf("%s: &%s{}", field.GoName, TypeString(typeInfo)))
+		} else {
+			inits = append(inits, fmt.Sprintf("%s: make(%s, 0)", field.GoName, TypeString(typeInfo)))
+		}
+	}
+
+	var b strings.Builder
+	if len(inits) == 0 {
+		fmt.Fprintf(&b, "// New%s returns a pointer to a new, zero-valued %s.\n", name, name)
+	} else {
+		fmt.Fprintf(&b, "// New%s returns a pointer to a new %s value whose slices are empty rather than nil.\n", name, name)
+	}
+	fmt.Fprintf(&b, "func New%s() *%s {\n", name, name)
+	if len(inits) == 0 {
+		fmt.Fprintf(&b, "\treturn &%s{}\n", name)
+	} else {
+		fmt.Fprintf(&b, "\treturn &%s{\n", name)
+		for _, init := range inits {
+			fmt.Fprintf(&b, "\t\t%s,\n", init)
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return generatedMethod{name: "New" + name, code: b.String()}
+}
+
+// hasMethod reports whether a method with the given name has already been generated
+func hasMethod(methods []generatedMethod, name string) bool {
+	for _, method := range methods {
+		if method.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMethod generates a Merge method that overlays the non-nil pointer fields of
+// another value, for PATCH-style structs. Structs without pointer fields get none.
+func mergeMethod(structDef models.StructDef) (generatedMethod, bool) {
+	var fields []string
+	for _, field := range structDef.Fields {
+		if field.GoType.IsPointer {
+			fields = append(fields, field.GoName)
+		}
+	}
+	if len(fields) == 0 {
+		return generatedMethod{}, false
+	}
+	sort.Strings(fields)
+
+	recv := receiverName(structDef.Name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Merge returns a copy of %s with each non-nil pointer field of other overlaid on it.\n", recv)
+	fmt.Fprintf(&b, "func (%s %s) Merge(other %s) %s {\n", recv, structDef.Name, structDef.Name, structDef.Name)
+	for _, name := range fields {
+		fmt.Fprintf(&b, "\tif other.%s != nil {\n", name)
+		fmt.Fprintf(&b, "\t\t%s.%s = other.%s\n", recv, name, name)
+		b.WriteString("\t}\n")
+	}
+	fmt.Fprintf(&b, "\treturn %s\n", recv)
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "Merge", code: b.String()}, true
+}
+
+// equalMethod generates an Equal method comparing two values field by field. Pointers
+// are compared by what they point to, times with time.Time.Equal and generated structs
+// with their own Equal method, which output.generate_equal gives every struct. Other
+// types, such as slices, maps and mapped types that may not be comparable, fall back
+// to reflect.DeepEqual.
+func equalMethod(structDef models.StructDef) generatedMethod {
+	fields := make([]models.FieldInfo, len(structDef.Fields))
+	copy(fields, structDef.Fields)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].GoName < fields[j].GoName })
+
+	name := structDef.Name
+	recv := receiverName(name)
+	var imports []string
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Equal reports whether %s and other hold the same values, comparing through pointers.\n", recv)
+	fmt.Fprintf(&b, "func (%s %s) Equal(other %s) bool {\n", recv, name, name)
+	for _, field := range fields {
+		mine, theirs := recv+"."+field.GoName, "other."+field.GoName
+		var differ string
+		switch kind := equalKind(field.GoType); {
+		case kind == "" || !field.GoType.IsPointer:
+			differ = valuesDiffer(kind, mine, theirs)
+		case kind == "Equal":
+			// Methods with value receivers can be called through the pointer
+			differ = fmt.Sprintf("(%s == nil) != (%s == nil) || %s != nil && %s", mine, theirs, mine, valuesDiffer(kind, mine, "*"+theirs))
+		default:
+			differ = fmt.Sprintf("(%s == nil) != (%s == nil) || %s != nil && %s", mine, theirs, mine, valuesDiffer(kind, "*"+mine, "*"+theirs))
+		}
+		if strings.HasPrefix(differ, "!reflect.") && len(imports) == 0 {
+			imports = append(imports, "reflect")
+		}
+
+		fmt.Fprintf(&b, "\tif %s {\n", differ)
+		b.WriteString("\t\treturn false\n")
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn true\n")
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "Equal", code: b.String(), imports: imports}
+}
+
+// equalKind returns how equalMethod compares values of a type: "==" for built-in
+// scalars and durations, "Equal" for times and generated structs, and "" for
+// reflect.DeepEqual
+func equalKind(typeInfo models.TypeInfo) string {
+	switch typeInfo.Kind {
+	case models.Struct, models.Time:
+		return "Equal"
+	case models.Duration:
+		return "=="
+	case models.String, models.Int, models.Float, models.Bool:
+		switch typeInfo.Name {
+		case "string", "bool", "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+			return "=="
+		}
+	}
+	return ""
+}
+
+// valuesDiffer returns an expression that is true when a and b differ, compared as
+// equalKind says
+func valuesDiffer(kind, a, b string) string {
+	switch kind {
+	case "==":
+		return a + " != " + b
+	case "Equal":
+		return "!" + a + ".Equal(" + b + ")"
+	default:
+		return "!reflect.DeepEqual(" + a + ", " + b + ")"
+	}
+}
+
+// sqlJSONMethods generates Scan and Value methods that store a struct in a database
+// column as JSON, implementing sql.Scanner and driver.Valuer. Scan accepts the []byte
+// or string a driver returns for JSON columns, and resets the value for NULL.
+func sqlJSONMethods(structDef models.StructDef) []generatedMethod {
+	name := structDef.Name
+	recv := receiverName(name)
+
+	var scan strings.Builder
+	fmt.Fprintf(&scan, "// Scan decodes a JSON database column into %s, implementing sql.Scanner.\n", recv)
+	fmt.Fprintf(&scan, "func (%s *%s) Scan(src interface{}) error {\n", recv, name)
+	scan.WriteString("\tswitch value := src.(type) {\n")
+	scan.WriteString("\tcase []byte:\n")
+	fmt.Fprintf(&scan, "\t\treturn json.Unmarshal(value, %s)\n", recv)
+	scan.WriteString("\tcase string:\n")
+	fmt.Fprintf(&scan, "\t\treturn json.Unmarshal([]byte(value), %s)\n", recv)
+	scan.WriteString("\tcase nil:\n")
+	fmt.Fprintf(&scan, "\t\t*%s = %s{}\n", recv, name)
+	scan.WriteString("\t\treturn nil\n")
+	scan.WriteString("\t}\n")
+	fmt.Fprintf(&scan, "\treturn fmt.Errorf(\"cannot scan %%T into %s\", src)\n", name)
+	scan.WriteString("}\n")
+
+	var value strings.Builder
+	fmt.Fprintf(&value, "// Value encodes %s as JSON for a database column, implementing driver.Valuer.\n", recv)
+	fmt.Fprintf(&value, "func (%s %s) Value() (driver.Value, error) {\n", recv, name)
+	fmt.Fprintf(&value, "\treturn json.Marshal(%s)\n", recv)
+	value.WriteString("}\n")
+
+	return []generatedMethod{
+		{name: "Scan", code: scan.String(), imports: []string{"encoding/json", "fmt"}},
+		{name: "Value", code: value.String(), imports: []string{"database/sql/driver", "encoding/json"}},
+	}
+}
+
+// fieldNamed returns the first of a struct's fields with one of the given Go names
+func fieldNamed(structDef models.StructDef, names ...string) (string, bool) {
+	for _, field := range structDef.Fields {
+		for _, name := range names {
+			if field.GoName == name {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// arrayWrapperMethods generates UnmarshalJSON and MarshalJSON methods that decode and
+// encode a struct wrapping a JSON array root as the array held by its single field
+func arrayWrapperMethods(structDef models.StructDef) []generatedMethod {
+	name := structDef.Name
+	field := structDef.Fields[0].GoName
+	recv := receiverName(name)
+	imports := []string{"encoding/json"}
+
+	var unmarshal strings.Builder
+	fmt.Fprintf(&unmarshal, "// UnmarshalJSON decodes a JSON array into %s.%s.\n", recv, field)
+	fmt.Fprintf(&unmarshal, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, name)
+	fmt.Fprintf(&unmarshal, "\treturn json.Unmarshal(data, &%s.%s)\n", recv, field)
+	unmarshal.WriteString("}\n")
+
+	var marshal strings.Builder
+	fmt.Fprintf(&marshal, "// MarshalJSON encodes %s.%s as a JSON array.\n", recv, field)
+	fmt.Fprintf(&marshal, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, name)
+	fmt.Fprintf(&marshal, "\treturn json.Marshal(%s.%s)\n", recv, field)
+	marshal.WriteString("}\n")
+
+	return []generatedMethod{
+		{name: "UnmarshalJSON", code: unmarshal.String(), imports: imports},
+		{name: "MarshalJSON", code: marshal.String(), imports: imports},
+	}
+}
+
+// stubMethod generates a method with the given signature whose body panics.
+// The receiver is unnamed so it can't collide with parameter names.
+func stubMethod(structDef models.StructDef, method models.MethodDef) generatedMethod {
+	signature := fmt.Sprintf("%s(%s)", method.Name, method.Params)
+	if method.Results != "" {
+		signature += " " + method.Results
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is a generated stub; replace the panic with a real implementation.\n", method.Name)
+	fmt.Fprintf(&b, "func (*%s) %s {\n", structDef.Name, signature)
+	b.WriteString("\tpanic(\"not implemented\")\n")
+	b.WriteString("}\n")
+
+	return generatedMethod{
+		name:    method.Name,
+		code:    b.String(),
+		imports: method.Imports,
+	}
+}
+
+// receiverName derives a short method receiver name from a type name
+func receiverName(typeName string) string {
+	for _, r := range typeName {
+		return string(unicode.ToLower(r))
+	}
+	return "t"
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// Report is a canonical summary of the shape of the generated types, for committing
+// and diffing in CI to notice when an API changes. Everything is sorted and only the
+// names, types and optionality that affect decoding are kept, so the same input
+// always gives the same report whatever the formatting and comment options.
+type Report struct {
+	Structs    []ReportStruct    `json:"structs"`
+	NamedTypes []ReportNamedType `json:"named_types,omitempty"`
+}
+
+// ReportStruct is a struct of the report, with its fields sorted by JSON key
+type ReportStruct struct {
+	Name   string        `json:"name"`
+	Fields []ReportField `json:"fields"`
+}
+
+// ReportField is an encoded field. Type is its Go type without the pointer; Optional
+// records the pointer, omitempty or omitzero, and keys missing from some samples.
+type ReportField struct {
+	Name     string `json:"name"` // JSON key
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// ReportNamedType is a named type with its underlying type and sorted enum values
+type ReportNamedType struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Values []string `json:"values,omitempty"`
+}
+
+// GenerateReport summarizes result as a Report. Fields that aren't encoded, such as
+// those tagged json:"-", are left out.
+func (g *Generator) GenerateReport(result models.AnalysisResult) Report {
+	report := Report{Structs: make([]ReportStruct, 0, len(result.Structs))}
+	for _, structDef := range result.Structs {
+		reportStruct := ReportStruct{Name: structDef.Name, Fields: []ReportField{}}
+		for _, field := range structDef.Fields {
+			tagValue, ok := fieldJSONTag(field)
+			if !ok {
+				continue
+			}
+			options := strings.Split(tagValue, ",")
+			goType := field.GoType
+			optional := field.Optional || goType.IsPointer || hasTagOption(options[1:], "omitempty") || hasTagOption(options[1:], "omitzero")
+			goType.IsPointer = false
+			reportStruct.Fields = append(reportStruct.Fields, ReportField{
+				Name:     options[0],
+				Type:     TypeString(goType),
+				Optional: optional,
+			})
+		}
+		sort.Slice(reportStruct.Fields, func(i, j int) bool {
+			return reportStruct.Fields[i].Name < reportStruct.Fields[j].Name
+		})
+		report.Structs = append(report.Structs, reportStruct)
+	}
+	sort.Slice(report.Structs, func(i, j int) bool {
+		return report.Structs[i].Name < report.Structs[j].Name
+	})
+
+	for _, namedType := range result.NamedTypes {
+		reportType := ReportNamedType{Name: namedType.Name, Type: TypeString(namedType.Type)}
+		if namedType.Union != nil {
+			reportType.Type = "interface"
+			for _, variant := range namedType.Union.Variants {
+				reportType.Values = append(reportType.Values, variant.Value)
+			}
+		}
+		for _, value := range namedType.Enum {
+			reportType.Values = append(reportType.Values, value.Value)
+		}
+		sort.Strings(reportType.Values)
+		report.NamedTypes = append(report.NamedTypes, reportType)
+	}
+	sort.Slice(report.NamedTypes, func(i, j int) bool {
+		return report.NamedTypes[i].Name < report.NamedTypes[j].Name
+	})
+	return report
+}
@@ -0,0 +1,235 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// GenerateFiles creates Go code from analysis results as separate files of one package,
+// keyed by file name. Each struct and named type gets a file named after it in
+// snake_case, e.g. order_item.go, holding its methods too. Constants, the type registry,
+// the go:generate directive and the ambiguous date note share a file named after the
+// package. Every file imports only the packages it uses. With output.separate_methods
+// there are just two files instead: types.go with every declaration and methods.go with
+// every method.
+func (g *Generator) GenerateFiles(result models.AnalysisResult, packageName string) (map[string]string, error) {
+	g.checkTypes(result)
+	result = g.sortDeclarations(result)
+	methodsByStruct, requiredImports := g.collectMethods(result)
+	aliases := importAliases(result, requiredImports)
+	result = withImportAliases(result, aliases)
+	if g.config.Output.SeparateMethods {
+		return g.typesAndMethodsFiles(result, packageName, methodsByStruct, requiredImports, aliases), nil
+	}
+
+	files := make(map[string]string)
+	owners := make(map[string]string)
+	add := func(owner, fileName string, body *bytes.Buffer, header string) error {
+		if other, taken := owners[fileName]; taken {
+			return fmt.Errorf("%s and %s would both be written to %s", other, owner, fileName)
+		}
+		owners[fileName] = owner
+		files[fileName] = fileSource(packageName, header, body.String(), requiredImports, aliases)
+		return nil
+	}
+
+	for _, namedType := range result.NamedTypes {
+		var body bytes.Buffer
+		writeNamedType(&body, namedType, namedTypeMethods(namedType))
+		if err := add(namedType.Name, typeFileName(namedType.Name), &body, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	sortedStructs := g.sortStructs(result.Structs)
+	for _, structDef := range sortedStructs {
+		var body bytes.Buffer
+		g.writeStruct(&body, structDef, methodsByStruct[structDef.Name])
+		if err := add(structDef.Name, typeFileName(structDef.Name), &body, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var shared bytes.Buffer
+	if result.UsedDefaultDateFormat {
+		shared.WriteString("// Note: Ambiguous date fields detected using US format (MM/DD/YYYY).\n")
+		shared.WriteString("// To use European format (DD/MM/YYYY), set date_format: \"eu\" in .gotyper.yml\n")
+	}
+	for _, constant := range result.Constants {
+		if shared.Len() > 0 {
+			shared.WriteString("\n")
+		}
+		writeConstant(&shared, constant)
+	}
+	if usesUUIDFormat(methodsByStruct) {
+		if shared.Len() > 0 {
+			shared.WriteString("\n")
+		}
+		shared.WriteString(uuidFormatVar)
+	}
+	if g.config.Output.GenerateTypeRegistry && len(sortedStructs) > 0 {
+		if shared.Len() > 0 {
+			shared.WriteString("\n")
+		}
+		shared.WriteString(typeRegistry(sortedStructs))
+	}
+	if example := g.usageExample(result, packageName); example != "" {
+		if shared.Len() > 0 {
+			shared.WriteString("\n")
+		}
+		shared.WriteString(example)
+	}
+	var directive string
+	if len(g.goGenerateArgs) > 0 {
+		directive = goGenerateDirective(g.goGenerateArgs)
+	}
+	if shared.Len() > 0 || directive != "" {
+		if err := add("package "+packageName, typeFileName(packageName), &shared, directive); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// typesAndMethodsFiles splits the generated code into types.go, holding the types,
+// constants and other declarations, and methods.go, holding the methods of structs and
+// named types. methods.go is left out when nothing has methods.
+func (g *Generator) typesAndMethodsFiles(result models.AnalysisResult, packageName string, methodsByStruct map[string][]generatedMethod, requiredImports map[string]struct{}, aliases map[string]string) map[string]string {
+	var types, methods bytes.Buffer
+	separate := func(buf *bytes.Buffer) {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+	}
+	writeMethods := func(generated []generatedMethod) {
+		for _, method := range generated {
+			separate(&methods)
+			methods.WriteString(method.code)
+		}
+	}
+
+	if result.UsedDefaultDateFormat {
+		types.WriteString("// Note: Ambiguous date fields detected using US format (MM/DD/YYYY).\n")
+		types.WriteString("// To use European format (DD/MM/YYYY), set date_format: \"eu\" in .gotyper.yml\n")
+	}
+	if usesUUIDFormat(methodsByStruct) {
+		methods.WriteString(uuidFormatVar)
+	}
+	for _, namedType := range result.NamedTypes {
+		separate(&types)
+		writeNamedType(&types, namedType, nil)
+		writeMethods(namedTypeMethods(namedType))
+	}
+	for _, constant := range result.Constants {
+		separate(&types)
+		writeConstant(&types, constant)
+	}
+	sortedStructs := g.sortStructs(result.Structs)
+	for _, structDef := range sortedStructs {
+		separate(&types)
+		g.writeStruct(&types, structDef, nil)
+		writeMethods(methodsByStruct[structDef.Name])
+	}
+	if g.config.Output.GenerateTypeRegistry && len(sortedStructs) > 0 {
+		separate(&types)
+		types.WriteString(typeRegistry(sortedStructs))
+	}
+	if example := g.usageExample(result, packageName); example != "" {
+		separate(&types)
+		types.WriteString(example)
+	}
+
+	var directive string
+	if len(g.goGenerateArgs) > 0 {
+		directive = goGenerateDirective(g.goGenerateArgs)
+	}
+	files := map[string]string{"types.go": fileSource(packageName, directive, types.String(), requiredImports, aliases)}
+	if methods.Len() > 0 {
+		files["methods.go"] = fileSource(packageName, "", methods.String(), requiredImports, aliases)
+	}
+	return files
+}
+
+// fileSource assembles a file from a package clause, an optional header line such as a
+// go:generate directive, the imports among available that body refers to, and body
+func fileSource(packageName, header, body string, available map[string]struct{}, aliases map[string]string) string {
+	used := usedImports(body, available, aliases)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("package %s\n", packageName))
+	if header != "" {
+		buf.WriteString("\n" + header + "\n")
+	}
+	writeImports(&buf, used, aliases)
+	if body != "" {
+		buf.WriteString("\n" + body)
+	}
+	return buf.String()
+}
+
+// usedImports returns the imports among available whose package names body uses as
+// qualifiers, such as time in time.Time. Should body not parse, all of them are kept
+// so the file's own syntax error is what gets reported.
+func usedImports(body string, available map[string]struct{}, aliases map[string]string) map[string]struct{} {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+body, parser.SkipObjectResolution)
+	if err != nil {
+		return available
+	}
+	qualifiers := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				qualifiers[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	used := make(map[string]struct{})
+	for path := range available {
+		name, ok := aliases[path]
+		if !ok {
+			name = packageName(path)
+		}
+		if qualifiers[name] {
+			used[path] = struct{}{}
+		}
+	}
+	return used
+}
+
+// typeFileName returns the file a type is written to by GenerateFiles: its name in
+// snake_case. A last word the go tool would read as a build constraint or a test file,
+// as in UserLinux or LoadTest, gets a _type suffix so the file is always compiled.
+func typeFileName(name string) string {
+	base := strcase.ToSnake(name)
+	if base == "" {
+		base = "types"
+	}
+	words := strings.Split(base, "_")
+	if last := words[len(words)-1]; len(words) > 1 && (last == "test" || buildConstraintWords[last]) {
+		base += "_type"
+	}
+	return base + ".go"
+}
+
+// buildConstraintWords are the GOOS and GOARCH values, which as the last _-separated
+// word of a file name restrict the file to that platform
+var buildConstraintWords = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+	"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+	"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
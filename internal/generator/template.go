@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/mcncl/gotyper/internal/inflect"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// TemplateData is the value a user template is executed with: .Package plus the
+// fields of the analysis result. Structs are in output.struct_order, named types and
+// constants sorted by name unless it is declaration, and ranging over .Imports visits
+// the import paths in sorted order.
+type TemplateData struct {
+	Package string
+	models.AnalysisResult
+}
+
+// templateFuncs returns the helpers available to user templates. singular honours
+// naming.custom_singulars.
+func (g *Generator) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"goType":     TypeString,
+		"typeString": TypeString,
+		"jsonTag":    templateJSONTag,
+		"isPointer":  func(typeInfo models.TypeInfo) bool { return typeInfo.IsPointer },
+		"pascal":     strcase.ToCamel,
+		"snake":      strcase.ToSnake,
+		"singular":   func(word string) string { return inflect.Singularize(word, g.config.Naming.CustomSingulars) },
+		"plural":     inflect.Pluralize,
+	}
+}
+
+// templateJSONTag returns the value of a field's json tag, e.g. "name,omitempty"
+func templateJSONTag(field models.FieldInfo) string {
+	value, _ := tags.Lookup(field.JSONTag, "json")
+	return value
+}
+
+// GenerateFromTemplate renders the analysis result with a text/template instead of
+// the built-in generator, e.g. to emit ORM models or documentation. The output is
+// not formatted, since it need not be Go.
+func (g *Generator) GenerateFromTemplate(result models.AnalysisResult, packageName, text string) (string, error) {
+	tmpl, err := template.New("output").Funcs(g.templateFuncs()).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	result = g.sortDeclarations(result)
+	result.Structs = g.sortStructs(result.Structs)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, TemplateData{Package: packageName, AnalysisResult: result}); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
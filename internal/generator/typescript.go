@@ -0,0 +1,202 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// GenerateTypeScript creates TypeScript declarations (.d.ts) describing the same JSON
+// as the Go structs. Struct, field and type comments are written as JSDoc.
+func (g *Generator) GenerateTypeScript(result models.AnalysisResult) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gotyper. DO NOT EDIT.\n")
+	result = g.sortDeclarations(result)
+
+	named := make(tsNamedTypes, len(result.NamedTypes))
+	for _, namedType := range result.NamedTypes {
+		named[namedType.Name] = true
+	}
+
+	for _, namedType := range result.NamedTypes {
+		buf.WriteString("\n")
+		writeJSDoc(&buf, "", namedType.Comment)
+		buf.WriteString(fmt.Sprintf("export type %s = %s;\n", namedType.Name, named.declaration(namedType)))
+	}
+
+	for _, constant := range result.Constants {
+		buf.WriteString("\n")
+		writeJSDoc(&buf, "", constant.Comment)
+		buf.WriteString(fmt.Sprintf("export declare const %s: %s;\n", constant.Name, constant.Value))
+	}
+
+	for _, structDef := range g.sortStructs(result.Structs) {
+		buf.WriteString("\n")
+		writeJSDoc(&buf, "", withDeprecatedTag(structDef.Comment, structDef.Deprecated))
+		buf.WriteString(fmt.Sprintf("export interface %s {\n", structDef.Name))
+		fields := make([]models.FieldInfo, len(structDef.Fields))
+		copy(fields, structDef.Fields)
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].GoName < fields[j].GoName
+		})
+		written := make(map[string]bool)
+		for _, field := range fields {
+			if field.InlinedFrom == "" {
+				writeTSProperty(&buf, "  ", field, named)
+				continue
+			}
+			// Fields types.inline_fields promoted out of a nested object are still
+			// encoded inside it, so they are declared there
+			if written[field.InlinedFrom] {
+				continue
+			}
+			written[field.InlinedFrom] = true
+			buf.WriteString(fmt.Sprintf("  %s: {\n", tsPropertyName(field.InlinedFrom)))
+			for _, inner := range fields {
+				if inner.InlinedFrom == field.InlinedFrom {
+					inner.JSONTag = tags.Build([]tags.Tag{{Key: "json", Value: inner.InlinedTag}}, nil)
+					writeTSProperty(&buf, "    ", inner, named)
+				}
+			}
+			buf.WriteString("  };\n")
+		}
+		buf.WriteString("}\n")
+	}
+
+	return buf.String(), nil
+}
+
+// writeTSProperty declares a field as an interface property, unless it is left out of JSON
+func writeTSProperty(buf *bytes.Buffer, indent string, field models.FieldInfo, named tsNamedTypes) {
+	tagValue, ok := fieldJSONTag(field)
+	if !ok {
+		return
+	}
+	options := strings.Split(tagValue, ",")
+	optional := ""
+	typeStr := named.render(field.GoType)
+	if field.NestedJSON {
+		// MarshalJSON writes types.nested_json_fields values as encoded strings
+		typeStr = "string"
+	}
+	if hasTagOption(options[1:], "omitempty") || hasTagOption(options[1:], "omitzero") {
+		optional = "?"
+	} else if field.GoType.IsPointer {
+		// A nil pointer without omitempty is written as null
+		typeStr += " | null"
+	}
+
+	writeJSDoc(buf, indent, withDeprecatedTag(field.Comment, field.Deprecated))
+	buf.WriteString(fmt.Sprintf("%s%s%s: %s;\n", indent, tsPropertyName(options[0]), optional, typeStr))
+}
+
+// tsNamedTypes records the named types declared in the output, which fields refer to by name
+type tsNamedTypes map[string]bool
+
+// declaration renders the right-hand side of a named type; enums become a union of their
+// values and discriminated unions a union of their variant interfaces
+func (named tsNamedTypes) declaration(namedType models.NamedType) string {
+	if namedType.Union != nil {
+		variants := make([]string, len(namedType.Union.Variants))
+		for i, variant := range namedType.Union.Variants {
+			variants[i] = variant.Struct
+		}
+		return strings.Join(variants, " | ")
+	}
+	if len(namedType.Enum) == 0 {
+		return named.render(namedType.Type)
+	}
+	values := make([]string, len(namedType.Enum))
+	for i, value := range namedType.Enum {
+		values[i] = value.Value
+	}
+	return strings.Join(values, " | ")
+}
+
+// render maps a Go type to the TypeScript type of its JSON encoding
+func (named tsNamedTypes) render(typeInfo models.TypeInfo) string {
+	if named[typeInfo.Name] {
+		return typeInfo.Name
+	}
+
+	switch typeInfo.Kind {
+	case models.Struct:
+		return typeInfo.StructName
+	case models.Slice:
+		if typeInfo.SliceElementType == nil {
+			return "unknown[]"
+		}
+		elementType := named.render(*typeInfo.SliceElementType)
+		if strings.Contains(elementType, " ") {
+			elementType = "(" + elementType + ")"
+		}
+		return elementType + "[]"
+	case models.Map:
+		if typeInfo.MapValueType == nil {
+			return "Record<string, unknown>"
+		}
+		return "Record<string, " + named.render(*typeInfo.MapValueType) + ">"
+	case models.String, models.Time, models.UUID:
+		return "string"
+	case models.Int, models.Float, models.Duration:
+		return "number"
+	case models.Bool:
+		return "boolean"
+	default:
+		return "unknown"
+	}
+}
+
+// tsPropertyName quotes JSON keys that aren't valid TypeScript identifiers
+func tsPropertyName(key string) string {
+	for i, r := range key {
+		isLetter := r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return strconv.Quote(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// writeJSDoc writes a comment as a JSDoc block, on one line when it fits
+func writeJSDoc(buf *bytes.Buffer, indent, comment string) {
+	lines := commentLines(strings.ReplaceAll(comment, "*/", "*\\/"))
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		buf.WriteString(fmt.Sprintf("%s/** %s */\n", indent, lines[0]))
+		return
+	}
+	buf.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		buf.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	buf.WriteString(indent + " */\n")
+}
+
+// withDeprecatedTag adds JSDoc's @deprecated tag to the comment of a deprecated declaration
+func withDeprecatedTag(comment string, deprecated bool) string {
+	if !deprecated {
+		return comment
+	}
+	return strings.TrimSpace(comment + "\n\n@deprecated")
+}
+
+// hasTagOption reports whether a struct tag option such as "omitempty" is present
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// unionDeclarations declares a discriminated union's interface and the named slice
+// that decodes it
+func unionDeclarations(namedType models.NamedType) string {
+	union := namedType.Union
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s interface {\n", namedType.Name)
+	fmt.Fprintf(&b, "\t%s()\n", unionMarker(namedType.Name))
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "// %s decodes a JSON array of %s values, choosing each element's struct by its %q key.\n", union.SliceName, namedType.Name, union.Discriminator)
+	fmt.Fprintf(&b, "type %s []%s\n", union.SliceName, namedType.Name)
+	return b.String()
+}
+
+// unionMarker names the unexported method that marks a union's variants
+func unionMarker(interfaceName string) string {
+	return "is" + interfaceName
+}
+
+// unionMarkerMethods returns the marker method of each variant struct, by struct name
+func unionMarkerMethods(namedTypes []models.NamedType) map[string][]generatedMethod {
+	methods := make(map[string][]generatedMethod)
+	for _, namedType := range namedTypes {
+		if namedType.Union == nil {
+			continue
+		}
+		marker := unionMarker(namedType.Name)
+		for _, variant := range namedType.Union.Variants {
+			if hasMethod(methods[variant.Struct], marker) {
+				continue
+			}
+			code := fmt.Sprintf("// %s marks %s as a %s.\nfunc (%s) %s() {}\n", marker, variant.Struct, namedType.Name, variant.Struct, marker)
+			methods[variant.Struct] = append(methods[variant.Struct], generatedMethod{name: marker, code: code})
+		}
+	}
+	return methods
+}
+
+// unionUnmarshalMethod generates UnmarshalJSON for a union's slice. Each element is
+// decoded into a pointer to the struct its discriminator names; null elements stay nil
+// and unknown discriminators are an error.
+func unionUnmarshalMethod(namedType models.NamedType) generatedMethod {
+	union := namedType.Union
+	recv := receiverName(union.SliceName)
+	discriminatorTag := tags.Build([]tags.Tag{{Key: "json", Value: union.Discriminator}}, nil)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// UnmarshalJSON decodes each element into the struct its %q key names.\n", union.Discriminator)
+	fmt.Fprintf(&b, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, union.SliceName)
+	b.WriteString("\tvar elements []json.RawMessage\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &elements); err != nil {\n")
+	b.WriteString("\t\treturn err\n")
+	b.WriteString("\t}\n")
+	b.WriteString("\tif elements == nil {\n")
+	fmt.Fprintf(&b, "\t\t*%s = nil\n", recv)
+	b.WriteString("\t\treturn nil\n")
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\tvalues := make(%s, 0, len(elements))\n", union.SliceName)
+	b.WriteString("\tfor i, element := range elements {\n")
+	b.WriteString("\t\tif string(element) == \"null\" {\n")
+	b.WriteString("\t\t\tvalues = append(values, nil)\n")
+	b.WriteString("\t\t\tcontinue\n")
+	b.WriteString("\t\t}\n")
+	fmt.Fprintf(&b, "\t\tvar variant struct {\n\t\t\tName string %s\n\t\t}\n", discriminatorTag)
+	b.WriteString("\t\tif err := json.Unmarshal(element, &variant); err != nil {\n")
+	b.WriteString("\t\t\treturn fmt.Errorf(\"element %d: %w\", i, err)\n")
+	b.WriteString("\t\t}\n")
+	fmt.Fprintf(&b, "\t\tvar value %s\n", namedType.Name)
+	b.WriteString("\t\tswitch variant.Name {\n")
+	for _, variant := range union.Variants {
+		fmt.Fprintf(&b, "\t\tcase %s:\n", strconv.Quote(variant.Value))
+		fmt.Fprintf(&b, "\t\t\tvalue = &%s{}\n", variant.Struct)
+	}
+	b.WriteString("\t\tdefault:\n")
+	fmt.Fprintf(&b, "\t\t\treturn fmt.Errorf(\"element %%d: unknown %s %%q\", i, variant.Name)\n", strings.ReplaceAll(strings.Trim(strconv.Quote(union.Discriminator), `"`), "%", "%%"))
+	b.WriteString("\t\t}\n")
+	b.WriteString("\t\tif err := json.Unmarshal(element, value); err != nil {\n")
+	b.WriteString("\t\t\treturn fmt.Errorf(\"element %d: %w\", i, err)\n")
+	b.WriteString("\t\t}\n")
+	b.WriteString("\t\tvalues = append(values, value)\n")
+	b.WriteString("\t}\n")
+	fmt.Fprintf(&b, "\t*%s = values\n", recv)
+	b.WriteString("\treturn nil\n")
+	b.WriteString("}\n")
+
+	return generatedMethod{name: "UnmarshalJSON", code: b.String(), imports: []string{"encoding/json", "fmt"}}
+}
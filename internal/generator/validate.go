@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/formats"
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// formatCheck is how a generated Validate method checks a string format. init is
+// an optional statement run before cond, which is true when the value is invalid.
+type formatCheck struct {
+	init    string
+	cond    string
+	imports []string
+}
+
+// formatCheckFor returns the check of a string format schema.runtime_format_validation
+// enforces, for the value expression v. It reports false for other formats.
+func formatCheckFor(format, v string) (formatCheck, bool) {
+	switch format {
+	case "uuid":
+		return formatCheck{cond: "!uuidFormat.MatchString(" + v + ")", imports: []string{"regexp"}}, true
+	case "email":
+		return formatCheck{init: "_, err := mail.ParseAddress(" + v + ")", cond: "err != nil", imports: []string{"net/mail"}}, true
+	case "uri", "url":
+		return formatCheck{init: "u, err := url.Parse(" + v + ")", cond: "err != nil || !u.IsAbs()", imports: []string{"net/url"}}, true
+	case "ipv4":
+		return formatCheck{cond: "net.ParseIP(" + v + ") == nil || strings.Contains(" + v + ", \":\")", imports: []string{"net", "strings"}}, true
+	case "ipv6":
+		return formatCheck{cond: "net.ParseIP(" + v + ") == nil || !strings.Contains(" + v + ", \":\")", imports: []string{"net", "strings"}}, true
+	}
+	return formatCheck{}, false
+}
+
+// uuidFormatVar declares the pattern Validate methods check uuid strings against,
+// the one the analyzer recognizes UUIDs with
+var uuidFormatVar = "// uuidFormat matches the UUIDs checked by Validate methods.\n" +
+	"var uuidFormat = regexp.MustCompile(`" + formats.UUID + "`)\n"
+
+// usesUUIDFormat reports whether any generated Validate method refers to uuidFormat
+func usesUUIDFormat(methodsByStruct map[string][]generatedMethod) bool {
+	for _, methods := range methodsByStruct {
+		for _, method := range methods {
+			if method.name == "Validate" && strings.Contains(method.code, "uuidFormat.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateMethod generates a Validate method checking the JSON Schema formats of a
+// struct's string fields at runtime, for formats such as uuid that validate tags
+// don't cover. Empty strings and nil pointers are left alone. Structs without
+// checked formats get none.
+func validateMethod(structDef models.StructDef) (generatedMethod, bool) {
+	recv := receiverName(structDef.Name)
+	imports := []string{"fmt"}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Validate checks that the string fields of %s hold values of their JSON Schema formats.\n", recv)
+	fmt.Fprintf(&b, "func (%s %s) Validate() error {\n", recv, structDef.Name)
+	checked := false
+	for _, field := range structDef.Fields {
+		if field.Format == "" || field.GoType.Name != "string" {
+			continue
+		}
+		value := recv + "." + field.GoName
+		present := value + ` != ""`
+		if field.GoType.IsPointer {
+			present = value + " != nil && *" + value + ` != ""`
+			value = "*" + value
+		}
+		check, ok := formatCheckFor(field.Format, value)
+		if !ok {
+			continue
+		}
+		checked = true
+		imports = append(imports, check.imports...)
+
+		message := strconv.Quote(strings.ReplaceAll(field.JSONKey, "%", "%%") + ": %q is not a valid " + field.Format)
+		fail := fmt.Sprintf("\t\treturn fmt.Errorf(%s, %s)\n", message, value)
+		if check.init == "" {
+			cond := check.cond
+			if strings.Contains(cond, "||") {
+				cond = "(" + cond + ")"
+			}
+			fmt.Fprintf(&b, "\tif %s && %s {\n", present, cond)
+			b.WriteString(fail)
+			b.WriteString("\t}\n")
+			continue
+		}
+		fmt.Fprintf(&b, "\tif %s {\n", present)
+		fmt.Fprintf(&b, "\t\tif %s; %s {\n", check.init, check.cond)
+		b.WriteString("\t" + fail)
+		b.WriteString("\t\t}\n")
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn nil\n")
+	b.WriteString("}\n")
+	if !checked {
+		return generatedMethod{}, false
+	}
+
+	return generatedMethod{name: "Validate", code: b.String(), imports: imports}, true
+}
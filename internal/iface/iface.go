@@ -0,0 +1,292 @@
+// Package iface parses Go interface declarations into method stubs for generated structs
+package iface
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// ParseFile reads the Go source file at path and returns the methods of the named interface.
+// If name is empty the file must declare exactly one interface.
+func ParseFile(path, name string) ([]models.MethodDef, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interface file: %w", err)
+	}
+	return ParseSource(path, src, name)
+}
+
+// ParseSource parses Go source and returns the methods of the named interface.
+// Interfaces embedded from the same file are expanded; embedding interfaces from
+// other packages is not supported because their method sets can't be resolved
+// without type-checking the dependency.
+func ParseSource(filename string, src []byte, name string) ([]models.MethodDef, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Go source: %w", err)
+	}
+
+	c := &collector{
+		fset:       fset,
+		interfaces: make(map[string]*ast.TypeSpec),
+		imports:    packageImports(file),
+		seen:       make(map[string]bool),
+		visiting:   make(map[string]bool),
+	}
+
+	var declared []string
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				c.interfaces[typeSpec.Name.Name] = typeSpec
+				declared = append(declared, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	if name == "" {
+		if len(declared) != 1 {
+			return nil, fmt.Errorf("%s declares %d interfaces; select one with %s:<Name>", filename, len(declared), filename)
+		}
+		name = declared[0]
+	}
+
+	if _, ok := c.interfaces[name]; !ok {
+		return nil, fmt.Errorf("interface %s not found in %s", name, filename)
+	}
+
+	if err := c.collect(name); err != nil {
+		return nil, err
+	}
+	return c.methods, nil
+}
+
+// importRef is an import available to the parsed file
+type importRef struct {
+	path    string
+	aliased bool
+}
+
+// collector walks an interface and its embedded interfaces, accumulating method stubs
+type collector struct {
+	fset       *token.FileSet
+	interfaces map[string]*ast.TypeSpec
+	imports    map[string]importRef
+	methods    []models.MethodDef
+	seen       map[string]bool
+	visiting   map[string]bool
+}
+
+// collect appends the methods of the named interface, expanding embedded interfaces in place
+func (c *collector) collect(name string) error {
+	typeSpec := c.interfaces[name]
+	if typeSpec.TypeParams != nil {
+		return fmt.Errorf("generic interface %s is not supported", name)
+	}
+	if c.visiting[name] {
+		return fmt.Errorf("interface %s embeds itself", name)
+	}
+	c.visiting[name] = true
+	defer delete(c.visiting, name)
+
+	for _, field := range typeSpec.Type.(*ast.InterfaceType).Methods.List {
+		if funcType, ok := field.Type.(*ast.FuncType); ok {
+			for _, methodName := range field.Names {
+				if err := c.addMethod(methodName.Name, funcType); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		switch embedded := field.Type.(type) {
+		case *ast.Ident:
+			if _, ok := c.interfaces[embedded.Name]; ok {
+				if err := c.collect(embedded.Name); err != nil {
+					return err
+				}
+				continue
+			}
+			if embedded.Name == "error" {
+				if !c.seen["Error"] {
+					c.seen["Error"] = true
+					c.methods = append(c.methods, models.MethodDef{Name: "Error", Results: "string"})
+				}
+				continue
+			}
+			return fmt.Errorf("embedded interface %s is not declared in this file", embedded.Name)
+		case *ast.SelectorExpr:
+			return fmt.Errorf("embedded interface %s from another package is not supported", c.render(embedded))
+		default:
+			return fmt.Errorf("interface %s contains type constraints, which are not supported", name)
+		}
+	}
+
+	return nil
+}
+
+// addMethod records a method signature, skipping names already contributed by another embedded interface
+func (c *collector) addMethod(name string, funcType *ast.FuncType) error {
+	if c.seen[name] {
+		return nil
+	}
+	c.seen[name] = true
+
+	imports := make(map[string]struct{})
+	params, err := c.fieldList(funcType.Params, imports)
+	if err != nil {
+		return fmt.Errorf("method %s: %w", name, err)
+	}
+	results, err := c.fieldList(funcType.Results, imports)
+	if err != nil {
+		return fmt.Errorf("method %s: %w", name, err)
+	}
+	// A single unnamed result is written without parentheses
+	if funcType.Results != nil && (len(funcType.Results.List) > 1 || len(funcType.Results.List[0].Names) > 0) {
+		results = "(" + results + ")"
+	}
+
+	method := models.MethodDef{Name: name, Params: params, Results: results}
+	for imp := range imports {
+		method.Imports = append(method.Imports, imp)
+	}
+	sort.Strings(method.Imports)
+	c.methods = append(c.methods, method)
+	return nil
+}
+
+// fieldList renders a parameter or result list without its parentheses
+func (c *collector) fieldList(list *ast.FieldList, imports map[string]struct{}) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+
+	parts := make([]string, 0, len(list.List))
+	for _, field := range list.List {
+		if err := c.resolveImports(field.Type, imports); err != nil {
+			return "", err
+		}
+		typeStr := c.render(field.Type)
+		if len(field.Names) == 0 {
+			parts = append(parts, typeStr)
+			continue
+		}
+		names := make([]string, len(field.Names))
+		for i, ident := range field.Names {
+			names[i] = ident.Name
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+typeStr)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// resolveImports records the import paths for package-qualified types in expr. Types
+// declared in the interface's own package are rejected: the generated code lives in
+// another package and can't refer to them.
+func (c *collector) resolveImports(expr ast.Expr, imports map[string]struct{}) error {
+	var err error
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.Field:
+			// Only the types of a func or struct type's fields name types, not their names
+			err = c.resolveImports(node.Type, imports)
+			return false
+		case *ast.Ident:
+			if _, ok := types.Universe.Lookup(node.Name).(*types.TypeName); !ok {
+				err = fmt.Errorf("type %s is declared in the interface's package, which generated code can't refer to; use predeclared or imported types", node.Name)
+			}
+			return false
+		}
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := selector.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		ref, ok := c.imports[pkg.Name]
+		switch {
+		case !ok:
+			err = fmt.Errorf("package %s is not imported", pkg.Name)
+		case ref.aliased:
+			err = fmt.Errorf("aliased import %s (%q) is not supported", pkg.Name, ref.path)
+		default:
+			imports[ref.path] = struct{}{}
+		}
+		return false
+	})
+	return err
+}
+
+// render prints an expression as Go source
+func (c *collector) render(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, c.fset, expr)
+	return buf.String()
+}
+
+// packageImports maps the names a file uses for its imports to their paths
+func packageImports(file *ast.File) map[string]importRef {
+	imports := make(map[string]importRef, len(file.Imports))
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := defaultPackageName(path)
+		aliased := false
+		if spec.Name != nil {
+			if spec.Name.Name == "_" || spec.Name.Name == "." {
+				continue
+			}
+			aliased = spec.Name.Name != name
+			name = spec.Name.Name
+		}
+		imports[name] = importRef{path: path, aliased: aliased}
+	}
+	return imports
+}
+
+// defaultPackageName guesses the package name for an import path from its last element,
+// skipping major version suffixes such as "/v2" and "gopkg.in/yaml.v3"
+func defaultPackageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isMajorVersion(name) {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	return name
+}
+
+// isMajorVersion reports whether s looks like "v2", "v3", ...
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
@@ -0,0 +1,162 @@
+package iface
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSource_SingleMethod(t *testing.T) {
+	src := `package store
+
+type Namer interface {
+	Name() string
+}
+`
+	methods, err := ParseSource("namer.go", []byte(src), "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.MethodDef{{Name: "Name", Results: "string"}}, methods)
+}
+
+func TestParseSource_Signatures(t *testing.T) {
+	src := `package store
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Store interface {
+	Load(ctx context.Context, id string, opts ...int) (map[string]time.Duration, error)
+	Save(context.Context, *yaml.Node) error
+	Stats() (hits, misses int)
+	Close()
+}
+`
+	methods, err := ParseSource("store.go", []byte(src), "Store")
+	require.NoError(t, err)
+	require.Len(t, methods, 4)
+
+	assert.Equal(t, models.MethodDef{
+		Name:    "Load",
+		Params:  "ctx context.Context, id string, opts ...int",
+		Results: "(map[string]time.Duration, error)",
+		Imports: []string{"context", "time"},
+	}, methods[0])
+	assert.Equal(t, models.MethodDef{
+		Name:    "Save",
+		Params:  "context.Context, *yaml.Node",
+		Results: "error",
+		Imports: []string{"context", "gopkg.in/yaml.v3"},
+	}, methods[1])
+	assert.Equal(t, "(hits, misses int)", methods[2].Results)
+	assert.Equal(t, models.MethodDef{Name: "Close"}, methods[3])
+}
+
+func TestParseSource_EmbeddedInterfaces(t *testing.T) {
+	src := `package store
+
+type Namer interface {
+	Name() string
+}
+
+type Entity interface {
+	Namer
+	error
+	ID() int
+	Name() string
+}
+`
+	methods, err := ParseSource("entity.go", []byte(src), "Entity")
+	require.NoError(t, err)
+
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = method.Name
+	}
+	assert.Equal(t, []string{"Name", "Error", "ID"}, names)
+}
+
+func TestParseSource_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		iface   string
+		wantErr string
+	}{
+		{
+			name:    "ambiguous interface",
+			src:     "package p\ntype A interface{ A() }\ntype B interface{ B() }\n",
+			wantErr: "declares 2 interfaces",
+		},
+		{
+			name:    "missing interface",
+			src:     "package p\ntype A interface{ A() }\n",
+			iface:   "B",
+			wantErr: "interface B not found",
+		},
+		{
+			name:    "external embedded interface",
+			src:     "package p\nimport \"io\"\ntype A interface{ io.Reader }\n",
+			wantErr: "io.Reader from another package is not supported",
+		},
+		{
+			name:    "aliased import",
+			src:     "package p\nimport ctx \"context\"\ntype A interface{ Do(ctx.Context) }\n",
+			wantErr: "aliased import ctx",
+		},
+		{
+			name:    "generic interface",
+			src:     "package p\ntype A[T any] interface{ Get() T }\n",
+			wantErr: "generic interface A",
+		},
+		{
+			name:    "local type",
+			src:     "package p\ntype Options struct{}\ntype A interface{ Apply(opts *Options) error }\n",
+			wantErr: "method Apply: type Options is declared in the interface's package",
+		},
+		{
+			name:    "local type in a func parameter",
+			src:     "package p\ntype Event struct{}\ntype A interface{ On(handler func(e Event)) }\n",
+			wantErr: "type Event is declared",
+		},
+		{
+			name:    "invalid source",
+			src:     "package p\ntype A interface{",
+			wantErr: "failed to parse Go source",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSource("p.go", []byte(tt.src), tt.iface)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "closer.go")
+	require.NoError(t, os.WriteFile(path, []byte("package p\ntype Closer interface{ Close() error }\n"), 0o644))
+
+	methods, err := ParseFile(path, "Closer")
+	require.NoError(t, err)
+	assert.Equal(t, []models.MethodDef{{Name: "Close", Results: "error"}}, methods)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.go"), "")
+	assert.Error(t, err)
+}
+
+func TestDefaultPackageName(t *testing.T) {
+	assert.Equal(t, "context", defaultPackageName("context"))
+	assert.Equal(t, "json", defaultPackageName("encoding/json"))
+	assert.Equal(t, "yaml", defaultPackageName("gopkg.in/yaml.v3"))
+	assert.Equal(t, "kong", defaultPackageName("github.com/alecthomas/kong/v2"))
+}
@@ -0,0 +1,154 @@
+// Package ignore matches paths against a .gotyperignore file, which uses gitignore
+// syntax to keep sample files out of --input-dir and protect files from --split-files
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the ignore file looked for in a directory
+const FileName = ".gotyperignore"
+
+// Matcher reports whether paths are ignored. The zero Matcher ignores nothing.
+type Matcher struct {
+	rules []rule
+}
+
+// rule is a compiled pattern line
+type rule struct {
+	regex   *regexp.Regexp
+	negate  bool // !pattern re-includes what earlier rules ignored
+	dirOnly bool // pattern/ only matches directories
+}
+
+// Load reads the .gotyperignore in dir. A directory without one gives a Matcher that
+// ignores nothing.
+func Load(dir string) (*Matcher, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return &Matcher{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return Parse(string(data))
+}
+
+// Parse compiles the lines of an ignore file. Blank lines and lines starting with #
+// are skipped; a leading ! negates a pattern, a trailing / limits it to directories,
+// and a pattern containing a / other than a trailing one is relative to the
+// directory of the file rather than matching at any depth. *, ? and [...] match
+// within a path segment and ** across segments.
+func Parse(content string) (*Matcher, error) {
+	matcher := &Matcher{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var r rule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`) // \# and \! match a literal # or !
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		expr := globToRegexp(line)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "^(?:.*/)?" + expr + "$"
+		}
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid pattern %q: %w", FileName, lineNumber, scanner.Text(), err)
+		}
+		r.regex = regex
+		matcher.rules = append(matcher.rules, r)
+	}
+	return matcher, scanner.Err()
+}
+
+// Match reports whether path, relative to the ignore file's directory and separated
+// by slashes or the OS separator, is ignored. A path inside an ignored directory is
+// ignored too, as in git.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if m.matchPath(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.matchPath(path, isDir)
+}
+
+// matchPath applies the rules to a single path; the last matching rule wins
+func (m *Matcher) matchPath(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.regex.MatchString(path) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates a gitignore glob to an unanchored regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
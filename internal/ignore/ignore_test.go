@@ -0,0 +1,65 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	matcher, err := Parse(`
+# Fixtures are not real responses
+fixtures/
+*.draft.json
+/root_only.json
+!keep.draft.json
+docs/**/*.md
+handwritten_?.go
+\#hash.json
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"fixtures", true, true},
+		{"fixtures/user.json", false, true},
+		{"api/fixtures/user.json", false, true},
+		{"fixtures", false, false}, // fixtures/ only matches directories
+		{"user.draft.json", false, true},
+		{"api/user.draft.json", false, true},
+		{"keep.draft.json", false, false},
+		{"root_only.json", false, true},
+		{"api/root_only.json", false, false},
+		{"docs/a/b/readme.md", false, true},
+		{"docs/readme.md", false, true},
+		{"handwritten_a.go", false, true},
+		{"handwritten_ab.go", false, false},
+		{"#hash.json", false, true},
+		{"user.json", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, matcher.Match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	matcher, err := Load(dir)
+	require.NoError(t, err)
+	assert.False(t, matcher.Match("anything.json", false), "a missing file ignores nothing")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("secret.json\n"), 0o644))
+	matcher, err = Load(dir)
+	require.NoError(t, err)
+	assert.True(t, matcher.Match("secret.json", false))
+	assert.False(t, matcher.Match("public.json", false))
+}
@@ -1,5 +1,13 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
 // JSONValue represents any JSON value (string, number, boolean, null, object, array)
 type JSONValue interface{}
 
@@ -13,6 +21,12 @@ type JSONArray []JSONValue
 type IntermediateRepresentation struct {
 	Root        JSONValue
 	RootIsArray bool // True if the root of the JSON is an array vs an object
+	// DuplicateKeys lists the paths of object keys that appeared more than once.
+	// The last value for each key is kept, matching encoding/json.
+	DuplicateKeys []string
+	// NonStandardNumbers lists the paths of NaN and Infinity values, read when the
+	// parser allows non-standard numbers. They are held as json.Number.
+	NonStandardNumbers []string
 }
 
 // GoTypeKind represents the inferred Go type
@@ -29,10 +43,12 @@ const (
 	// Complex types
 	Struct GoTypeKind = "struct"
 	Slice  GoTypeKind = "slice"
+	Map    GoTypeKind = "map" // map[string]T, for objects whose keys are data rather than field names
 
 	// Special string types
-	Time GoTypeKind = "time.Time" // Will require import "time"
-	UUID GoTypeKind = "uuid.UUID" // Will require import "github.com/google/uuid" or similar
+	Time     GoTypeKind = "time.Time"     // Will require import "time"
+	Duration GoTypeKind = "time.Duration" // Numeric JSON converted using FieldInfo.DurationUnit
+	UUID     GoTypeKind = "uuid.UUID"     // Will require import "github.com/google/uuid" or similar
 )
 
 // TypeInfo holds information about an inferred Go type.
@@ -42,6 +58,9 @@ type TypeInfo struct {
 	IsPointer        bool       `json:"is_pointer,omitempty"`         // True if the type should be a pointer (e.g., for nullable fields)
 	StructName       string     `json:"struct_name,omitempty"`        // If Kind is Struct, this is the name of the defined struct.
 	SliceElementType *TypeInfo  `json:"slice_element_type,omitempty"` // If Kind is Slice, this describes the element type.
+	ArrayLength      int        `json:"array_length,omitempty"`       // If Kind is Slice and this is set, a fixed-size array [ArrayLength]T
+	MapValueType     *TypeInfo  `json:"map_value_type,omitempty"`     // If Kind is Map, this describes the value type; keys are strings.
+	Import           string     `json:"import,omitempty"`             // Package path the qualifier in Name refers to, for mapped types such as uuid.UUID
 }
 
 // FieldInfo represents a field within a Go struct to be generated.
@@ -52,6 +71,30 @@ type FieldInfo struct {
 	JSONTag string            `json:"json_tag"` // e.g., `json:"user_name,omitempty"`
 	Tags    map[string]string `json:"tags"`     // Multiple tag formats: {"json": "user_name,omitempty", "yaml": "user_name", "xml": "user_name"}
 	Comment string            `json:"comment"`  // Field comment
+	// Doc is a comment written above the field, without the leading "//"
+	Doc string `json:"doc,omitempty"`
+	// DurationUnit is the unit ("s", "ms", ...) of the JSON number behind a time.Duration field
+	DurationUnit string `json:"duration_unit,omitempty"`
+	// Optional is true when the key was missing from some of the objects merged into the struct
+	Optional bool `json:"optional,omitempty"`
+	// LenientString marks a string field that also accepts JSON numbers and booleans, keeping their text
+	LenientString bool `json:"lenient_string,omitempty"`
+	// FormattedNumber marks a number field whose JSON values may be strings with
+	// formatting, such as "12.5%" or "$1,200.00"
+	FormattedNumber bool `json:"formatted_number,omitempty"`
+	// NestedJSON marks a field whose JSON value is a string holding the encoded JSON of its type
+	NestedJSON bool `json:"nested_json,omitempty"`
+	// Deprecated marks a field that should no longer be used, e.g. from JSON Schema's deprecated keyword
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Default is the value a missing field takes, e.g. from JSON Schema's default keyword
+	Default JSONValue `json:"default,omitempty"`
+	// Format is the JSON Schema format of a string field, e.g. "uuid" or "email"
+	Format string `json:"format,omitempty"`
+	// InlinedFrom is the JSON key of the nested object a field was promoted out of by
+	// types.inline_fields. Such fields are tagged json:"-" and encoded through it.
+	InlinedFrom string `json:"inlined_from,omitempty"`
+	// InlinedTag is the json tag value of a promoted field within its nested object
+	InlinedTag string `json:"inlined_tag,omitempty"`
 }
 
 // StructDef represents a Go struct definition that needs to be generated.
@@ -60,7 +103,67 @@ type StructDef struct {
 	Name   string      `json:"name"`    // Name of the Go struct (e.g., "Root", "User", "Address")
 	Fields []FieldInfo `json:"fields"`  // List of fields in this struct
 	IsRoot bool        `json:"is_root"` // True if this is a top-level struct generated from the JSON root
-	// We might add comments or other metadata here later.
+	// Comment documents the struct, e.g. from a JSON Schema description. It has no leading "//".
+	Comment string `json:"comment,omitempty"`
+	// Deprecated marks a struct whose type should no longer be used
+	Deprecated bool `json:"deprecated,omitempty"`
+	// DisallowUnknownFields requests an UnmarshalJSON that rejects keys the struct doesn't declare.
+	DisallowUnknownFields bool `json:"disallow_unknown_fields,omitempty"`
+	// WrapsArray marks a struct whose only field holds the elements of a JSON array root.
+	// It is encoded and decoded as the bare array.
+	WrapsArray bool `json:"wraps_array,omitempty"`
+	// Methods are stub methods to generate on the struct, e.g. to satisfy an interface.
+	Methods []MethodDef `json:"methods,omitempty"`
+	// SourcePath is the JSONPath of the sample objects the struct was inferred from,
+	// e.g. $.orders[*].items[*]; empty for structs not inferred from JSON
+	SourcePath string `json:"source_path,omitempty"`
+}
+
+// MethodDef describes a method signature to generate as a stub.
+type MethodDef struct {
+	Name    string   `json:"name"`              // Method name
+	Params  string   `json:"params"`            // Parameter list without parentheses, e.g. "ctx context.Context, id string"
+	Results string   `json:"results,omitempty"` // Result list as written after the parameters, e.g. "error" or "(int, error)"
+	Imports []string `json:"imports,omitempty"` // Import paths referenced by the signature
+}
+
+// NamedType represents a package-level named type over a non-struct type, e.g. "type RootType string".
+type NamedType struct {
+	Name    string   `json:"name"`               // Type name
+	Type    TypeInfo `json:"type"`               // Underlying type
+	IsAlias bool     `json:"is_alias,omitempty"` // Emit "type Name = T" so T's methods are kept
+	Comment string   `json:"comment,omitempty"`  // Doc comment without the leading "//"
+	// Enum lists the allowed values when the type is an enumeration
+	Enum []EnumValue `json:"enum,omitempty"`
+	// Union makes the type an interface over the structs of a discriminated union
+	Union *Union `json:"union,omitempty"`
+}
+
+// Union describes JSON objects whose Discriminator key names which struct they decode into.
+type Union struct {
+	Discriminator string         `json:"discriminator"` // JSON key naming the variant, e.g. "type"
+	SliceName     string         `json:"slice_name"`    // Named slice of the interface that decodes the variants, e.g. "Shapes"
+	Variants      []UnionVariant `json:"variants"`
+}
+
+// UnionVariant is one member of a discriminated union.
+type UnionVariant struct {
+	Value  string `json:"value"`  // Discriminator value, e.g. "circle"
+	Struct string `json:"struct"` // Struct the object decodes into, e.g. "CircleShape"
+}
+
+// EnumValue is one member of an enumerated named type.
+type EnumValue struct {
+	Name  string `json:"name"`  // Go constant name, e.g. "PriorityHigh"
+	Label string `json:"label"` // Symbolic name returned by String(), e.g. "High"
+	Value string `json:"value"` // Go literal for the value, e.g. "3" or "\"high\""
+}
+
+// ConstantDef represents a package-level constant to be generated.
+type ConstantDef struct {
+	Name    string `json:"name"`              // Constant name
+	Value   string `json:"value"`             // Go literal for the value, e.g. "\"person\"" or "42"
+	Comment string `json:"comment,omitempty"` // Doc comment without the leading "//"
 }
 
 // AnalysisResult holds all the struct definitions generated by the analyzer.
@@ -68,6 +171,162 @@ type AnalysisResult struct {
 	Structs []StructDef `json:"structs"`
 	// We might add required imports here later, e.g. "time", "github.com/google/uuid"
 	Imports map[string]struct{} `json:"imports"`
+	// NamedTypes are package-level named types, e.g. for a primitive JSON root
+	NamedTypes []NamedType `json:"named_types,omitempty"`
+	// Constants are package-level constants to generate, e.g. for JSON Schema const values
+	Constants []ConstantDef `json:"constants,omitempty"`
 	// UsedDefaultDateFormat is true if ambiguous dates were detected using the default US format
 	UsedDefaultDateFormat bool `json:"used_default_date_format,omitempty"`
+	// Warnings describe inferences the user may want to check, e.g. arrays with only null elements
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Root is the type of the document root, e.g. a slice of the element struct for an
+	// array root. It is nil when the result describes several types without a root.
+	Root *TypeInfo `json:"root,omitempty"`
+}
+
+// Warning types, which say what a Warning is about
+const (
+	WarningRootName           = "root_name"           // The root name had to be changed into a Go identifier
+	WarningNullArray          = "null_array"          // An array holds only nulls
+	WarningHeterogeneousArray = "heterogeneous_array" // An array mixes element types
+	WarningAmbiguousField     = "ambiguous_field"     // Merged objects disagree on a field's type
+	WarningAmbiguousDate      = "ambiguous_date"      // A date could be MM/DD or DD/MM and the default was used
+	WarningTagName            = "tag_name"            // encoding/json can't use a key as a tag name
+	WarningDuplicateKey       = "duplicate_key"       // An object repeats a key
+	WarningUnmatchedConfig    = "unmatched_config"    // A configured pattern matched no key
+	WarningUntypedSlice       = "untyped_slice"       // The generator was given a slice without an element type
+	WarningMethodClash        = "method_clash"        // A struct has a field named like a method the generator would add
+	WarningBigNumber          = "big_number"          // A number needs a type encoding/json can't decode it into unaided
+	WarningNonStandardNumber  = "non_standard_number" // NaN or Infinity, which encoding/json can't decode into a float64
+	WarningUnionMembers       = "union_members"       // An array had too many variants for a union and was merged
+	WarningNullRoot           = "null_root"           // The root is null, so the root struct only wraps it
+	WarningNestedJSON         = "nested_json"         // A types.nested_json_fields string doesn't hold a JSON object or array
+)
+
+// Warning is an inference the user may want to check, or a problem that didn't stop analysis
+type Warning struct {
+	Type    string `json:"type"`
+	Path    string `json:"path,omitempty"` // Dotted JSON key path of the field concerned, if any
+	Message string `json:"message"`
+}
+
+// String returns the message
+func (w Warning) String() string {
+	return w.Message
+}
+
+// MarshalJSON encodes the result with Imports as a sorted list instead of a set,
+// so the output is stable and easy to inspect.
+func (r AnalysisResult) MarshalJSON() ([]byte, error) {
+	type plain AnalysisResult
+	imports := make([]string, 0, len(r.Imports))
+	for imp := range r.Imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	return json.Marshal(struct {
+		plain
+		Imports []string `json:"imports"`
+	}{plain(r), imports})
+}
+
+// DiscoveryOrder orders structs as they are met walking the document from the root:
+// each struct is followed by the structs its fields reference, in field order.
+// The analyzer and schema converter add nested structs before their parents and use it
+// to reorder them, so output.struct_order "declaration" can keep the slice order.
+func DiscoveryOrder(structs []StructDef, root string) []StructDef {
+	byName := make(map[string]int, len(structs))
+	for i, s := range structs {
+		byName[s.Name] = i
+	}
+
+	ordered := make([]StructDef, 0, len(structs))
+	visited := make(map[string]bool, len(structs))
+	var visit func(name string)
+	visit = func(name string) {
+		i, ok := byName[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		ordered = append(ordered, structs[i])
+		for _, field := range structs[i].Fields {
+			visit(field.GoType.ReferencedStruct())
+		}
+	}
+
+	visit(root)
+	// Structs the root doesn't reach keep their relative order
+	for _, s := range structs {
+		visit(s.Name)
+	}
+	return ordered
+}
+
+// ReferencedStruct returns the name of the struct a type refers to, looking through
+// slices and maps, or "" if there is none
+func (t TypeInfo) ReferencedStruct() string {
+	switch {
+	case t.Kind == Struct:
+		return t.StructName
+	case t.SliceElementType != nil:
+		return t.SliceElementType.ReferencedStruct()
+	case t.MapValueType != nil:
+		return t.MapValueType.ReferencedStruct()
+	}
+	return ""
+}
+
+// CodecSignature describes the attributes of a field, beyond its type and tags, that
+// change the code generated for its struct, such as an UnmarshalJSON stripping number
+// formatting or a Validate method checking a format. Structs whose fields differ in
+// them must not be merged.
+func (f FieldInfo) CodecSignature() string {
+	return fmt.Sprintf("unit=%s|lenient=%t|formatted=%t|nested=%t|default=%#v|format=%s|optional=%t",
+		f.DurationUnit, f.LenientString, f.FormattedNumber, f.NestedJSON, f.Default, f.Format, f.Optional)
+}
+
+// Fingerprint hashes the struct's shape: each field's JSON key, Go name, JSON tag,
+// type and codec signature, independent of field order and of the struct's own name. Structs with the same
+// fields always share a fingerprint; different shapes rarely do, so a match is a
+// candidate to confirm rather than proof of equivalence.
+func (s StructDef) Fingerprint() uint64 {
+	fields := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		var b strings.Builder
+		b.WriteString(f.JSONKey)
+		b.WriteByte(0)
+		b.WriteString(f.GoName)
+		b.WriteByte(0)
+		b.WriteString(f.JSONTag)
+		b.WriteByte(0)
+		writeTypeSignature(&b, &f.GoType)
+		b.WriteByte(0)
+		b.WriteString(f.CodecSignature())
+		fields[i] = b.String()
+	}
+	sort.Strings(fields)
+
+	h := fnv.New64a()
+	for _, f := range fields {
+		h.Write([]byte(f))
+		h.Write([]byte{1})
+	}
+	return h.Sum64()
+}
+
+// writeTypeSignature writes the parts of a type that identify it: kind, name,
+// pointer-ness, struct name and any element or value type
+func writeTypeSignature(b *strings.Builder, t *TypeInfo) {
+	if t == nil {
+		return
+	}
+	fmt.Fprintf(b, "%s|%s|%t|%s|", t.Kind, t.Name, t.IsPointer, t.StructName)
+	switch t.Kind {
+	case Slice:
+		writeTypeSignature(b, t.SliceElementType)
+	case Map:
+		writeTypeSignature(b, t.MapValueType)
+	}
 }
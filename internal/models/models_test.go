@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStructDef_Fingerprint(t *testing.T) {
+	id := FieldInfo{JSONKey: "id", GoName: "Id", GoType: TypeInfo{Kind: Int, Name: "int64"}, JSONTag: "`json:\"id\"`"}
+	name := FieldInfo{JSONKey: "name", GoName: "Name", GoType: TypeInfo{Kind: String, Name: "string"}, JSONTag: "`json:\"name\"`"}
+	tags := FieldInfo{
+		JSONKey: "tags",
+		GoName:  "Tags",
+		GoType:  TypeInfo{Kind: Slice, Name: "[]string", IsPointer: true, SliceElementType: &TypeInfo{Kind: String, Name: "string"}},
+		JSONTag: "`json:\"tags,omitempty\"`",
+	}
+	base := StructDef{Name: "User", Fields: []FieldInfo{id, name, tags}}
+
+	// Same fields in another order, under another name
+	same := StructDef{Name: "Author", Fields: []FieldInfo{tags, name, id}}
+	assert.Equal(t, base.Fingerprint(), same.Fingerprint())
+
+	optionalName := name
+	optionalName.GoType.IsPointer = true
+	renamed := id
+	renamed.GoName = "ID"
+	intTags := tags
+	intTags.GoType.SliceElementType = &TypeInfo{Kind: Int, Name: "int64"}
+	retagged := id
+	retagged.JSONTag = "`json:\"id,omitempty\"`"
+
+	for desc, other := range map[string]StructDef{
+		"missing field":    {Fields: []FieldInfo{id, name}},
+		"pointer type":     {Fields: []FieldInfo{id, optionalName, tags}},
+		"go name":          {Fields: []FieldInfo{renamed, name, tags}},
+		"element type":     {Fields: []FieldInfo{id, name, intTags}},
+		"json tag":         {Fields: []FieldInfo{retagged, name, tags}},
+		"additional field": {Fields: []FieldInfo{id, name, tags, {JSONKey: "x", GoName: "X"}}},
+	} {
+		assert.NotEqual(t, base.Fingerprint(), other.Fingerprint(), desc)
+	}
+}
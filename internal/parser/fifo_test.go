@@ -0,0 +1,60 @@
+//go:build unix
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+func TestParseFile_NamedPipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("can't create a named pipe: %v", err)
+	}
+
+	// Opening a FIFO for writing blocks until it is opened for reading
+	go func() {
+		writer, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		_, _ = writer.WriteString(`{"id": 1}`)
+		_ = writer.Close()
+	}()
+
+	ir, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() of a named pipe error = %v, wantErr nil", err)
+	}
+	obj, ok := ir.Root.(models.JSONObject)
+	if !ok || obj["id"] == nil {
+		t.Errorf("ParseFile() of a named pipe Root = %#v, want an object with id", ir.Root)
+	}
+}
+
+func TestKnownEmpty(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pipe := filepath.Join(dir, "input.fifo")
+	if err := syscall.Mkfifo(pipe, 0o600); err != nil {
+		t.Skipf("can't create a named pipe: %v", err)
+	}
+
+	for path, want := range map[string]bool{empty: true, pipe: false} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := knownEmpty(stat); got != want {
+			t.Errorf("knownEmpty(%s) = %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+
+	"github.com/mcncl/gotyper/internal/errors"
+	"github.com/mcncl/gotyper/internal/models"
+)
+
+// applyFilter runs a jq expression over a decoded JSON value. A filter with several
+// results, such as .data.items[], yields them as an array.
+func applyFilter(expr string, value models.JSONValue) (models.JSONValue, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, errors.NewInputError(fmt.Sprintf("invalid filter %q: %v", expr, err), nil)
+	}
+
+	var results []models.JSONValue
+	iter := query.Run(toJQ(value))
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, isErr := result.(error); isErr {
+			return nil, errors.NewInputError(fmt.Sprintf("filter %q failed: %v", expr, err), nil)
+		}
+		converted, err := fromJQ(result)
+		if err != nil {
+			return nil, errors.NewInputError(fmt.Sprintf("filter %q failed: %v", expr, err), nil)
+		}
+		results = append(results, converted)
+	}
+
+	switch len(results) {
+	case 0:
+		return nil, errors.NewInputError(fmt.Sprintf("filter %q produced no output", expr), errors.ErrEmptyInput)
+	case 1:
+		return results[0], nil
+	default:
+		return models.JSONArray(results), nil
+	}
+}
+
+// toJQ converts a parsed value into the plain types gojq works with. Integers become
+// int (or *big.Int when too large) and other numbers float64.
+func toJQ(value models.JSONValue) any {
+	switch v := value.(type) {
+	case models.JSONObject:
+		obj := make(map[string]any, len(v))
+		for key, val := range v {
+			obj[key] = toJQ(val)
+		}
+		return obj
+	case models.JSONArray:
+		arr := make([]any, len(v))
+		for i, val := range v {
+			arr[i] = toJQ(val)
+		}
+		return arr
+	case json.Number:
+		if i, err := strconv.Atoi(string(v)); err == nil {
+			return i
+		}
+		if i, ok := new(big.Int).SetString(string(v), 10); ok {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// fromJQ converts a gojq result back into parsed values. Whole float64 values keep a
+// decimal point so numbers written as 1.0 are still inferred as floats.
+func fromJQ(value any) (models.JSONValue, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		obj := make(models.JSONObject, len(v))
+		for key, val := range v {
+			converted, err := fromJQ(val)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = converted
+		}
+		return obj, nil
+	case []any:
+		arr := make(models.JSONArray, len(v))
+		for i, val := range v {
+			converted, err := fromJQ(val)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	case int:
+		return json.Number(strconv.Itoa(v)), nil
+	case *big.Int:
+		return json.Number(v.String()), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("result contains %v, which JSON can't represent", v)
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return json.Number(s), nil
+	default:
+		return v, nil
+	}
+}
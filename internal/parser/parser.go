@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,36 +14,59 @@ import (
 	"github.com/mcncl/gotyper/internal/models"
 )
 
+// Options controls optional parser behaviour
+type Options struct {
+	// ErrorOnDuplicateKeys turns duplicate object keys into a parsing error
+	// instead of recording them as a warning
+	ErrorOnDuplicateKeys bool
+	// Filter is a jq expression applied to the decoded JSON before it is analyzed,
+	// e.g. ".data.items" to keep only part of an API response
+	Filter string
+	// AllowTrailingData reads every root value of concatenated JSON such as {...}{...}
+	// and treats them as an array, instead of rejecting more than one root value
+	AllowTrailingData bool
+	// AllowNonStandardNumbers reads the bare NaN, Infinity and -Infinity tokens some
+	// emitters write for non-finite floats, instead of rejecting them as invalid JSON
+	AllowNonStandardNumbers bool
+}
+
 // Parse converts JSON data from an io.Reader into an IntermediateRepresentation
 func Parse(reader io.Reader) (models.IntermediateRepresentation, error) {
+	return ParseWithOptions(reader, Options{})
+}
+
+// ParseWithOptions converts JSON data from an io.Reader into an IntermediateRepresentation
+// using the given parser options
+func ParseWithOptions(reader io.Reader, opts Options) (models.IntermediateRepresentation, error) {
+	if opts.AllowNonStandardNumbers {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return models.IntermediateRepresentation{}, errors.NewInputError("failed to read input", err)
+		}
+		reader = bytes.NewReader(markNonStandardNumbers(data))
+	}
 	decoder := json.NewDecoder(reader)
 	decoder.UseNumber() // Ensure numbers are read as json.Number
 
-	var rootValue models.JSONValue
-	if err := decoder.Decode(&rootValue); err != nil {
-		if stderrors.Is(err, io.EOF) { // io.EOF means empty input if nothing was decoded
-			// To be more precise, check if anything was decoded.
-			// A common way is to check if the reader was advanced.
-			// However, for an empty stream, Decode returns io.EOF.
-			// For a stream with just whitespace, it might also return io.EOF
-			// or a syntax error depending on the content.
-			return models.IntermediateRepresentation{}, errors.NewParsingError("input is empty or contains only whitespace", errors.ErrEmptyInput)
-		}
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		if stderrors.As(err, &syntaxError) {
-			return models.IntermediateRepresentation{}, errors.NewParsingError(
-				fmt.Sprintf("JSON syntax error at offset %d", syntaxError.Offset),
-				errors.ErrInvalidJSON,
-			)
-		}
-		if stderrors.As(err, &unmarshalTypeError) {
-			return models.IntermediateRepresentation{}, errors.NewParsingError(
-				fmt.Sprintf("JSON type error at offset %d for type %s", unmarshalTypeError.Offset, unmarshalTypeError.Type),
-				errors.ErrInvalidJSON,
-			)
+	// Walk the token stream rather than decoding into a map so that duplicate
+	// keys, which encoding/json silently resolves as last-wins, can be reported
+	walker := &tokenWalker{decoder: decoder}
+	rootValue, err := walker.walkRoot()
+	if err != nil {
+		return models.IntermediateRepresentation{}, decodeError(err)
+	}
+
+	if opts.AllowTrailingData && decoder.More() {
+		// Concatenated values are read one after another, like NDJSON without the newlines
+		values := models.JSONArray{rootValue}
+		for decoder.More() {
+			value, err := walker.walkRoot()
+			if err != nil {
+				return models.IntermediateRepresentation{}, decodeError(err)
+			}
+			values = append(values, value)
 		}
-		return models.IntermediateRepresentation{}, errors.NewParsingError("failed to decode JSON", err)
+		rootValue = values
 	}
 
 	// Check for trailing data after the first JSON value.
@@ -64,9 +88,24 @@ func Parse(reader io.Reader) (models.IntermediateRepresentation, error) {
 		}
 	}
 
-	rootValue = normalizeJSONValue(rootValue) // Normalize the root value
+	if len(walker.duplicateKeys) > 0 && opts.ErrorOnDuplicateKeys {
+		return models.IntermediateRepresentation{}, errors.NewParsingError(
+			fmt.Sprintf("duplicate keys found: %s", strings.Join(walker.duplicateKeys, ", ")),
+			errors.ErrDuplicateKeys,
+		)
+	}
+
+	if opts.Filter != "" {
+		rootValue, err = applyFilter(opts.Filter, rootValue)
+		if err != nil {
+			return models.IntermediateRepresentation{}, err
+		}
+	}
+
 	ir := models.IntermediateRepresentation{
-		Root: rootValue,
+		Root:               rootValue,
+		DuplicateKeys:      walker.duplicateKeys,
+		NonStandardNumbers: walker.nonStandardNumbers,
 	}
 
 	// Determine if the root of the JSON structure is an array.
@@ -87,28 +126,200 @@ func Parse(reader io.Reader) (models.IntermediateRepresentation, error) {
 	return ir, nil
 }
 
-// normalizeJSONValue converts raw JSON types into our model types
-func normalizeJSONValue(val models.JSONValue) models.JSONValue {
-	switch v := val.(type) {
-	case map[string]interface{}:
-		obj := make(models.JSONObject, len(v))
-		for key, value := range v {
-			obj[key] = normalizeJSONValue(value)
+// decodeError converts an error from reading a root value into a parsing error
+func decodeError(err error) error {
+	if stderrors.Is(err, io.EOF) { // io.EOF means empty input if nothing was decoded
+		// For an empty stream or a stream with just whitespace, the first
+		// Token call returns io.EOF.
+		return errors.NewParsingError("input is empty or contains only whitespace", errors.ErrEmptyInput)
+	}
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	if stderrors.As(err, &syntaxError) {
+		message := fmt.Sprintf("JSON syntax error at offset %d", syntaxError.Offset)
+		if strings.Contains(syntaxError.Error(), "invalid character 'N'") || strings.Contains(syntaxError.Error(), "invalid character 'I'") {
+			message += " (NaN and Infinity are not valid JSON numbers unless non-standard numbers are allowed)"
 		}
-		return obj
-	case []interface{}:
-		arr := make(models.JSONArray, len(v))
-		for i, value := range v {
-			arr[i] = normalizeJSONValue(value)
+		return errors.NewParsingError(message, errors.ErrInvalidJSON)
+	}
+	if stderrors.As(err, &unmarshalTypeError) {
+		return errors.NewParsingError(
+			fmt.Sprintf("JSON type error at offset %d for type %s", unmarshalTypeError.Offset, unmarshalTypeError.Type),
+			errors.ErrInvalidJSON,
+		)
+	}
+	return errors.NewParsingError("failed to decode JSON", err)
+}
+
+// tokenWalker builds model values from a JSON token stream, recording any
+// duplicate object keys along the way
+type tokenWalker struct {
+	decoder       *json.Decoder
+	duplicateKeys []string
+	// nonStandardNumbers holds the paths of the NaN and Infinity values read
+	nonStandardNumbers []string
+}
+
+// walkRoot reads a single top-level JSON value
+func (w *tokenWalker) walkRoot() (models.JSONValue, error) {
+	token, err := w.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	return w.walkValue(token, "")
+}
+
+// walkValue converts the value starting at token, reading further tokens for
+// objects and arrays. path is the location of the value, used for reporting.
+func (w *tokenWalker) walkValue(token json.Token, path string) (models.JSONValue, error) {
+	if text, ok := token.(string); ok && strings.HasPrefix(text, nonStandardMarker) {
+		w.nonStandardNumbers = append(w.nonStandardNumbers, path)
+		return json.Number(strings.TrimPrefix(text, nonStandardMarker)), nil
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil // Primitives (string, json.Number, bool, nil) are returned as is
+	}
+
+	switch delim {
+	case '{':
+		obj := make(models.JSONObject)
+		for w.decoder.More() {
+			keyToken, err := w.nextToken()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyToken.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", keyToken)
+			}
+
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if _, exists := obj[key]; exists {
+				w.duplicateKeys = append(w.duplicateKeys, keyPath)
+			}
+
+			valueToken, err := w.nextToken()
+			if err != nil {
+				return nil, err
+			}
+			value, err := w.walkValue(valueToken, keyPath)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value // Last value wins, matching encoding/json
+		}
+		if _, err := w.nextToken(); err != nil { // Consume the closing '}'
+			return nil, err
 		}
-		return arr
+		return obj, nil
+	case '[':
+		arr := make(models.JSONArray, 0)
+		for w.decoder.More() {
+			elementToken, err := w.nextToken()
+			if err != nil {
+				return nil, err
+			}
+			element, err := w.walkValue(elementToken, fmt.Sprintf("%s[%d]", path, len(arr)))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, element)
+		}
+		if _, err := w.nextToken(); err != nil { // Consume the closing ']'
+			return nil, err
+		}
+		return arr, nil
 	default:
-		return v // Primitives (string, json.Number, bool, nil) are returned as is
+		return nil, fmt.Errorf("unexpected delimiter %v", delim)
+	}
+}
+
+// nextToken reads a token inside a value. Running out of input here means the
+// value was truncated rather than the input being empty.
+func (w *tokenWalker) nextToken() (json.Token, error) {
+	token, err := w.decoder.Token()
+	if stderrors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	// The tokenizer reports truncated input as a syntax error; report it the
+	// same way Decode does
+	var syntaxError *json.SyntaxError
+	if stderrors.As(err, &syntaxError) && syntaxError.Error() == "unexpected end of JSON input" {
+		return nil, io.ErrUnexpectedEOF
 	}
+	return token, err
+}
+
+// nonStandardMarker starts the strings markNonStandardNumbers replaces NaN and Infinity
+// tokens with, which the walker turns back into numbers. A NUL can't appear unescaped
+// in JSON text, so no string in the input starts with it.
+const nonStandardMarker = "\x00nonstandard:"
+
+// nonStandardTokens are the number tokens markNonStandardNumbers replaces
+var nonStandardTokens = []string{"NaN", "Infinity", "-Infinity", "+Infinity"}
+
+// markNonStandardNumbers replaces the NaN and Infinity tokens outside strings with
+// marked strings, so encoding/json can tokenize the input
+func markNonStandardNumbers(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(data) {
+					i++
+					out.WriteByte(data[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			out.WriteByte(c)
+			continue
+		}
+		replaced := false
+		for _, token := range nonStandardTokens {
+			if bytes.HasPrefix(data[i:], []byte(token)) {
+				literal := strings.TrimPrefix(token, "+")
+				// nonStandardMarker, with its NUL escaped as JSON strings need
+				out.WriteString(`"\u0000nonstandard:` + literal + `"`)
+				i += len(token) - 1
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+// knownEmpty reports whether a file is empty before reading it. Only regular files
+// have a meaningful size: named pipes and devices report zero however much they
+// stream, so they are read and an empty stream is caught by the parser.
+func knownEmpty(stat os.FileInfo) bool {
+	return stat.Mode().IsRegular() && stat.Size() == 0
 }
 
 // ParseString parses JSON from a string
 func ParseString(jsonString string) (models.IntermediateRepresentation, error) {
+	return ParseStringWithOptions(jsonString, Options{})
+}
+
+// ParseStringWithOptions parses JSON from a string using the given parser options
+func ParseStringWithOptions(jsonString string, opts Options) (models.IntermediateRepresentation, error) {
 	// TrimSpace is important here because an empty string reader will give io.EOF to Decode,
 	// but a string with only spaces might not, depending on the decoder's behavior.
 	if strings.TrimSpace(jsonString) == "" {
@@ -116,11 +327,16 @@ func ParseString(jsonString string) (models.IntermediateRepresentation, error) {
 		return models.IntermediateRepresentation{}, errors.NewInputError("input string is empty", errors.ErrEmptyInput)
 	}
 	reader := strings.NewReader(jsonString)
-	return Parse(reader)
+	return ParseWithOptions(reader, opts)
 }
 
 // ParseFile parses JSON from a file path
 func ParseFile(filePath string) (models.IntermediateRepresentation, error) {
+	return ParseFileWithOptions(filePath, Options{})
+}
+
+// ParseFileWithOptions parses JSON from a file path using the given parser options
+func ParseFileWithOptions(filePath string, opts Options) (models.IntermediateRepresentation, error) {
 	if strings.TrimSpace(filePath) == "" {
 		return models.IntermediateRepresentation{}, errors.NewInputError("file path is empty", errors.ErrInvalidFilePath)
 	}
@@ -152,12 +368,12 @@ func ParseFile(filePath string) (models.IntermediateRepresentation, error) {
 			err,
 		)
 	}
-	if stat.Size() == 0 {
+	if knownEmpty(stat) {
 		return models.IntermediateRepresentation{}, errors.NewInputError(
 			fmt.Sprintf("input file '%s' is empty", filePath),
 			errors.ErrFileEmpty,
 		)
 	}
 
-	return Parse(file)
+	return ParseWithOptions(file, opts)
 }
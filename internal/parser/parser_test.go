@@ -279,3 +279,157 @@ func TestParse_RootPrimitives(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_DuplicateKeys(t *testing.T) {
+	jsonStr := `{"a": 1, "a": 2}`
+
+	ir, err := ParseString(jsonStr)
+	if err != nil {
+		t.Fatalf("ParseString() error = %v, wantErr nil", err)
+	}
+
+	expectedDuplicates := []string{"a"}
+	if !reflect.DeepEqual(ir.DuplicateKeys, expectedDuplicates) {
+		t.Errorf("ParseString() DuplicateKeys = %v, want %v", ir.DuplicateKeys, expectedDuplicates)
+	}
+
+	// The last value wins, matching encoding/json
+	expectedRoot := models.JSONObject{"a": json.Number("2")}
+	if !reflect.DeepEqual(ir.Root, expectedRoot) {
+		t.Errorf("ParseString() root = %v, want %v", ir.Root, expectedRoot)
+	}
+
+	_, err = ParseStringWithOptions(jsonStr, Options{ErrorOnDuplicateKeys: true})
+	if err == nil {
+		t.Fatalf("ParseStringWithOptions() with strict duplicate keys, err = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "duplicate keys found: a") {
+		t.Errorf("ParseStringWithOptions() err = %v, want error listing duplicate key 'a'", err)
+	}
+}
+
+func TestParse_DuplicateKeysNestedPaths(t *testing.T) {
+	jsonStr := `{"user": {"name": "a", "name": "b"}, "items": [{"id": 1}, {"id": 2, "id": 3}]}`
+
+	ir, err := ParseString(jsonStr)
+	if err != nil {
+		t.Fatalf("ParseString() error = %v, wantErr nil", err)
+	}
+
+	expectedDuplicates := []string{"user.name", "items[1].id"}
+	if !reflect.DeepEqual(ir.DuplicateKeys, expectedDuplicates) {
+		t.Errorf("ParseString() DuplicateKeys = %v, want %v", ir.DuplicateKeys, expectedDuplicates)
+	}
+}
+
+func TestParse_NoDuplicateKeys(t *testing.T) {
+	ir, err := ParseStringWithOptions(`{"a": 1, "b": {"a": 2}}`, Options{ErrorOnDuplicateKeys: true})
+	if err != nil {
+		t.Fatalf("ParseStringWithOptions() error = %v, wantErr nil", err)
+	}
+	if len(ir.DuplicateKeys) != 0 {
+		t.Errorf("ParseStringWithOptions() DuplicateKeys = %v, want none", ir.DuplicateKeys)
+	}
+}
+
+func TestParse_Filter(t *testing.T) {
+	jsonStr := `{"meta": {"page": 1}, "data": {"items": [{"id": 1, "score": 2.0}, {"id": 2, "score": 3.5}]}}`
+	expectedItems := models.JSONArray{
+		models.JSONObject{"id": json.Number("1"), "score": json.Number("2.0")},
+		models.JSONObject{"id": json.Number("2"), "score": json.Number("3.5")},
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{name: "nested array", filter: ".data.items"},
+		{name: "iterated elements are collected into an array", filter: ".data.items[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ir, err := ParseStringWithOptions(jsonStr, Options{Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("ParseStringWithOptions() error = %v, wantErr nil", err)
+			}
+			if !ir.RootIsArray {
+				t.Errorf("ParseStringWithOptions() RootIsArray = false, want true")
+			}
+			if !reflect.DeepEqual(ir.Root, expectedItems) {
+				t.Errorf("ParseStringWithOptions() root = %#v, want %#v", ir.Root, expectedItems)
+			}
+		})
+	}
+
+	if _, err := ParseStringWithOptions(jsonStr, Options{Filter: ".data.items["}); err == nil || !strings.Contains(err.Error(), "invalid filter") {
+		t.Errorf("ParseStringWithOptions() with a malformed filter, err = %v, want invalid filter error", err)
+	}
+	if _, err := ParseStringWithOptions(jsonStr, Options{Filter: "empty"}); err == nil || !strings.Contains(err.Error(), "produced no output") {
+		t.Errorf("ParseStringWithOptions() with a filter producing nothing, err = %v, want no output error", err)
+	}
+}
+
+func TestParse_AllowTrailingData(t *testing.T) {
+	jsonStr := `{"id": 1}{"id": 2, "ok": true}` + "\n" + `{"id": 3}`
+
+	if _, err := ParseString(jsonStr); err == nil {
+		t.Fatalf("ParseString() with concatenated values, err = nil, want error")
+	}
+
+	ir, err := ParseStringWithOptions(jsonStr, Options{AllowTrailingData: true})
+	if err != nil {
+		t.Fatalf("ParseStringWithOptions() error = %v, wantErr nil", err)
+	}
+	if !ir.RootIsArray {
+		t.Errorf("ParseStringWithOptions() RootIsArray = false, want true")
+	}
+	expected := models.JSONArray{
+		models.JSONObject{"id": json.Number("1")},
+		models.JSONObject{"id": json.Number("2"), "ok": true},
+		models.JSONObject{"id": json.Number("3")},
+	}
+	if !reflect.DeepEqual(ir.Root, expected) {
+		t.Errorf("ParseStringWithOptions() Root = %#v, want %#v", ir.Root, expected)
+	}
+
+	// A single value is unchanged
+	ir, err = ParseStringWithOptions(`{"id": 1}`, Options{AllowTrailingData: true})
+	if err != nil {
+		t.Fatalf("ParseStringWithOptions() error = %v, wantErr nil", err)
+	}
+	if ir.RootIsArray {
+		t.Errorf("ParseStringWithOptions() single value RootIsArray = true, want false")
+	}
+
+	if _, err := ParseStringWithOptions(`{"id": 1}{"id":`, Options{AllowTrailingData: true}); err == nil {
+		t.Errorf("ParseStringWithOptions() with a truncated second value, err = nil, want error")
+	}
+}
+
+func TestParse_AllowNonStandardNumbers(t *testing.T) {
+	jsonStr := `{"x": NaN, "y": [1.5, -Infinity, Infinity], "s": "NaN \" Infinity"}`
+
+	_, err := ParseString(jsonStr)
+	if err == nil {
+		t.Fatalf("ParseString() with NaN, err = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "NaN and Infinity") {
+		t.Errorf("ParseString() error = %q, want a hint about NaN and Infinity", err)
+	}
+
+	ir, err := ParseStringWithOptions(jsonStr, Options{AllowNonStandardNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseStringWithOptions() error = %v, wantErr nil", err)
+	}
+	expected := models.JSONObject{
+		"x": json.Number("NaN"),
+		"y": models.JSONArray{json.Number("1.5"), json.Number("-Infinity"), json.Number("Infinity")},
+		"s": `NaN " Infinity`,
+	}
+	if !reflect.DeepEqual(ir.Root, expected) {
+		t.Errorf("ParseStringWithOptions() Root = %#v, want %#v", ir.Root, expected)
+	}
+	if want := []string{"x", "y[1]", "y[2]"}; !reflect.DeepEqual(ir.NonStandardNumbers, want) {
+		t.Errorf("ParseStringWithOptions() NonStandardNumbers = %v, want %v", ir.NonStandardNumbers, want)
+	}
+}
@@ -4,11 +4,16 @@ package schema
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/mcncl/gotyper/internal/config"
 	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
 )
 
 // SchemaType handles JSON Schema type field which can be string or array of strings
@@ -120,10 +125,23 @@ type Schema struct {
 
 	// Enum
 	Enum []interface{} `json:"enum,omitempty"`
+	// EnumVarNames names the enum members, following the x-enum-varnames extension
+	EnumVarNames []string `json:"x-enum-varnames,omitempty"`
+
+	// Const (draft-06+) fixes a property to a single value
+	Const interface{} `json:"const,omitempty"`
 
 	// Nullable (JSON Schema draft-07+)
 	Nullable bool `json:"nullable,omitempty"`
 
+	// Deprecated (draft 2019-09+) marks a property or type that should no longer be used
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Conditional subschemas (draft-07+)
+	If   *Schema `json:"if,omitempty"`
+	Then *Schema `json:"then,omitempty"`
+	Else *Schema `json:"else,omitempty"`
+
 	// Composition (basic support)
 	AllOf []*Schema `json:"allOf,omitempty"`
 	AnyOf []*Schema `json:"anyOf,omitempty"`
@@ -133,13 +151,26 @@ type Schema struct {
 	Definitions map[string]*Schema `json:"definitions,omitempty"`
 	Defs        map[string]*Schema `json:"$defs,omitempty"` // JSON Schema draft 2019-09+
 
+	// Components holds the reusable schemas of an OpenAPI 3 document
+	Components *Components `json:"components,omitempty"`
+
 	// Default value
 	Default interface{} `json:"default,omitempty"`
 
 	// Examples
 	Examples []interface{} `json:"examples,omitempty"`
+	// Example is OpenAPI's single-value form of Examples
+	Example interface{} `json:"example,omitempty"`
+}
+
+// Components is the components object of an OpenAPI 3 document. Only its schemas are used.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
 }
 
+// refPrefixes are the local $ref prefixes that point into the converter's definitions
+var refPrefixes = []string{"#/definitions/", "#/$defs/", "#/components/schemas/"}
+
 // ParseFile reads and parses a JSON Schema from a file
 func ParseFile(path string) (*Schema, error) {
 	data, err := os.ReadFile(path)
@@ -168,15 +199,24 @@ func ParseString(s string) (*Schema, error) {
 // Converter converts JSON Schema to Go struct definitions
 type Converter struct {
 	schema       *Schema
+	config       *config.Config
 	structs      []models.StructDef
+	constants    []models.ConstantDef
+	namedTypes   []models.NamedType
 	imports      map[string]struct{}
 	structNames  map[string]int             // Track used names to avoid collisions
 	definitions  map[string]*Schema         // Merged definitions for $ref resolution
 	resolvedRefs map[string]models.TypeInfo // Cache for already resolved $refs
+	resolving    map[string]string          // $refs being converted, with the struct name each will get
 }
 
 // NewConverter creates a new schema converter
 func NewConverter(schema *Schema) *Converter {
+	return NewConverterWithConfig(schema, config.NewConfig())
+}
+
+// NewConverterWithConfig creates a new schema converter with custom configuration
+func NewConverterWithConfig(schema *Schema, cfg *config.Config) *Converter {
 	// Merge definitions and $defs
 	definitions := make(map[string]*Schema)
 	for k, v := range schema.Definitions {
@@ -185,14 +225,21 @@ func NewConverter(schema *Schema) *Converter {
 	for k, v := range schema.Defs {
 		definitions[k] = v
 	}
+	if schema.Components != nil {
+		for k, v := range schema.Components.Schemas {
+			definitions[k] = v
+		}
+	}
 
 	return &Converter{
 		schema:       schema,
+		config:       cfg,
 		structs:      make([]models.StructDef, 0),
 		imports:      make(map[string]struct{}),
 		structNames:  make(map[string]int),
 		definitions:  definitions,
 		resolvedRefs: make(map[string]models.TypeInfo),
+		resolving:    make(map[string]string),
 	}
 }
 
@@ -200,6 +247,9 @@ func NewConverter(schema *Schema) *Converter {
 func (c *Converter) Convert(rootName string) (models.AnalysisResult, error) {
 	if rootName == "" {
 		rootName = c.schema.Title
+		if rootName == "" {
+			rootName = nameFromID(c.schema.ID)
+		}
 		if rootName == "" {
 			rootName = "RootType"
 		}
@@ -209,17 +259,61 @@ func (c *Converter) Convert(rootName string) (models.AnalysisResult, error) {
 	rootName = toPascalCase(rootName)
 
 	// Convert the root schema
-	_, err := c.convertSchema(c.schema, rootName, true)
+	rootType, err := c.convertSchema(c.schema, rootName, true)
 	if err != nil {
 		return models.AnalysisResult{}, fmt.Errorf("failed to convert schema: %w", err)
 	}
 
 	return models.AnalysisResult{
-		Structs: c.structs,
-		Imports: c.imports,
+		Structs:    models.DiscoveryOrder(c.structs, rootType.ReferencedStruct()),
+		Imports:    c.imports,
+		Constants:  c.constants,
+		NamedTypes: c.namedTypes,
+		Root:       &rootType,
+	}, nil
+}
+
+// ConvertComponents converts each schema under components.schemas of an OpenAPI 3
+// document, in name order. Object schemas become structs named after their component
+// and enums become named types; other components are only inlined where referenced.
+func (c *Converter) ConvertComponents() (models.AnalysisResult, error) {
+	if c.schema.Components == nil || len(c.schema.Components.Schemas) == 0 {
+		return models.AnalysisResult{}, fmt.Errorf("document has no components.schemas")
+	}
+
+	names := make([]string, 0, len(c.schema.Components.Schemas))
+	for name := range c.schema.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := c.resolveRef("#/components/schemas/"+name, name); err != nil {
+			return models.AnalysisResult{}, fmt.Errorf("failed to convert component %s: %w", name, err)
+		}
+	}
+
+	return models.AnalysisResult{
+		Structs:    c.structs,
+		Imports:    c.imports,
+		Constants:  c.constants,
+		NamedTypes: c.namedTypes,
 	}, nil
 }
 
+// nameFromID derives a type name from the last segment of a schema's $id, dropping any
+// query, fragment and file extensions: https://ex.com/schemas/user.schema.json gives "user"
+func nameFromID(id string) string {
+	id, _, _ = strings.Cut(id, "#")
+	id, _, _ = strings.Cut(id, "?")
+	segments := strings.FieldsFunc(id, func(r rune) bool { return r == '/' || r == ':' })
+	if len(segments) == 0 {
+		return ""
+	}
+	name, _, _ := strings.Cut(segments[len(segments)-1], ".")
+	return name
+}
+
 // convertSchema recursively converts a schema to Go types
 func (c *Converter) convertSchema(schema *Schema, suggestedName string, isRoot bool) (models.TypeInfo, error) {
 	// Handle $ref
@@ -237,7 +331,7 @@ func (c *Converter) convertSchema(schema *Schema, suggestedName string, isRoot b
 	schemaType := schema.Type.Primary()
 	if schemaType == "" {
 		// Infer type from properties
-		if len(schema.Properties) > 0 {
+		if len(schema.Properties) > 0 || hasConditionalProperties(schema) || isMapSchema(schema) {
 			schemaType = "object"
 		} else if schema.Items != nil {
 			schemaType = "array"
@@ -254,8 +348,16 @@ func (c *Converter) convertSchema(schema *Schema, suggestedName string, isRoot b
 		}
 	}
 
+	// String and integer enums become named types with a constant per value
+	if typeInfo, ok := c.convertEnum(schema, schemaType, suggestedName); ok {
+		return typeInfo, nil
+	}
+
 	switch schemaType {
 	case "object":
+		if isMapSchema(schema) && !isRoot {
+			return c.convertMap(schema, suggestedName)
+		}
 		return c.convertObject(schema, suggestedName, isRoot)
 	case "array":
 		return c.convertArray(schema, suggestedName)
@@ -286,18 +388,21 @@ func (c *Converter) convertObject(schema *Schema, structName string, isRoot bool
 		requiredSet[r] = true
 	}
 
+	// Properties only defined in then/else branches are kept as optional fields
+	properties, conditionNotes := c.objectProperties(schema)
+
 	// Convert properties to fields
-	fields := make([]models.FieldInfo, 0, len(schema.Properties))
+	fields := make([]models.FieldInfo, 0, len(properties))
 
 	// Sort property names for deterministic output
-	propNames := make([]string, 0, len(schema.Properties))
-	for name := range schema.Properties {
+	propNames := make([]string, 0, len(properties))
+	for name := range properties {
 		propNames = append(propNames, name)
 	}
 	sort.Strings(propNames)
 
 	for _, propName := range propNames {
-		propSchema := schema.Properties[propName]
+		propSchema := properties[propName]
 
 		// Generate field name
 		goFieldName := toPascalCase(propName)
@@ -311,29 +416,70 @@ func (c *Converter) convertObject(schema *Schema, structName string, isRoot bool
 
 		// Determine if field is optional (pointer)
 		// Field is pointer if: not required, OR explicitly nullable, OR type includes "null"
+		// Maps are left as values: a nil map already encodes as null
 		isRequired := requiredSet[propName]
-		if !isRequired || propSchema.Nullable || propSchema.Type.IsNullable() {
+		if (!isRequired || propSchema.Nullable || propSchema.Type.IsNullable() || enumAllowsNull(propSchema)) && typeInfo.Kind != models.Map {
 			typeInfo.IsPointer = true
 		}
 
+		// A const property gets a package-level constant holding its value
+		var constName, constValue string
+		if propSchema.Const != nil {
+			if typeInfo.Kind == models.Interface {
+				typeInfo = constTypeInfo(propSchema.Const, typeInfo.IsPointer)
+			}
+			if literal, ok := constLiteral(propSchema.Const, typeInfo); ok {
+				constName = c.generateUniqueName(finalName + goFieldName)
+				constValue = literal
+				c.constants = append(c.constants, models.ConstantDef{
+					Name:    constName,
+					Value:   literal,
+					Comment: fmt.Sprintf("%s is the fixed value of %s.%s.", constName, finalName, goFieldName),
+				})
+			}
+		}
+
 		// Generate tags
 		jsonTag, tags, comment := c.generateFieldTags(propName, propSchema, typeInfo, isRequired)
+		if constName != "" {
+			comment = appendNote(comment, fmt.Sprintf("Always %s (%s)", constValue, constName))
+		}
+		if note, ok := conditionNotes[propName]; ok {
+			comment = appendNote(comment, note)
+		}
+		if example, ok := firstExample(propSchema); ok && c.config.Schema.ExampleComments {
+			comment = appendNote(comment, "Example: "+example)
+		}
+
+		// Formats decoded into their own types, such as date-time, need no checking
+		var format string
+		if typeInfo.Kind == models.String {
+			format = propSchema.Format
+		}
 
 		fields = append(fields, models.FieldInfo{
-			JSONKey: propName,
-			GoName:  goFieldName,
-			GoType:  typeInfo,
-			JSONTag: jsonTag,
-			Tags:    tags,
-			Comment: comment,
+			JSONKey:    propName,
+			GoName:     goFieldName,
+			GoType:     typeInfo,
+			JSONTag:    jsonTag,
+			Tags:       tags,
+			Comment:    comment,
+			Deprecated: propSchema.Deprecated,
+			Default:    propSchema.Default,
+			Format:     format,
 		})
 	}
 
 	// Create struct definition
 	structDef := models.StructDef{
-		Name:   finalName,
-		Fields: fields,
-		IsRoot: isRoot,
+		Name:       finalName,
+		Fields:     fields,
+		IsRoot:     isRoot,
+		Comment:    schema.Description,
+		Deprecated: schema.Deprecated,
+	}
+	if c.config.Schema.EnforceAdditionalProperties && schema.AdditionalProperties != nil && !schema.AdditionalProperties.Allowed {
+		structDef.DisallowUnknownFields = true
 	}
 	c.structs = append(c.structs, structDef)
 
@@ -344,7 +490,33 @@ func (c *Converter) convertObject(schema *Schema, structName string, isRoot bool
 	}, nil
 }
 
-// convertArray converts an array schema to a Go slice
+// appendNote adds a sentence to a field comment
+func appendNote(comment, note string) string {
+	if comment == "" {
+		return note
+	}
+	return strings.TrimSuffix(comment, ".") + ". " + note
+}
+
+// firstExample renders the first example of a schema as compact JSON,
+// preferring "examples" over OpenAPI's "example"
+func firstExample(schema *Schema) (string, bool) {
+	example := schema.Example
+	if len(schema.Examples) > 0 {
+		example = schema.Examples[0]
+	}
+	if example == nil {
+		return "", false
+	}
+	data, err := json.Marshal(example)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// convertArray converts an array schema to a Go slice, or with schema.fixed_size_arrays
+// an array of exactly N items (minItems and maxItems both N) to a Go array [N]T
 func (c *Converter) convertArray(schema *Schema, suggestedName string) (models.TypeInfo, error) {
 	// Determine element type
 	var elementType models.TypeInfo
@@ -364,10 +536,25 @@ func (c *Converter) convertArray(schema *Schema, suggestedName string) (models.T
 
 	// Build slice type
 	sliceName := "[]" + elementType.Name
-	if elementType.Kind == models.Struct {
+	switch {
+	case elementType.Kind == models.Struct:
 		// Use pointer elements for struct slices
 		sliceName = "[]*" + elementType.Name
 		elementType.IsPointer = true
+	case schema.Items != nil && elementAllowsNull(schema.Items, elementType):
+		// Elements that may be null, e.g. items of type ["integer", "null"], are pointers
+		sliceName = "[]*" + elementType.Name
+		elementType.IsPointer = true
+	}
+
+	if n := fixedLength(schema); n > 0 && c.config.Schema.FixedSizeArrays {
+		// An array can't be nil, so it is only a pointer when the property is optional
+		return models.TypeInfo{
+			Kind:             models.Slice,
+			Name:             fmt.Sprintf("[%d]%s", n, strings.TrimPrefix(sliceName, "[]")),
+			SliceElementType: &elementType,
+			ArrayLength:      n,
+		}, nil
 	}
 
 	return models.TypeInfo{
@@ -378,6 +565,51 @@ func (c *Converter) convertArray(schema *Schema, suggestedName string) (models.T
 	}, nil
 }
 
+// fixedLength returns N for an array schema with minItems and maxItems both N, or 0
+func fixedLength(schema *Schema) int {
+	if schema.MinItems == nil || schema.MaxItems == nil || *schema.MinItems != *schema.MaxItems {
+		return 0
+	}
+	return *schema.MinItems
+}
+
+// elementAllowsNull reports whether an items schema admits null for an element type
+// that can't already hold it; interface{}, slices and maps decode null as nil
+func elementAllowsNull(items *Schema, elementType models.TypeInfo) bool {
+	switch elementType.Kind {
+	case models.Interface, models.Slice, models.Map:
+		return false
+	}
+	return items.Nullable || items.Type.IsNullable() || enumAllowsNull(items)
+}
+
+// isMapSchema reports whether an object schema only describes its values, through an
+// additionalProperties schema, so it is a map[string]T rather than a struct
+func isMapSchema(schema *Schema) bool {
+	return len(schema.Properties) == 0 && !hasConditionalProperties(schema) &&
+		schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil
+}
+
+// convertMap converts an object schema with arbitrary keys to a Go map. Struct values
+// are pointers when types.map_value_pointers is set.
+func (c *Converter) convertMap(schema *Schema, suggestedName string) (models.TypeInfo, error) {
+	valueName := singularize(suggestedName)
+	if valueName == suggestedName {
+		valueName += "Value"
+	}
+	valueType, err := c.convertSchema(schema.AdditionalProperties.Schema, valueName, false)
+	if err != nil {
+		return models.TypeInfo{}, fmt.Errorf("failed to convert additionalProperties: %w", err)
+	}
+
+	name := "map[string]" + valueType.Name
+	if valueType.Kind == models.Struct && c.config.Types.MapValuePointers {
+		valueType.IsPointer = true
+		name = "map[string]*" + valueType.Name
+	}
+	return models.TypeInfo{Kind: models.Map, Name: name, MapValueType: &valueType}, nil
+}
+
 // convertString converts a string schema to Go type
 func (c *Converter) convertString(schema *Schema) models.TypeInfo {
 	// Check format for special types
@@ -403,6 +635,151 @@ func (c *Converter) convertNumber(schema *Schema) models.TypeInfo {
 	return models.TypeInfo{Kind: models.Float, Name: "float64"}
 }
 
+// convertEnum converts a string or integer enum into a named type with a constant
+// for each value. It reports false for other enums, which keep their plain type.
+func (c *Converter) convertEnum(schema *Schema, schemaType, suggestedName string) (models.TypeInfo, bool) {
+	var values []interface{}
+	for _, value := range schema.Enum {
+		if value != nil {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return models.TypeInfo{}, false
+	}
+
+	// Without a declared type the first member decides between a string and an integer enum
+	enumKinds := map[string]models.GoTypeKind{"string": models.String, "integer": models.Int}
+	underlying := constTypeInfo(values[0], false)
+	if kind, ok := enumKinds[schemaType]; schemaType != "" && (!ok || kind != underlying.Kind) {
+		return models.TypeInfo{}, false
+	}
+	if underlying.Kind != models.String && underlying.Kind != models.Int {
+		return models.TypeInfo{}, false
+	}
+
+	// Null members make the field a pointer instead; every other member needs a Go literal
+	literals := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		literal, ok := constLiteral(value, underlying)
+		if !ok {
+			return models.TypeInfo{}, false
+		}
+		if !seen[literal] {
+			seen[literal] = true
+			literals = append(literals, literal)
+		}
+	}
+
+	// x-enum-varnames only applies when it names every member
+	varNames := schema.EnumVarNames
+	if len(varNames) != len(schema.Enum) || len(literals) != len(schema.Enum) {
+		varNames = nil
+	}
+
+	typeName := c.generateUniqueName(suggestedName)
+	namedType := models.NamedType{
+		Name:    typeName,
+		Type:    underlying,
+		Comment: schema.Description,
+	}
+	if namedType.Comment == "" {
+		namedType.Comment = fmt.Sprintf("%s is a JSON Schema enum; its values are declared below.", typeName)
+	}
+	for i, literal := range literals {
+		label := literal
+		if underlying.Kind == models.String {
+			label, _ = strconv.Unquote(literal)
+		}
+		if varNames != nil {
+			label = varNames[i]
+		}
+
+		suffix := identifierSuffix(label)
+		if underlying.Kind == models.Int && varNames == nil {
+			suffix = strings.Replace(literal, "-", "Minus", 1)
+		}
+		if suffix == "" {
+			suffix = fmt.Sprintf("Value%d", i+1)
+		}
+		namedType.Enum = append(namedType.Enum, models.EnumValue{
+			Name:  c.generateUniqueName(typeName + suffix),
+			Label: label,
+			Value: literal,
+		})
+	}
+	c.namedTypes = append(c.namedTypes, namedType)
+
+	return models.TypeInfo{Kind: underlying.Kind, Name: typeName}, true
+}
+
+// enumAllowsNull reports whether null is one of a schema's enum members
+func enumAllowsNull(schema *Schema) bool {
+	for _, value := range schema.Enum {
+		if value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierSuffix converts an enum label into PascalCase, dropping characters
+// that can't appear in a Go identifier
+func identifierSuffix(label string) string {
+	if label == "" {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, toPascalCase(label))
+}
+
+// constTypeInfo infers a Go type from a const value when the schema declares no type
+func constTypeInfo(value interface{}, isPointer bool) models.TypeInfo {
+	switch v := value.(type) {
+	case string:
+		return models.TypeInfo{Kind: models.String, Name: "string", IsPointer: isPointer}
+	case bool:
+		return models.TypeInfo{Kind: models.Bool, Name: "bool", IsPointer: isPointer}
+	case float64:
+		if v == math.Trunc(v) {
+			return models.TypeInfo{Kind: models.Int, Name: "int64", IsPointer: isPointer}
+		}
+		return models.TypeInfo{Kind: models.Float, Name: "float64", IsPointer: isPointer}
+	default:
+		return models.TypeInfo{Kind: models.Interface, Name: "interface{}", IsPointer: isPointer}
+	}
+}
+
+// constLiteral renders a const value as a Go literal of the field's type.
+// It reports false when the value can't be a Go constant of that type (objects, arrays, null, or mismatches).
+func constLiteral(value interface{}, typeInfo models.TypeInfo) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		if typeInfo.Kind == models.String {
+			return strconv.Quote(v), true
+		}
+	case bool:
+		if typeInfo.Kind == models.Bool {
+			return strconv.FormatBool(v), true
+		}
+	case float64:
+		switch typeInfo.Kind {
+		case models.Int:
+			if v == math.Trunc(v) {
+				return strconv.FormatFloat(v, 'f', -1, 64), true
+			}
+		case models.Float:
+			return strconv.FormatFloat(v, 'g', -1, 64), true
+		}
+	}
+	return "", false
+}
+
 // resolveRef resolves a $ref to its schema
 func (c *Converter) resolveRef(ref string, suggestedName string) (models.TypeInfo, error) {
 	// Check cache first to avoid duplicate struct generation
@@ -410,24 +787,22 @@ func (c *Converter) resolveRef(ref string, suggestedName string) (models.TypeInf
 		return cached, nil
 	}
 
-	// Handle local references like "#/definitions/User" or "#/$defs/User"
-	if strings.HasPrefix(ref, "#/definitions/") {
-		defName := strings.TrimPrefix(ref, "#/definitions/")
-		if defSchema, ok := c.definitions[defName]; ok {
-			typeInfo, err := c.convertSchema(defSchema, toPascalCase(defName), false)
-			if err != nil {
-				return models.TypeInfo{}, err
-			}
-			c.resolvedRefs[ref] = typeInfo // Cache the result
-			return typeInfo, nil
+	// Handle local references like "#/definitions/User", "#/$defs/User" or
+	// "#/components/schemas/User"
+	for _, prefix := range refPrefixes {
+		if !strings.HasPrefix(ref, prefix) {
+			continue
 		}
-		return models.TypeInfo{}, fmt.Errorf("unresolved $ref: %s", ref)
-	}
-
-	if strings.HasPrefix(ref, "#/$defs/") {
-		defName := strings.TrimPrefix(ref, "#/$defs/")
+		// A definition that refers to itself, directly or through others, points back
+		// at the struct being built
+		if name, ok := c.resolving[ref]; ok {
+			return models.TypeInfo{Kind: models.Struct, Name: name, StructName: name, IsPointer: true}, nil
+		}
+		defName := strings.TrimPrefix(ref, prefix)
 		if defSchema, ok := c.definitions[defName]; ok {
+			c.resolving[ref] = c.nextUniqueName(toPascalCase(defName))
 			typeInfo, err := c.convertSchema(defSchema, toPascalCase(defName), false)
+			delete(c.resolving, ref)
 			if err != nil {
 				return models.TypeInfo{}, err
 			}
@@ -450,20 +825,7 @@ func (c *Converter) mergeAllOf(schemas []*Schema) *Schema {
 
 	for _, s := range schemas {
 		// Resolve refs first
-		resolved := s
-		if s.Ref != "" {
-			if strings.HasPrefix(s.Ref, "#/definitions/") {
-				defName := strings.TrimPrefix(s.Ref, "#/definitions/")
-				if defSchema, ok := c.definitions[defName]; ok {
-					resolved = defSchema
-				}
-			} else if strings.HasPrefix(s.Ref, "#/$defs/") {
-				defName := strings.TrimPrefix(s.Ref, "#/$defs/")
-				if defSchema, ok := c.definitions[defName]; ok {
-					resolved = defSchema
-				}
-			}
-		}
+		resolved := c.resolveLocal(s)
 
 		// Merge properties
 		for k, v := range resolved.Properties {
@@ -486,17 +848,109 @@ func (c *Converter) mergeAllOf(schemas []*Schema) *Schema {
 	return merged
 }
 
+// resolveLocal returns the definition a local $ref points to, or the schema itself
+func (c *Converter) resolveLocal(s *Schema) *Schema {
+	for _, prefix := range refPrefixes {
+		if strings.HasPrefix(s.Ref, prefix) {
+			if defSchema, ok := c.definitions[strings.TrimPrefix(s.Ref, prefix)]; ok {
+				return defSchema
+			}
+		}
+	}
+	return s
+}
+
+// hasConditionalProperties reports whether a then or else branch declares properties
+func hasConditionalProperties(schema *Schema) bool {
+	for _, branch := range []*Schema{schema.Then, schema.Else} {
+		if branch != nil && len(branch.Properties) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// objectProperties returns an object's properties together with those only declared
+// in its then/else branches. Conditional properties are never required, and each
+// gets a note describing when it applies; properties declared directly win.
+func (c *Converter) objectProperties(schema *Schema) (map[string]*Schema, map[string]string) {
+	if !hasConditionalProperties(schema) {
+		return schema.Properties, nil
+	}
+
+	properties := make(map[string]*Schema, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		properties[name] = prop
+	}
+	notes := make(map[string]string)
+
+	condition := describeCondition(schema.If)
+	branches := []struct {
+		schema *Schema
+		note   string
+	}{
+		{schema.Then, "Only when " + condition},
+		{schema.Else, "Only when not " + condition},
+	}
+	for _, branch := range branches {
+		if branch.schema == nil {
+			continue
+		}
+		resolved := c.resolveLocal(branch.schema)
+		for name, prop := range resolved.Properties {
+			if _, exists := properties[name]; exists {
+				continue
+			}
+			properties[name] = prop
+			notes[name] = branch.note
+		}
+	}
+	return properties, notes
+}
+
+// describeCondition summarizes an if schema for field comments. Conditions on
+// const or single-value enum properties are spelled out, e.g. (country = "US").
+func describeCondition(ifSchema *Schema) string {
+	if ifSchema == nil {
+		return "(if condition)"
+	}
+
+	names := make([]string, 0, len(ifSchema.Properties))
+	for name := range ifSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		prop := ifSchema.Properties[name]
+		value := prop.Const
+		if value == nil && len(prop.Enum) == 1 {
+			value = prop.Enum[0]
+		}
+		data, err := json.Marshal(value)
+		if value == nil || err != nil {
+			return "(if condition)"
+		}
+		parts = append(parts, fmt.Sprintf("%s = %s", name, data))
+	}
+	if len(parts) == 0 {
+		return "(if condition)"
+	}
+	return "(" + strings.Join(parts, " and ") + ")"
+}
+
 // generateFieldTags creates tags for a field based on schema
 func (c *Converter) generateFieldTags(jsonKey string, schema *Schema, typeInfo models.TypeInfo, isRequired bool) (string, map[string]string, string) {
-	tags := make(map[string]string)
+	fieldTags := make(map[string]string)
 	var comment string
 
 	// JSON tag
-	jsonTagValue := jsonKey
-	if typeInfo.IsPointer {
+	jsonTagValue := c.config.TagKey(jsonKey)
+	if typeInfo.IsPointer || (typeInfo.Kind == models.Map && !isRequired) {
 		jsonTagValue += ",omitempty"
 	}
-	tags["json"] = jsonTagValue
+	fieldTags["json"] = jsonTagValue
 
 	// Build validation tag parts
 	var validationParts []string
@@ -536,13 +990,11 @@ func (c *Converter) generateFieldTags(jsonKey string, schema *Schema, typeInfo m
 	}
 
 	// Build final tag string
-	var tagParts []string
-	tagParts = append(tagParts, fmt.Sprintf("json:\"%s\"", jsonTagValue))
-
+	tagParts := []tags.Tag{{Key: "json", Value: jsonTagValue}}
 	if len(validationParts) > 0 {
 		validateTag := strings.Join(validationParts, ",")
-		tags["validate"] = validateTag
-		tagParts = append(tagParts, fmt.Sprintf("validate:\"%s\"", validateTag))
+		fieldTags["validate"] = validateTag
+		tagParts = append(tagParts, tags.Tag{Key: "validate", Value: validateTag})
 	}
 
 	// Use description as comment
@@ -550,19 +1002,24 @@ func (c *Converter) generateFieldTags(jsonKey string, schema *Schema, typeInfo m
 		comment = schema.Description
 	}
 
-	finalTag := "`" + strings.Join(tagParts, " ") + "`"
-	return finalTag, tags, comment
+	return tags.Build(tagParts, c.config.TagOrder()), fieldTags, comment
 }
 
 // generateUniqueName ensures struct names are unique
 func (c *Converter) generateUniqueName(baseName string) string {
+	name := c.nextUniqueName(baseName)
+	c.structNames[baseName]++
+	return name
+}
+
+// nextUniqueName returns the name generateUniqueName will give baseName next, without
+// reserving it
+func (c *Converter) nextUniqueName(baseName string) string {
 	name := baseName
-	count := c.structNames[baseName]
-	if count > 0 {
+	if count := c.structNames[baseName]; count > 0 {
 		name = fmt.Sprintf("%s%d", baseName, count)
 	}
-	c.structNames[baseName] = count + 1
-	return name
+	return c.config.LimitNameLength(name)
 }
 
 // toPascalCase converts a string to PascalCase
@@ -3,6 +3,7 @@ package schema
 import (
 	"testing"
 
+	"github.com/mcncl/gotyper/internal/config"
 	"github.com/mcncl/gotyper/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -468,3 +469,540 @@ func TestConvertNullableField(t *testing.T) {
 	// Nullable field should be pointer (even if not in required list already)
 	assert.True(t, fieldMap["name"].GoType.IsPointer)
 }
+
+func TestConvertNullableArrayItems(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["counts", "names", "tags"],
+		"properties": {
+			"counts": {"type": "array", "items": {"type": ["integer", "null"]}},
+			"names": {"type": "array", "items": {"type": "string", "nullable": true}},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Stats")
+	require.NoError(t, err)
+
+	fieldMap := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fieldMap[f.JSONKey] = f
+	}
+
+	assert.Equal(t, "[]*int64", fieldMap["counts"].GoType.Name)
+	require.NotNil(t, fieldMap["counts"].GoType.SliceElementType)
+	assert.True(t, fieldMap["counts"].GoType.SliceElementType.IsPointer)
+	assert.Equal(t, "[]*string", fieldMap["names"].GoType.Name)
+
+	// Elements that can't be null stay values
+	assert.Equal(t, "[]string", fieldMap["tags"].GoType.Name)
+	assert.False(t, fieldMap["tags"].GoType.SliceElementType.IsPointer)
+}
+
+func TestConvertAdditionalPropertiesFalse(t *testing.T) {
+	input := `{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string"},
+			"address": {
+				"type": "object",
+				"properties": {"city": {"type": "string"}}
+			},
+			"labels": {
+				"type": "object",
+				"additionalProperties": true,
+				"properties": {"team": {"type": "string"}}
+			}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	// Disabled by default
+	result, err := NewConverter(schema).Convert("Person")
+	require.NoError(t, err)
+	for _, s := range result.Structs {
+		assert.False(t, s.DisallowUnknownFields, s.Name)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Schema.EnforceAdditionalProperties = true
+	result, err = NewConverterWithConfig(schema, cfg).Convert("Person")
+	require.NoError(t, err)
+
+	structMap := make(map[string]models.StructDef)
+	for _, s := range result.Structs {
+		structMap[s.Name] = s
+	}
+
+	// Only the object that forbids additional properties is strict
+	assert.True(t, structMap["Person"].DisallowUnknownFields)
+	assert.False(t, structMap["PersonAddress"].DisallowUnknownFields)
+	assert.False(t, structMap["PersonLabels"].DisallowUnknownFields)
+}
+
+func TestParseConst(t *testing.T) {
+	schema, err := ParseString(`{"properties": {"kind": {"type": "string", "const": "person"}, "version": {"const": 2}}}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "person", schema.Properties["kind"].Const)
+	assert.Equal(t, float64(2), schema.Properties["version"].Const)
+	assert.Nil(t, schema.Const)
+}
+
+func TestConvertConst(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["kind"],
+		"properties": {
+			"kind": {"type": "string", "const": "person", "description": "Discriminator"},
+			"version": {"const": 2},
+			"meta": {"const": {"a": 1}}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Person")
+	require.NoError(t, err)
+
+	fieldMap := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fieldMap[f.JSONKey] = f
+	}
+
+	assert.Equal(t, "string", fieldMap["kind"].GoType.Name)
+	assert.Equal(t, `Discriminator. Always "person" (PersonKind)`, fieldMap["kind"].Comment)
+
+	// Untyped const infers its Go type from the value
+	assert.Equal(t, "int64", fieldMap["version"].GoType.Name)
+
+	// Objects can't be Go constants, so no constant is emitted for them
+	assert.Equal(t, []models.ConstantDef{
+		{Name: "PersonKind", Value: `"person"`, Comment: "PersonKind is the fixed value of Person.Kind."},
+		{Name: "PersonVersion", Value: "2", Comment: "PersonVersion is the fixed value of Person.Version."},
+	}, result.Constants)
+	assert.Empty(t, fieldMap["meta"].Comment)
+}
+
+func TestConvertConstTypeMismatch(t *testing.T) {
+	schema, err := ParseString(`{"type": "object", "properties": {"count": {"type": "integer", "const": "many"}}}`)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Stats")
+	require.NoError(t, err)
+	assert.Empty(t, result.Constants)
+	assert.Equal(t, "int64", result.Structs[0].Fields[0].GoType.Name)
+}
+
+func TestConvertEnum(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["status"],
+		"properties": {
+			"status": {"type": "string", "enum": ["in-progress", "done", null]},
+			"priority": {"type": "integer", "enum": [1, 2, 3], "x-enum-varnames": ["Low", "Medium", "High"]},
+			"level": {"enum": [-1, 0]},
+			"tags": {"type": "array", "items": {"enum": ["a", "b"]}},
+			"ratio": {"type": "number", "enum": [0.5, 1]},
+			"mixed": {"enum": ["a", 1]}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Task")
+	require.NoError(t, err)
+
+	fieldMap := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fieldMap[f.JSONKey] = f
+	}
+
+	assert.Equal(t, "TaskStatus", fieldMap["status"].GoType.Name)
+	assert.True(t, fieldMap["status"].GoType.IsPointer, "null enum member makes the field nullable")
+	assert.Equal(t, "TaskPriority", fieldMap["priority"].GoType.Name)
+	assert.Equal(t, "[]TaskTag", fieldMap["tags"].GoType.Name)
+
+	// Enums that aren't all strings or all integers keep their plain type
+	assert.Equal(t, "float64", fieldMap["ratio"].GoType.Name)
+	assert.Equal(t, "interface{}", fieldMap["mixed"].GoType.Name)
+
+	namedTypes := make(map[string]models.NamedType)
+	for _, namedType := range result.NamedTypes {
+		namedTypes[namedType.Name] = namedType
+	}
+	require.Len(t, namedTypes, 4)
+
+	assert.Equal(t, []models.EnumValue{
+		{Name: "TaskStatusInProgress", Label: "in-progress", Value: `"in-progress"`},
+		{Name: "TaskStatusDone", Label: "done", Value: `"done"`},
+	}, namedTypes["TaskStatus"].Enum)
+	assert.Equal(t, []models.EnumValue{
+		{Name: "TaskPriorityLow", Label: "Low", Value: "1"},
+		{Name: "TaskPriorityMedium", Label: "Medium", Value: "2"},
+		{Name: "TaskPriorityHigh", Label: "High", Value: "3"},
+	}, namedTypes["TaskPriority"].Enum)
+	assert.Equal(t, "int64", namedTypes["TaskLevel"].Type.Name)
+	assert.Equal(t, []models.EnumValue{
+		{Name: "TaskLevelMinus1", Label: "-1", Value: "-1"},
+		{Name: "TaskLevel0", Label: "0", Value: "0"},
+	}, namedTypes["TaskLevel"].Enum)
+	assert.Len(t, namedTypes["TaskTag"].Enum, 2)
+}
+
+func TestConvertExampleComments(t *testing.T) {
+	input := `{
+		"type": "object",
+		"properties": {
+			"email": {"type": "string", "description": "Contact address.", "examples": ["ann@example.com", "bob@example.com"]},
+			"age": {"type": "integer", "example": 42},
+			"tags": {"type": "array", "items": {"type": "string"}, "example": ["a", "b"]},
+			"name": {"type": "string"}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	// Examples are ignored unless enabled
+	result, err := NewConverter(schema).Convert("User")
+	require.NoError(t, err)
+	for _, f := range result.Structs[0].Fields {
+		assert.NotContains(t, f.Comment, "Example", f.JSONKey)
+	}
+
+	cfg := config.NewConfig()
+	cfg.Schema.ExampleComments = true
+	result, err = NewConverterWithConfig(schema, cfg).Convert("User")
+	require.NoError(t, err)
+
+	comments := make(map[string]string)
+	for _, f := range result.Structs[0].Fields {
+		comments[f.JSONKey] = f.Comment
+	}
+	assert.Equal(t, `Contact address. Example: "ann@example.com"`, comments["email"])
+	assert.Equal(t, "Example: 42", comments["age"])
+	assert.Equal(t, `Example: ["a","b"]`, comments["tags"])
+	assert.Empty(t, comments["name"])
+}
+
+func TestConvertIfThenElse(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["country"],
+		"properties": {
+			"country": {"type": "string"},
+			"postal_code": {"type": "string", "description": "Postal code."}
+		},
+		"if": {"properties": {"country": {"const": "US"}}},
+		"then": {
+			"required": ["state"],
+			"properties": {
+				"state": {"type": "string"},
+				"postal_code": {"type": "string", "pattern": "[0-9]{5}"}
+			}
+		},
+		"else": {"$ref": "#/definitions/International"},
+		"definitions": {
+			"International": {"properties": {"province": {"type": "string", "description": "Region"}}}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Address")
+	require.NoError(t, err)
+
+	fieldMap := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fieldMap[f.JSONKey] = f
+	}
+	require.Len(t, fieldMap, 4)
+
+	// then-only property, optional because the branch may not apply
+	require.Contains(t, fieldMap, "state")
+	assert.True(t, fieldMap["state"].GoType.IsPointer)
+	assert.Equal(t, `Only when (country = "US")`, fieldMap["state"].Comment)
+
+	// else branch through a $ref
+	assert.Equal(t, `Region. Only when not (country = "US")`, fieldMap["province"].Comment)
+
+	// Properties declared on the object itself keep their definition
+	assert.Equal(t, "Postal code.", fieldMap["postal_code"].Comment)
+	assert.False(t, fieldMap["country"].GoType.IsPointer)
+}
+
+func TestConvertAdditionalPropertiesMap(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["labels"],
+		"properties": {
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"users": {
+				"type": "object",
+				"additionalProperties": {"type": "object", "properties": {"name": {"type": "string"}}}
+			}
+		}
+	}`
+
+	for _, tt := range []struct {
+		name          string
+		valuePointers bool
+		wantUsers     string
+	}{
+		{name: "values", wantUsers: "map[string]AccountUser"},
+		{name: "pointers", valuePointers: true, wantUsers: "map[string]*AccountUser"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseString(input)
+			require.NoError(t, err)
+			cfg := config.NewConfig()
+			cfg.Types.MapValuePointers = tt.valuePointers
+
+			result, err := NewConverterWithConfig(schema, cfg).Convert("Account")
+			require.NoError(t, err)
+
+			fieldMap := make(map[string]models.FieldInfo)
+			for _, s := range result.Structs {
+				if s.IsRoot {
+					for _, f := range s.Fields {
+						fieldMap[f.JSONKey] = f
+					}
+				}
+			}
+			require.Len(t, fieldMap, 2)
+
+			labels := fieldMap["labels"]
+			assert.Equal(t, models.Map, labels.GoType.Kind)
+			assert.Equal(t, "map[string]string", labels.GoType.Name)
+			assert.False(t, labels.GoType.IsPointer, "maps are never pointers")
+			assert.Equal(t, "`json:\"labels\" validate:\"required\"`", labels.JSONTag)
+
+			users := fieldMap["users"]
+			assert.Equal(t, tt.wantUsers, users.GoType.Name)
+			require.NotNil(t, users.GoType.MapValueType)
+			assert.Equal(t, tt.valuePointers, users.GoType.MapValueType.IsPointer)
+			assert.Equal(t, "`json:\"users,omitempty\"`", users.JSONTag)
+		})
+	}
+}
+
+func TestConvertComponents(t *testing.T) {
+	doc := `{
+		"openapi": "3.0.3",
+		"info": {"title": "Pets", "version": "1.0.0"},
+		"paths": {},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"required": ["name"],
+					"properties": {
+						"name": {"type": "string"},
+						"owner": {"$ref": "#/components/schemas/user"}
+					}
+				},
+				"user": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "integer"}
+					}
+				}
+			}
+		}
+	}`
+
+	s, err := ParseString(doc)
+	require.NoError(t, err)
+	result, err := NewConverter(s).ConvertComponents()
+	require.NoError(t, err)
+
+	// Each component becomes one struct, even when it is also referenced
+	require.Len(t, result.Structs, 2)
+	structs := make(map[string]models.StructDef)
+	for _, structDef := range result.Structs {
+		structs[structDef.Name] = structDef
+	}
+	require.Contains(t, structs, "Pet")
+	require.Contains(t, structs, "User")
+
+	fields := make(map[string]models.FieldInfo)
+	for _, field := range structs["Pet"].Fields {
+		fields[field.JSONKey] = field
+	}
+	assert.Equal(t, "string", fields["name"].GoType.Name)
+	assert.Equal(t, "User", fields["owner"].GoType.ReferencedStruct())
+	assert.Equal(t, "int64", structs["User"].Fields[0].GoType.Name)
+
+	// A plain JSON Schema has no components to convert
+	s, err = ParseString(`{"type": "object", "properties": {"id": {"type": "integer"}}}`)
+	require.NoError(t, err)
+	_, err = NewConverter(s).ConvertComponents()
+	require.Error(t, err)
+}
+
+func TestResolveRefComponents(t *testing.T) {
+	doc := `{
+		"openapi": "3.1.0",
+		"components": {
+			"schemas": {
+				"Address": {
+					"type": "object",
+					"properties": {"city": {"type": "string"}}
+				},
+				"Customer": {
+					"type": "object",
+					"properties": {"address": {"$ref": "#/components/schemas/Address"}}
+				},
+				"Order": {
+					"type": "object",
+					"required": ["shipping"],
+					"properties": {
+						"shipping": {"$ref": "#/components/schemas/Address"},
+						"customer": {"$ref": "#/components/schemas/Customer"}
+					}
+				},
+				"Node": {
+					"type": "object",
+					"required": ["parent"],
+					"properties": {
+						"parent": {"$ref": "#/components/schemas/Node"},
+						"children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}
+					}
+				}
+			}
+		}
+	}`
+
+	s, err := ParseString(doc)
+	require.NoError(t, err)
+	result, err := NewConverter(s).ConvertComponents()
+	require.NoError(t, err)
+
+	structs := make(map[string]models.StructDef)
+	for _, structDef := range result.Structs {
+		structs[structDef.Name] = structDef
+	}
+	assert.Len(t, result.Structs, 4, "the shared Address component is converted once")
+
+	fieldType := func(structName, key string) models.TypeInfo {
+		for _, field := range structs[structName].Fields {
+			if field.JSONKey == key {
+				return field.GoType
+			}
+		}
+		t.Fatalf("%s has no field %s", structName, key)
+		return models.TypeInfo{}
+	}
+	assert.Equal(t, "Address", fieldType("Order", "shipping").StructName)
+	assert.Equal(t, "Address", fieldType("Customer", "address").StructName)
+	assert.Equal(t, "Customer", fieldType("Order", "customer").StructName)
+
+	// A component referring to itself stops at a pointer to its own struct
+	parent := fieldType("Node", "parent")
+	assert.Equal(t, "Node", parent.StructName)
+	assert.True(t, parent.IsPointer)
+	assert.Equal(t, "Node", fieldType("Node", "children").ReferencedStruct())
+}
+
+func TestConvertRootNameFromID(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		rootName string
+		expected string
+	}{
+		{"id only", `{"$id": "https://ex.com/schemas/user.json", "type": "object"}`, "", "User"},
+		{"schema suffix", `{"$id": "https://ex.com/schemas/order-item.schema.json#", "type": "object"}`, "", "OrderItem"},
+		{"urn", `{"$id": "urn:example:invoice", "type": "object"}`, "", "Invoice"},
+		{"title wins", `{"$id": "https://ex.com/user.json", "title": "Account", "type": "object"}`, "", "Account"},
+		{"explicit name wins", `{"$id": "https://ex.com/user.json", "type": "object"}`, "Person", "Person"},
+		{"no title or id", `{"type": "object"}`, "", "RootType"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseString(tt.schema)
+			require.NoError(t, err)
+			result, err := NewConverter(s).Convert(tt.rootName)
+			require.NoError(t, err)
+			require.Len(t, result.Structs, 1)
+			assert.Equal(t, tt.expected, result.Structs[0].Name)
+		})
+	}
+}
+
+func TestConvertDefaults(t *testing.T) {
+	input := `{
+		"type": "object",
+		"properties": {
+			"limit": {"type": "integer", "default": 10},
+			"locale": {"type": "string", "default": "en"},
+			"name": {"type": "string"}
+		}
+	}`
+
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	result, err := NewConverter(schema).Convert("Settings")
+	require.NoError(t, err)
+
+	fieldMap := make(map[string]models.FieldInfo)
+	for _, f := range result.Structs[0].Fields {
+		fieldMap[f.JSONKey] = f
+	}
+
+	assert.Equal(t, float64(10), fieldMap["limit"].Default)
+	assert.Equal(t, "en", fieldMap["locale"].Default)
+	assert.Nil(t, fieldMap["name"].Default)
+}
+
+func TestConvertFixedSizeArrays(t *testing.T) {
+	input := `{
+		"type": "object",
+		"required": ["position"],
+		"properties": {
+			"position": {"type": "array", "items": {"type": "integer"}, "minItems": 3, "maxItems": 3},
+			"color": {"type": "array", "items": {"type": "integer"}, "minItems": 3, "maxItems": 3},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 5}
+		}
+	}`
+	schema, err := ParseString(input)
+	require.NoError(t, err)
+
+	convert := func(fixed bool) map[string]models.FieldInfo {
+		cfg := config.NewConfig()
+		cfg.Schema.FixedSizeArrays = fixed
+		result, err := NewConverterWithConfig(schema, cfg).Convert("Pixel")
+		require.NoError(t, err)
+		fieldMap := make(map[string]models.FieldInfo)
+		for _, f := range result.Structs[0].Fields {
+			fieldMap[f.JSONKey] = f
+		}
+		return fieldMap
+	}
+
+	fields := convert(true)
+	assert.Equal(t, "[3]int64", fields["position"].GoType.Name)
+	assert.Equal(t, 3, fields["position"].GoType.ArrayLength)
+	assert.False(t, fields["position"].GoType.IsPointer)
+	// Optional arrays are pointers, since an array can't be nil
+	assert.Equal(t, "[3]int64", fields["color"].GoType.Name)
+	assert.True(t, fields["color"].GoType.IsPointer)
+	assert.Equal(t, "[]string", fields["tags"].GoType.Name)
+	assert.Zero(t, fields["tags"].GoType.ArrayLength)
+
+	fields = convert(false)
+	assert.Equal(t, "[]int64", fields["position"].GoType.Name)
+	assert.Zero(t, fields["position"].GoType.ArrayLength)
+}
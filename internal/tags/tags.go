@@ -0,0 +1,127 @@
+// Package tags builds Go struct tag strings in a configurable key order
+package tags
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Tag is a single key:"value" pair of a struct tag. Value is unescaped, so a JSON key
+// containing a quote is held as is and escaped again by Build.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Parse splits a struct tag such as `validate:"required" binding:"x"` into its pairs.
+// The tag may be a backquoted or double-quoted literal, as written by Build, or bare.
+// Parsing stops at the first malformed pair.
+func Parse(raw string) []Tag {
+	if unquoted, err := strconv.Unquote(raw); err == nil && strings.HasPrefix(raw, "\"") {
+		raw = unquoted
+	}
+	raw = strings.Trim(raw, "`")
+	var tags []Tag
+	for {
+		raw = strings.TrimLeft(raw, " ")
+		colon := strings.Index(raw, ":")
+		if colon <= 0 || len(raw) < colon+2 || raw[colon+1] != '"' {
+			return tags
+		}
+		key := raw[:colon]
+		if strings.ContainsAny(key, " \"") {
+			return tags
+		}
+
+		// Find the closing quote, skipping escaped characters
+		i := colon + 2
+		for i < len(raw) && raw[i] != '"' {
+			if raw[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(raw) {
+			return tags
+		}
+		value, err := strconv.Unquote(raw[colon+1 : i+1])
+		if err != nil {
+			value = raw[colon+2 : i] // Keep malformed escapes as written
+		}
+		tags = append(tags, Tag{Key: key, Value: value})
+		raw = raw[i+1:]
+	}
+}
+
+// Lookup returns the value of key in a struct tag accepted by Parse
+func Lookup(raw, key string) (string, bool) {
+	for _, tag := range Parse(raw) {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Build renders tags as a struct tag literal. Keys listed in order come first,
+// in that order; the rest follow alphabetically. A later tag with the same key
+// replaces an earlier one. Values are quoted as reflect.StructTag expects, and the
+// tag is backquoted unless a value contains a backquote, which needs a "..." literal.
+func Build(tags []Tag, order []string) string {
+	values := make(map[string]string, len(tags))
+	var keys []string
+	for _, tag := range tags {
+		if _, seen := values[tag.Key]; !seen {
+			keys = append(keys, tag.Key)
+		}
+		values[tag.Key] = tag.Value
+	}
+
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		if _, exists := rank[key]; !exists {
+			rank[key] = i
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		ri, iListed := rank[keys[i]]
+		rj, jListed := rank[keys[j]]
+		switch {
+		case iListed && jListed:
+			return ri < rj
+		case iListed != jListed:
+			return iListed
+		default:
+			return keys[i] < keys[j]
+		}
+	})
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + ":" + strconv.Quote(values[key])
+	}
+	tag := strings.Join(parts, " ")
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// ValidJSONName reports whether encoding/json accepts name as the name in a json tag.
+// It ignores names with other characters, such as quotes, backslashes or commas, and
+// uses the Go field name instead.
+func ValidJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
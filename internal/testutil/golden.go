@@ -0,0 +1,33 @@
+// Package testutil provides helpers shared by the package tests
+package testutil
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites golden files with the current output: go test ./... -update
+var update = flag.Bool("update", false, "rewrite testdata/*.golden files with the current output")
+
+// Golden compares got with testdata/<name>.golden in the calling package's directory.
+// With -update the file is (re)written instead, so an intended output change is
+// reviewed as a diff of the golden file.
+func Golden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run go test with -update to create it")
+	assert.Equal(t, string(want), got, "output differs from %s; run go test with -update if the change is intended", path)
+}
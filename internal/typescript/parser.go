@@ -0,0 +1,360 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies the form of a token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+// token is a lexed token. doc holds the JSDoc comment directly before it, if any.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	doc  string
+}
+
+// lex splits TypeScript source into tokens, dropping comments other than JSDoc
+func lex(src string) ([]token, error) {
+	var tokens []token
+	var doc string
+	line := 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			body := string(runes[i+2:])[:end]
+			if strings.HasPrefix(body, "*") {
+				doc = jsDocText(body[1:])
+			}
+			line += strings.Count(body, "\n")
+			i += 2 + len([]rune(body)) + 2
+		case r == '"' || r == '\'' || r == '`':
+			var b strings.Builder
+			start := line
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: b.String(), line: start, doc: doc})
+			doc = ""
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), line: line, doc: doc})
+			doc = ""
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && (isIdentStart(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), line: line, doc: doc})
+			doc = ""
+		case strings.ContainsRune("{}()[]<>;,:?|&=.", r):
+			tokens = append(tokens, token{kind: tokPunct, text: string(r), line: line, doc: doc})
+			doc = ""
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, line: line}), nil
+}
+
+// isIdentStart reports whether r can start a TypeScript identifier
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
+
+// jsDocText returns the text of a JSDoc comment body, without the leading * of each
+// line or any @tags
+func jsDocText(body string) string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if strings.HasPrefix(line, "@") {
+			break
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parser builds a File from tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// is reports whether the current token is the punctuation or keyword text
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokPunct || tok.kind == tokIdent) && tok.text == text
+}
+
+// accept consumes the current token if it is text
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// expect consumes the current token, which must be text
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q, found %s", text, describe(p.peek()))
+	}
+	return nil
+}
+
+// errorf returns an error at the current token's line
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.peek().line, fmt.Sprintf(format, args...))
+}
+
+// describe names a token for error messages
+func describe(tok token) string {
+	if tok.kind == tokEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
+
+// parseFile parses a sequence of interface declarations
+func (p *parser) parseFile() (*File, error) {
+	file := &File{}
+	for p.peek().kind != tokEOF {
+		doc := p.peek().doc
+		for p.is("export") || p.is("declare") {
+			p.next()
+		}
+		if !p.is("interface") {
+			return nil, p.errorf("expected an interface declaration, found %s (only interfaces are supported)", describe(p.peek()))
+		}
+		line := p.next().line
+
+		name := p.next()
+		if name.kind != tokIdent {
+			return nil, fmt.Errorf("line %d: expected an interface name, found %s", name.line, describe(name))
+		}
+		if p.is("<") {
+			return nil, p.errorf("generic interface %s is not supported", name.text)
+		}
+		if p.is("extends") {
+			return nil, p.errorf("interface %s extends another interface, which is not supported", name.text)
+		}
+		members, err := p.parseMembers()
+		if err != nil {
+			return nil, err
+		}
+		p.accept(";")
+		file.Interfaces = append(file.Interfaces, Interface{Name: name.text, Doc: doc, Members: members, Line: line})
+	}
+	return file, nil
+}
+
+// parseMembers parses { name: Type; other?: Type }
+func (p *parser) parseMembers() ([]Member, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var members []Member
+	for !p.accept("}") {
+		tok := p.next()
+		doc := tok.doc
+		if tok.kind == tokIdent && tok.text == "readonly" && !p.is(":") && !p.is("?") {
+			tok = p.next()
+		}
+		switch tok.kind {
+		case tokIdent, tokString, tokNumber:
+		case tokPunct:
+			if tok.text == "[" {
+				return nil, fmt.Errorf("line %d: index signatures are not supported, use Record<string, T>", tok.line)
+			}
+			return nil, fmt.Errorf("line %d: expected a member name, found %s", tok.line, describe(tok))
+		default:
+			return nil, fmt.Errorf("line %d: expected \"}\", found end of file", tok.line)
+		}
+		if p.is("(") {
+			return nil, p.errorf("method %s is not supported", tok.text)
+		}
+
+		member := Member{Name: tok.text, Doc: doc, Line: tok.line}
+		member.Optional = p.accept("?")
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		memberType, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		member.Type = memberType
+		members = append(members, member)
+
+		if !p.accept(";") && !p.accept(",") && !p.is("}") {
+			return nil, p.errorf("expected \";\" after member %s, found %s", member.Name, describe(p.peek()))
+		}
+	}
+	return members, nil
+}
+
+// parseType parses a type, including unions such as string | null
+func (p *parser) parseType() (*Type, error) {
+	p.accept("|")
+	var variants []*Type
+	for {
+		variant, err := p.parseArrayType()
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+		if !p.accept("|") {
+			break
+		}
+	}
+	if len(variants) == 1 {
+		return variants[0], nil
+	}
+	return &Type{Kind: KindUnion, Variants: variants}, nil
+}
+
+// parseArrayType parses a primary type followed by any number of []
+func (p *parser) parseArrayType() (*Type, error) {
+	t, err := p.parsePrimaryType()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("[") {
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = &Type{Kind: KindArray, Elem: t}
+	}
+	return t, nil
+}
+
+// parsePrimaryType parses a named, literal, object, parenthesized or generic type
+func (p *parser) parsePrimaryType() (*Type, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokString:
+		p.next()
+		return &Type{Kind: KindLiteral, Name: "string"}, nil
+	case tokNumber:
+		p.next()
+		return &Type{Kind: KindLiteral, Name: "number"}, nil
+	case tokPunct:
+		switch tok.text {
+		case "(":
+			p.next()
+			t, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			return t, p.expect(")")
+		case "{":
+			members, err := p.parseMembers()
+			if err != nil {
+				return nil, err
+			}
+			return &Type{Kind: KindObject, Members: members}, nil
+		}
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true", "false":
+			return &Type{Kind: KindLiteral, Name: "boolean"}, nil
+		case "readonly":
+			return p.parseArrayType()
+		case "Array", "ReadonlyArray":
+			if err := p.expect("<"); err != nil {
+				return nil, err
+			}
+			elem, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			return &Type{Kind: KindArray, Elem: elem}, p.expect(">")
+		case "Record":
+			if err := p.expect("<"); err != nil {
+				return nil, err
+			}
+			key, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			if key.Kind != KindRef || key.Name != "string" {
+				return nil, fmt.Errorf("line %d: only Record<string, T> is supported", tok.line)
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			value, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			return &Type{Kind: KindMap, Elem: value}, p.expect(">")
+		}
+		if p.is("<") {
+			return nil, fmt.Errorf("line %d: generic type %s is not supported", tok.line, tok.text)
+		}
+		return &Type{Kind: KindRef, Name: tok.text}, nil
+	}
+	return nil, p.errorf("expected a type, found %s", describe(tok))
+}
@@ -0,0 +1,320 @@
+// Package typescript parses TypeScript interface declarations and converts them to Go
+// struct definitions. It understands a focused subset of the language: interfaces with
+// primitive, array, Record, literal, union and nested object member types.
+package typescript
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/inflect"
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/mcncl/gotyper/internal/tags"
+)
+
+// File is a parsed TypeScript source file
+type File struct {
+	Interfaces []Interface
+}
+
+// Interface is an interface declaration
+type Interface struct {
+	Name    string
+	Doc     string // JSDoc comment before the declaration, without the comment markers
+	Members []Member
+	Line    int
+}
+
+// Member is a property of an interface or object type
+type Member struct {
+	Name     string
+	Optional bool // Declared with ?, e.g. nickname?: string
+	Type     *Type
+	Doc      string
+	Line     int
+}
+
+// TypeKind identifies the form of a Type
+type TypeKind int
+
+// Type kinds
+const (
+	KindRef     TypeKind = iota // A named type: a primitive such as string, or an interface
+	KindLiteral                 // A literal type such as "admin", 42 or true; Name is its primitive
+	KindArray                   // T[] or Array<T>
+	KindMap                     // Record<string, T>
+	KindObject                  // An object type literal, { id: number }
+	KindUnion                   // A | B
+)
+
+// Type is a member's type expression
+type Type struct {
+	Kind     TypeKind
+	Name     string   // KindRef and KindLiteral
+	Elem     *Type    // KindArray element and KindMap value
+	Members  []Member // KindObject
+	Variants []*Type  // KindUnion
+}
+
+// ParseFile reads and parses the interfaces of a TypeScript file
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TypeScript file: %w", err)
+	}
+	return ParseString(string(data))
+}
+
+// ParseString parses the interfaces of TypeScript source. Anything other than
+// interface declarations and comments is an error.
+func ParseString(src string) (*File, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.parseFile()
+}
+
+// Converter converts parsed interfaces to Go struct definitions
+type Converter struct {
+	file        *File
+	config      *config.Config
+	interfaces  map[string]bool
+	structs     []models.StructDef
+	imports     map[string]struct{}
+	structNames map[string]int // Track used names to avoid collisions
+}
+
+// NewConverter creates a new TypeScript converter
+func NewConverter(file *File) *Converter {
+	return NewConverterWithConfig(file, config.NewConfig())
+}
+
+// NewConverterWithConfig creates a new TypeScript converter with custom configuration
+func NewConverterWithConfig(file *File, cfg *config.Config) *Converter {
+	return &Converter{
+		file:        file,
+		config:      cfg,
+		interfaces:  make(map[string]bool),
+		structs:     make([]models.StructDef, 0),
+		imports:     make(map[string]struct{}),
+		structNames: make(map[string]int),
+	}
+}
+
+// Convert converts each interface to a struct of the same name, in declaration order,
+// each followed by the structs of its nested object types. Optional members and
+// members that may be null become pointers, except slices and maps, whose nil value
+// already stands for a missing key.
+func (c *Converter) Convert() (models.AnalysisResult, error) {
+	if len(c.file.Interfaces) == 0 {
+		return models.AnalysisResult{}, fmt.Errorf("no interface declarations found")
+	}
+	for _, iface := range c.file.Interfaces {
+		if c.interfaces[iface.Name] {
+			return models.AnalysisResult{}, fmt.Errorf("line %d: interface %s is declared more than once", iface.Line, iface.Name)
+		}
+		c.interfaces[iface.Name] = true
+		c.structNames[iface.Name]++
+	}
+
+	for _, iface := range c.file.Interfaces {
+		if _, err := c.convertObject(iface.Name, iface.Doc, iface.Members); err != nil {
+			return models.AnalysisResult{}, fmt.Errorf("failed to convert interface %s: %w", iface.Name, err)
+		}
+	}
+
+	return models.AnalysisResult{
+		Structs: c.structs,
+		Imports: c.imports,
+	}, nil
+}
+
+// convertObject adds a struct named structName for members and returns its type. The
+// struct is added before those of its nested object types.
+func (c *Converter) convertObject(structName, doc string, members []Member) (models.TypeInfo, error) {
+	index := len(c.structs)
+	c.structs = append(c.structs, models.StructDef{Name: structName, Comment: doc})
+
+	fields := make([]models.FieldInfo, 0, len(members))
+	for _, member := range members {
+		goName := c.config.GetFieldName(member.Name)
+		if goName == "" {
+			goName = fmt.Sprintf("Field%d", len(fields)+1)
+		}
+
+		typeInfo, nullable, err := c.convertType(member.Type, structName+goName)
+		if err != nil {
+			return models.TypeInfo{}, fmt.Errorf("line %d: member %s: %w", member.Line, member.Name, err)
+		}
+
+		optional := member.Optional || nullable
+		switch typeInfo.Kind {
+		case models.Slice, models.Map, models.Interface:
+			// nil already encodes as null
+		default:
+			typeInfo.IsPointer = optional
+		}
+
+		jsonTagValue := c.config.TagKey(member.Name)
+		if optional {
+			jsonTagValue += ",omitempty"
+		}
+		fields = append(fields, models.FieldInfo{
+			JSONKey:  member.Name,
+			GoName:   goName,
+			GoType:   typeInfo,
+			JSONTag:  tags.Build([]tags.Tag{{Key: "json", Value: jsonTagValue}}, c.config.TagOrder()),
+			Tags:     map[string]string{"json": jsonTagValue},
+			Comment:  oneLine(member.Doc),
+			Optional: member.Optional,
+		})
+	}
+	c.structs[index].Fields = fields
+
+	return models.TypeInfo{
+		Kind:       models.Struct,
+		Name:       structName,
+		StructName: structName,
+	}, nil
+}
+
+// convertType returns the Go type of a TypeScript type, and whether the type admits
+// null or undefined. Nested object types become structs named after suggestedName.
+func (c *Converter) convertType(t *Type, suggestedName string) (models.TypeInfo, bool, error) {
+	switch t.Kind {
+	case KindRef:
+		switch t.Name {
+		case "null", "undefined":
+			return models.TypeInfo{Kind: models.Interface, Name: "interface{}"}, true, nil
+		}
+		typeInfo, err := c.convertRef(t.Name)
+		return typeInfo, false, err
+
+	case KindLiteral:
+		typeInfo, err := c.convertRef(t.Name)
+		return typeInfo, false, err
+
+	case KindArray:
+		elementName := inflect.Singularize(suggestedName, c.config.Naming.CustomSingulars)
+		elementType, nullable, err := c.convertType(t.Elem, elementName)
+		if err != nil {
+			return models.TypeInfo{}, false, err
+		}
+		if nullable && elementType.Kind != models.Interface && elementType.Kind != models.Slice && elementType.Kind != models.Map {
+			// (string | null)[] holds nulls, so its elements are pointers
+			elementType.IsPointer = true
+		}
+		return models.TypeInfo{
+			Kind:             models.Slice,
+			Name:             "[]" + typeName(elementType),
+			SliceElementType: &elementType,
+		}, false, nil
+
+	case KindMap:
+		valueName := inflect.Singularize(suggestedName, c.config.Naming.CustomSingulars)
+		if valueName == suggestedName {
+			valueName += "Value"
+		}
+		valueType, nullable, err := c.convertType(t.Elem, valueName)
+		if err != nil {
+			return models.TypeInfo{}, false, err
+		}
+		if (nullable && valueType.Kind != models.Interface) || (valueType.Kind == models.Struct && c.config.Types.MapValuePointers) {
+			valueType.IsPointer = true
+		}
+		return models.TypeInfo{
+			Kind:         models.Map,
+			Name:         "map[string]" + typeName(valueType),
+			MapValueType: &valueType,
+		}, false, nil
+
+	case KindObject:
+		typeInfo, err := c.convertObject(c.generateUniqueName(suggestedName), "", t.Members)
+		return typeInfo, false, err
+
+	case KindUnion:
+		return c.convertUnion(t, suggestedName)
+	}
+	return models.TypeInfo{}, false, fmt.Errorf("unsupported type")
+}
+
+// convertUnion converts A | B. Null and undefined only make the type nullable; the rest
+// must share one Go type, as string literal unions do, or the union is interface{}.
+func (c *Converter) convertUnion(t *Type, suggestedName string) (models.TypeInfo, bool, error) {
+	var nullable bool
+	var variants []*Type
+	for _, variant := range t.Variants {
+		if variant.Kind == KindRef && (variant.Name == "null" || variant.Name == "undefined") {
+			nullable = true
+			continue
+		}
+		variants = append(variants, variant)
+	}
+	if len(variants) == 0 {
+		return models.TypeInfo{Kind: models.Interface, Name: "interface{}"}, true, nil
+	}
+
+	var result models.TypeInfo
+	for i, variant := range variants {
+		typeInfo, variantNullable, err := c.convertType(variant, suggestedName)
+		if err != nil {
+			return models.TypeInfo{}, false, err
+		}
+		nullable = nullable || variantNullable
+		if i > 0 && typeName(typeInfo) != typeName(result) {
+			return models.TypeInfo{Kind: models.Interface, Name: "interface{}"}, nullable, nil
+		}
+		result = typeInfo
+	}
+	return result, nullable, nil
+}
+
+// convertRef converts a primitive type name or a reference to a declared interface
+func (c *Converter) convertRef(name string) (models.TypeInfo, error) {
+	switch name {
+	case "string":
+		return models.TypeInfo{Kind: models.String, Name: "string"}, nil
+	case "number":
+		return models.TypeInfo{Kind: models.Float, Name: "float64"}, nil
+	case "bigint":
+		return models.TypeInfo{Kind: models.Int, Name: "int64"}, nil
+	case "boolean":
+		return models.TypeInfo{Kind: models.Bool, Name: "bool"}, nil
+	case "any", "unknown", "object":
+		return models.TypeInfo{Kind: models.Interface, Name: "interface{}"}, nil
+	case "Date":
+		c.imports["time"] = struct{}{}
+		return models.TypeInfo{Kind: models.Time, Name: "time.Time"}, nil
+	}
+	if c.interfaces[name] {
+		return models.TypeInfo{Kind: models.Struct, Name: name, StructName: name}, nil
+	}
+	return models.TypeInfo{}, fmt.Errorf("unknown type %s", name)
+}
+
+// generateUniqueName ensures struct names are unique
+func (c *Converter) generateUniqueName(baseName string) string {
+	name := baseName
+	if count := c.structNames[baseName]; count > 0 {
+		name = fmt.Sprintf("%s%d", baseName, count)
+	}
+	c.structNames[baseName]++
+	return c.config.LimitNameLength(name)
+}
+
+// typeName renders a type as written in a composite type's Name, e.g. *User in []*User
+func typeName(t models.TypeInfo) string {
+	if t.IsPointer {
+		return "*" + t.Name
+	}
+	return t.Name
+}
+
+// oneLine joins the lines of a comment into one
+func oneLine(doc string) string {
+	return strings.Join(strings.Fields(doc), " ")
+}
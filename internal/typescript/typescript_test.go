@@ -0,0 +1,121 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/mcncl/gotyper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func convert(t *testing.T, src string) models.AnalysisResult {
+	t.Helper()
+	file, err := ParseString(src)
+	require.NoError(t, err)
+	result, err := NewConverter(file).Convert()
+	require.NoError(t, err)
+	return result
+}
+
+func TestConvertInterface(t *testing.T) {
+	result := convert(t, `
+export interface User {
+  name: string;
+  nickname?: string;
+}
+`)
+
+	require.Len(t, result.Structs, 1)
+	user := result.Structs[0]
+	assert.Equal(t, "User", user.Name)
+	require.Len(t, user.Fields, 2)
+
+	assert.Equal(t, "Name", user.Fields[0].GoName)
+	assert.Equal(t, "string", user.Fields[0].GoType.Name)
+	assert.False(t, user.Fields[0].GoType.IsPointer)
+	assert.Equal(t, "`json:\"name\"`", user.Fields[0].JSONTag)
+
+	assert.Equal(t, "Nickname", user.Fields[1].GoName)
+	assert.True(t, user.Fields[1].GoType.IsPointer, "optional members are pointers")
+	assert.Equal(t, "`json:\"nickname,omitempty\"`", user.Fields[1].JSONTag)
+}
+
+func TestConvertTypes(t *testing.T) {
+	result := convert(t, `
+/** An order placed by a customer */
+interface Order {
+  id: number;
+  // Plain comments are dropped
+  status: "pending" | "shipped";
+  /** When it was paid */
+  paidAt: string | null;
+  tags: string[];
+  lines: Array<OrderLine>;
+  customer: Customer;
+  address?: { street: string, city: string };
+  metadata: Record<string, unknown>;
+  mixed: string | number;
+}
+
+interface OrderLine { sku: string; quantity: number }
+
+interface Customer {
+  readonly email: string;
+}
+`)
+
+	names := make([]string, len(result.Structs))
+	for i, s := range result.Structs {
+		names[i] = s.Name
+	}
+	assert.Equal(t, []string{"Order", "OrderAddress", "OrderLine", "Customer"}, names)
+
+	order := result.Structs[0]
+	assert.Equal(t, "An order placed by a customer", order.Comment)
+	fields := make(map[string]models.FieldInfo)
+	for _, field := range order.Fields {
+		fields[field.JSONKey] = field
+	}
+	assert.Equal(t, "float64", fields["id"].GoType.Name)
+	assert.Equal(t, "string", fields["status"].GoType.Name)
+	assert.False(t, fields["status"].GoType.IsPointer)
+	assert.True(t, fields["paidAt"].GoType.IsPointer, "string | null is a pointer")
+	assert.Equal(t, "When it was paid", fields["paidAt"].Comment)
+	assert.Equal(t, "[]string", fields["tags"].GoType.Name)
+	assert.Equal(t, models.Slice, fields["lines"].GoType.Kind)
+	assert.Equal(t, "OrderLine", fields["lines"].GoType.SliceElementType.StructName)
+	assert.Equal(t, "Customer", fields["customer"].GoType.StructName)
+	assert.Equal(t, "OrderAddress", fields["address"].GoType.StructName)
+	assert.True(t, fields["address"].GoType.IsPointer)
+	assert.Equal(t, "map[string]interface{}", fields["metadata"].GoType.Name)
+	assert.Equal(t, models.Interface, fields["mixed"].GoType.Kind)
+
+	assert.Equal(t, "Email", result.Structs[3].Fields[0].GoName)
+}
+
+func TestParseString_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"type alias", "type ID = string;", "only interfaces are supported"},
+		{"extends", "interface A extends B { x: string }", "extends"},
+		{"missing colon", "interface A {\n  x string\n}", `line 2: expected ":"`},
+		{"unterminated", "interface A { x: string;", "end of file"},
+		{"index signature", "interface A { [key: string]: number }", "Record<string, T>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString(tt.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	file, err := ParseString("interface A { b: Missing }")
+	require.NoError(t, err)
+	_, err = NewConverter(file).Convert()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown type Missing")
+}
@@ -2,43 +2,106 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
+	"go/token"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/mcncl/gotyper/internal/analyzer"
+	"github.com/mcncl/gotyper/internal/cache"
+	"github.com/mcncl/gotyper/internal/compat"
 	"github.com/mcncl/gotyper/internal/config"
 	"github.com/mcncl/gotyper/internal/errors"
 	"github.com/mcncl/gotyper/internal/formatter"
 	"github.com/mcncl/gotyper/internal/generator"
+	"github.com/mcncl/gotyper/internal/iface"
+	"github.com/mcncl/gotyper/internal/ignore"
 	"github.com/mcncl/gotyper/internal/models"
 	"github.com/mcncl/gotyper/internal/parser"
 	"github.com/mcncl/gotyper/internal/schema"
+	"github.com/mcncl/gotyper/internal/typescript"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 // CLI defines the command-line interface
 var CLI struct {
 	Input       string `help:"Path to input JSON file. If not specified, reads from stdin." short:"i" type:"path"`
+	InputDir    string `help:"Directory of sample JSON files (*.json, searched recursively) merged as if they were the elements of one array. Paths matching its .gotyperignore are skipped." type:"path"`
 	URL         string `help:"URL to fetch JSON from. Supports http and https." short:"u"`
 	Schema      string `help:"Path or URL to JSON Schema file. Generates structs from schema instead of sample JSON." short:"s"`
+	OpenAPI     bool   `help:"Read --schema as an OpenAPI 3 document and generate a type for each of its components.schemas." name:"openapi"`
+	FromTS      string `help:"Path to a TypeScript file of interface declarations. Generates a struct for each interface instead of from sample JSON." name:"from-ts" type:"path"`
 	Output      string `help:"Path to output Go file. If not specified, writes to stdout." short:"o" type:"path"`
+	Append      bool   `help:"Add the generated structs to the existing --output file instead of overwriting it, skipping types it already declares."`
+	SplitFiles  bool   `help:"Write each struct and named type to its own file, named after it in snake_case, in the --output directory."`
+	TypesOnly   bool   `help:"Write only the type, constant and method declarations, without the package clause, imports or file header, for splicing into another file."`
 	Package     string `help:"Package name for generated code." short:"p" default:"main"`
-	RootName    string `help:"Name for the root struct." short:"r" default:"RootType"`
+	RootName    string `help:"Name for the root struct. Defaults to a JSON Schema's title or $id, then RootType." short:"r"`
 	Config      string `help:"Path to config file. If not specified, searches for .gotyper.yml in current and parent directories." short:"c" type:"path"`
 	Format      bool   `help:"Format the output code according to Go standards." short:"f" default:"true"`
 	Debug       bool   `help:"Enable debug logging." short:"d"`
 	Version     bool   `help:"Show version information." short:"v"`
+	PrintConfig bool   `help:"Print the effective configuration, after merging defaults, the config file and flags, as YAML to stderr and exit."`
 	Interactive bool   `help:"Run in interactive mode, allowing direct JSON input with Ctrl+D to process." short:"I"`
+	Stdin       bool   `help:"Read piped JSON from stdin, skipping terminal detection."`
+	Implement   string `help:"Go file declaring an interface to stub on the root struct. Use file.go:Name when the file declares several."`
+	Template    string `help:"text/template file to render the analysis result with instead of generating Go structs."`
+	CompatCheck string `help:"Previously generated Go file to compare the result against. Reports breaking changes instead of writing output and fails if there are any." type:"path"`
+
+	PreserveCommentsFrom string `help:"Previously generated Go file whose struct and field comments are kept on the matching structs and fields (by JSON key) of the new output." type:"path"`
+
+	OutputFormat string `help:"Output format: go for struct definitions, ts for TypeScript declarations, schema for a JSON Schema, json for the analysis result." enum:"go,ts,schema,json" default:"go"`
+	Emit         string `help:"Write a report instead of code: report is a canonical JSON summary of struct names, field names, types and optionality, sorted so it can be committed and diffed in CI to catch API changes." enum:",report" default:""`
+	RequiredFrom string `help:"What makes a property required in --output-format schema: non-pointer (neither pointer nor omitempty), always-present (in every merged object) or none."`
+	JSONIndent   int    `help:"Spaces to indent JSON output by (--output-format json or schema)." default:"2"`
+	JSONCompact  bool   `help:"Write JSON output on a single line, ignoring --json-indent."`
+
+	CacheDir string        `help:"Directory to cache --url responses in, so repeated runs within --cache-ttl skip the request." type:"path"`
+	CacheTTL time.Duration `help:"How long a cached --url response is reused." default:"1h"`
+	NoCache  bool          `help:"Fetch --url even when --cache-dir holds a fresh response."`
+
+	Filter                  string   `help:"jq expression applied to the input before analysis, e.g. .data.items. Several results are treated as an array."`
+	ErrorOnDuplicateKeys    bool     `help:"Fail instead of warning when a JSON object contains duplicate keys."`
+	StrictConfig            bool     `help:"Fail instead of warning when a type mapping, validation rule, custom tag option or skip_fields entry matches no field."`
+	WarningsJSON            string   `help:"Also write warnings to this file as a JSON array of {type, path, message} objects." type:"path"`
+	AllowTrailingData       bool     `help:"Read several JSON values, such as one object per line or {...}{...}, and treat them as an array. Also --multi." aliases:"multi"`
+	AllowNonStandardNumbers bool     `help:"Read the bare NaN, Infinity and -Infinity tokens some emitters write, typing them as float64. encoding/json still rejects them, so decoding the JSON needs custom unmarshaling."`
+	NoWrapRootPrimitive     bool     `help:"Emit a named type (type RootType string) for a primitive JSON root instead of a wrapper struct."`
+	RootOptional            bool     `help:"Generate ParseRootType(data) (*RootType, error), which returns nil for a JSON null instead of a zero struct."`
+	WrapArrayAs             string   `help:"Wrap a JSON array root in a struct named by --root-name, with a field of this name holding the elements, e.g. Items."`
+	MergeStrategy           string   `help:"How to type a field whose type differs between merged array elements: interface, first, string or error."`
+	MaxUnionMembers         int      `help:"Merge an array of objects into one struct, with a warning, when a union or per-shape structs would need more than N variants. 0 means no limit."`
+	StructOrder             string   `help:"Order of structs in the output: root_first, declaration (document order) or name."`
+	PreserveNullFields      bool     `help:"Type fields and array elements that are sometimes null as pointers to their concrete type instead of interface{}."`
+	OptionalThreshold       float64  `help:"Fraction of merged objects (0-1) a key must appear in to be required; rarer fields become optional pointers. 0 uses the default."`
+	FieldTagCase            string   `help:"Casing of json tag names: original (the JSON key as is), lower or upper. Field names are unaffected."`
+	OmitemptyOnlyOptional   bool     `help:"Add omitempty only to fields missing from or null in some of the merged objects, whatever their type. Same as json_tags.omitempty: optional-only." name:"json-tag-omitempty-only-optional"`
+	TrimPrefix              []string `help:"Prefix to remove from JSON keys before naming fields, e.g. attr_. Repeatable."`
+	TrimSuffix              []string `help:"Suffix to remove from JSON keys before naming fields. Repeatable."`
+	ASCIIOnly               bool     `help:"Transliterate accented letters in JSON keys and drop other non-ASCII characters from field names. Tags keep the original key." name:"ascii-only"`
+	ExcludeFields           []string `help:"Glob matching JSON keys, or dotted paths such as *.debug_info, of fields to leave out entirely. Repeatable."`
+
+	GoVersion string `help:"Target Go version for generated code (e.g. 1.22). Defaults to the Go version gotyper was built with."`
+
+	TypesPackageAlias string `help:"Package name qualifying the root type in the output.usage_example comment, e.g. api for api.User. Defaults to --package."`
 }
 
 // Context holds the runtime context
 type Context struct {
-	Debug  bool
-	Config *config.Config
+	Debug      bool
+	Config     *config.Config
+	ConfigPath string // Config file that was loaded, if any
 }
 
 // Version information
@@ -55,8 +118,9 @@ func main() {
 	)
 
 	// Check if no arguments provided and set interactive mode by default
+	// when stdin is a terminal; piped input is read directly
 	if len(os.Args) == 1 {
-		CLI.Interactive = true
+		CLI.Interactive = stdinIsTerminal()
 		// Explicitly ensure default package name is set to 'main'
 		if CLI.Package == "" {
 			CLI.Package = "main"
@@ -99,6 +163,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	if CLI.PrintConfig {
+		if err := printConfig(os.Stderr, ctx.Config); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", errors.UserFriendlyError(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	err = run(ctx)
 	if err != nil {
 		// Use our custom error handling to provide user-friendly error messages
@@ -127,22 +199,148 @@ func createContext() (*Context, error) {
 	if err != nil {
 		return nil, errors.NewInputError("failed to load configuration", err)
 	}
+	if cfg.RootName != "" {
+		rootName, err := analyzer.SanitizeRootName(cfg.RootName)
+		if err != nil {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid root name %q: it has no letters or digits", cfg.RootName), nil)
+		}
+		if rootName != cfg.RootName {
+			fmt.Fprintf(os.Stderr, "Warning: root name %q is not a valid exported Go identifier, using %q\n", cfg.RootName, rootName)
+			cfg.RootName = rootName
+		}
+	}
+	if CLI.NoWrapRootPrimitive {
+		cfg.Output.RootPrimitiveAlias = true
+	}
+	if CLI.RootOptional {
+		cfg.Output.RootOptional = true
+	}
+	if CLI.WrapArrayAs != "" {
+		cfg.Output.WrapRootArrayAs = CLI.WrapArrayAs
+	}
+	cfg.Naming.TrimKeyPrefixes = append(cfg.Naming.TrimKeyPrefixes, CLI.TrimPrefix...)
+	cfg.Naming.TrimKeySuffixes = append(cfg.Naming.TrimKeySuffixes, CLI.TrimSuffix...)
+	cfg.JSONTags.ExcludeFields = append(cfg.JSONTags.ExcludeFields, CLI.ExcludeFields...)
+	if CLI.ASCIIOnly {
+		cfg.Naming.ASCIIOnly = true
+	}
+	if CLI.OmitemptyOnlyOptional {
+		cfg.JSONTags.Omitempty = config.OmitemptyOptionalOnly
+	}
+	if CLI.MaxUnionMembers < 0 {
+		return nil, errors.NewInputError(fmt.Sprintf("invalid --max-union-members %d: must not be negative", CLI.MaxUnionMembers), nil)
+	}
+	if CLI.MaxUnionMembers > 0 {
+		cfg.Arrays.MaxUnionMembers = CLI.MaxUnionMembers
+	}
+	if CLI.MergeStrategy != "" {
+		if !config.ValidMergeStrategy(CLI.MergeStrategy) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --merge-strategy %q: must be interface, first, string or error", CLI.MergeStrategy), nil)
+		}
+		cfg.Arrays.MergeStrategy = CLI.MergeStrategy
+	}
+	if CLI.FieldTagCase != "" {
+		if !config.ValidTagCase(CLI.FieldTagCase) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --field-tag-case %q: must be original, lower or upper", CLI.FieldTagCase), nil)
+		}
+		cfg.JSONTags.TagCase = CLI.FieldTagCase
+	}
+	if CLI.RequiredFrom != "" {
+		if !config.ValidRequiredFrom(CLI.RequiredFrom) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --required-from %q: must be non-pointer, always-present or none", CLI.RequiredFrom), nil)
+		}
+		cfg.Output.SchemaRequiredFrom = CLI.RequiredFrom
+	}
+	if CLI.StructOrder != "" {
+		if !config.ValidStructOrder(CLI.StructOrder) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --struct-order %q: must be root_first, declaration or name", CLI.StructOrder), nil)
+		}
+		cfg.Output.StructOrder = CLI.StructOrder
+	}
+	if CLI.PreserveNullFields {
+		cfg.Types.PreserveNullFields = true
+	}
+	if CLI.OptionalThreshold != 0 {
+		if !config.ValidOptionalThreshold(CLI.OptionalThreshold) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --optional-threshold %g: must be between 0 and 1", CLI.OptionalThreshold), nil)
+		}
+		cfg.Types.OptionalThreshold = CLI.OptionalThreshold
+	}
+	if CLI.FromTS != "" && CLI.Schema != "" {
+		return nil, errors.NewInputError("cannot specify --from-ts with --schema", nil)
+	}
+	if CLI.OpenAPI && CLI.Schema == "" {
+		return nil, errors.NewInputError("--openapi requires --schema", nil)
+	}
+	if CLI.Append && CLI.Output == "" {
+		return nil, errors.NewInputError("--append requires --output", nil)
+	}
+	if CLI.SplitFiles {
+		switch {
+		case CLI.Output == "":
+			return nil, errors.NewInputError("--split-files requires --output, the directory to write the files to", nil)
+		case CLI.Append || CLI.TypesOnly:
+			return nil, errors.NewInputError("--split-files can't be used with --append or --types-only", nil)
+		}
+	}
+	if CLI.TypesOnly && CLI.Append {
+		return nil, errors.NewInputError("--types-only can't be used with --append, which needs the generated imports", nil)
+	}
+	if CLI.JSONIndent < 0 {
+		return nil, errors.NewInputError(fmt.Sprintf("invalid --json-indent %d: must not be negative", CLI.JSONIndent), nil)
+	}
+	if CLI.TypesPackageAlias != "" {
+		if !token.IsIdentifier(CLI.TypesPackageAlias) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --types-package-alias %q: must be a Go identifier", CLI.TypesPackageAlias), nil)
+		}
+		cfg.Output.TypesPackageAlias = CLI.TypesPackageAlias
+	}
+	if CLI.GoVersion != "" {
+		if !config.ValidGoVersion(CLI.GoVersion) {
+			return nil, errors.NewInputError(fmt.Sprintf("invalid --go-version %q: expected a version like 1.22", CLI.GoVersion), nil)
+		}
+		cfg.GoVersion = CLI.GoVersion
+	}
 
 	return &Context{
-		Debug:  CLI.Debug,
-		Config: cfg,
+		Debug:      CLI.Debug,
+		Config:     cfg,
+		ConfigPath: configPath,
 	}, nil
 }
 
+// printConfig writes cfg to w as YAML, in the config file's layout
+func printConfig(w io.Writer, cfg *config.Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return errors.NewOutputError("failed to encode the configuration", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
 // run executes the main program logic
-func run(ctx *Context) error {
+func run(ctx *Context) (err error) {
 	var analysisResult models.AnalysisResult
-	var err error
+	var warnings []models.Warning
+	if CLI.WarningsJSON != "" {
+		// Written even when generation fails, so CI can see why
+		defer func() {
+			if writeErr := writeWarningsJSON(warnings); err == nil {
+				err = writeErr
+			}
+		}()
+	}
 
-	// Check if using JSON Schema mode or JSON sample mode
+	// Check if using JSON Schema mode, TypeScript mode or JSON sample mode
 	if CLI.Schema != "" {
 		// Schema mode: parse and convert JSON Schema
-		analysisResult, err = parseSchema(ctx.Config.RootName)
+		analysisResult, err = parseSchema(ctx.Config)
+		if err != nil {
+			return err
+		}
+	} else if CLI.FromTS != "" {
+		analysisResult, err = parseTypeScript(ctx.Config)
 		if err != nil {
 			return err
 		}
@@ -152,23 +350,127 @@ func run(ctx *Context) error {
 		if err != nil {
 			return err
 		}
-
+		if err := loadSidecar(ctx.Config); err != nil {
+			return err
+		}
 		analyzerInst := analyzer.NewAnalyzerWithConfig(ctx.Config)
 		analysisResult, err = analyzerInst.Analyze(ir, ctx.Config.RootName)
 		if err != nil {
 			return errors.NewAnalysisError("failed to analyze JSON structure", err)
 		}
+		warnings = analysisResult.Warnings
+
+		var unmatched []string
+		for _, warning := range warnings {
+			if warning.Type == models.WarningUnmatchedConfig && CLI.StrictConfig {
+				unmatched = append(unmatched, warning.Message)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		if len(unmatched) > 0 {
+			return errors.NewInputError(fmt.Sprintf("configuration matches nothing in the input: %s", strings.Join(unmatched, "; ")), nil)
+		}
+	}
+
+	if CLI.Implement != "" {
+		if err := attachInterfaceStubs(&analysisResult, CLI.Implement); err != nil {
+			return err
+		}
+	}
+
+	if CLI.PreserveCommentsFrom != "" {
+		if err := preserveComments(&analysisResult, CLI.PreserveCommentsFrom); err != nil {
+			return err
+		}
+	}
+
+	if CLI.CompatCheck != "" {
+		return checkCompatibility(analysisResult, CLI.CompatCheck)
+	}
+
+	if CLI.Template != "" {
+		return renderTemplate(ctx, analysisResult)
+	}
+
+	if CLI.Emit == "report" {
+		data, err := marshalJSONOutput(generator.NewGeneratorWithConfig(ctx.Config).GenerateReport(analysisResult))
+		if err != nil {
+			return errors.NewOutputError("failed to encode report", err)
+		}
+		return writeOutput(string(data))
+	}
+
+	switch CLI.OutputFormat {
+	case "json":
+		return writeAnalysisJSON(analysisResult)
+	case "ts":
+		declarations, err := generator.NewGeneratorWithConfig(ctx.Config).GenerateTypeScript(analysisResult)
+		if err != nil {
+			return errors.NewGenerateError("failed to generate TypeScript declarations", err)
+		}
+		return writeOutput(declarations)
+	case "schema":
+		document, err := generator.NewGeneratorWithConfig(ctx.Config).GenerateJSONSchema(analysisResult)
+		if err != nil {
+			return errors.NewGenerateError("failed to generate JSON Schema", err)
+		}
+		data, err := marshalJSONOutput(document)
+		if err != nil {
+			return errors.NewOutputError("failed to encode JSON Schema", err)
+		}
+		return writeOutput(string(data))
 	}
 
 	// Generate Go structs
-	generatorInst := generator.NewGenerator()
-	code, err := generatorInst.GenerateStructs(analysisResult, ctx.Config.Package)
+	generatorInst := generator.NewGeneratorWithConfig(ctx.Config)
+	if ctx.Config.Output.EmbedGoGenerate {
+		if args, ok := goGenerateArgs(ctx); ok {
+			generatorInst.SetGoGenerateArgs(args)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: output.embed_go_generate needs --input, --url or --schema; skipping the go:generate directive")
+		}
+	}
+	if ctx.Config.Output.SeparateMethods && !CLI.SplitFiles {
+		fmt.Fprintln(os.Stderr, "Warning: output.separate_methods needs --split-files; writing methods with their types")
+	}
+	format := CLI.Format && ctx.Config.Formatting.Enabled
+	if !format && !CLI.SplitFiles && !CLI.TypesOnly && !CLI.Append {
+		// Nothing needs the whole file, so write each declaration as it's generated
+		if err := streamOutput(generatorInst, analysisResult, ctx.Config.Package); err != nil {
+			return err
+		}
+		for _, warning := range generatorInst.Warnings() {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			warnings = append(warnings, warning)
+		}
+		return nil
+	}
+	var code string
+	var files map[string]string
+	if CLI.SplitFiles {
+		files, err = generatorInst.GenerateFiles(analysisResult, ctx.Config.Package)
+	} else {
+		code, err = generatorInst.GenerateStructs(analysisResult, ctx.Config.Package)
+	}
 	if err != nil {
 		return errors.NewGenerateError("failed to generate Go structs", err)
 	}
+	for _, warning := range generatorInst.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		warnings = append(warnings, warning)
+	}
+	if CLI.SplitFiles {
+		return writeFiles(CLI.Output, files, format)
+	}
+	if CLI.TypesOnly {
+		if code, err = generator.StripPackageClause(code); err != nil {
+			return errors.NewGenerateError("failed to strip the package clause", err)
+		}
+	}
 
 	// Format the code if requested and enabled in config
-	if CLI.Format && ctx.Config.Formatting.Enabled {
+	if format {
 		formatterInst := formatter.NewFormatter()
 		code, err = formatterInst.Format(code)
 		if err != nil {
@@ -176,16 +478,282 @@ func run(ctx *Context) error {
 		}
 	}
 
+	if CLI.Append {
+		if code, err = appendToOutput(code); err != nil {
+			return err
+		}
+	}
+
 	// Output the result
 	return writeOutput(code)
 }
 
+// appendToOutput merges generated code into the existing --output file, if there is one
+func appendToOutput(code string) (string, error) {
+	existing, err := os.ReadFile(CLI.Output)
+	if os.IsNotExist(err) {
+		return code, nil
+	}
+	if err != nil {
+		return "", errors.NewOutputError(fmt.Sprintf("failed to read '%s' to append to", CLI.Output), err)
+	}
+	merged, err := generator.AppendDeclarations(existing, []byte(code))
+	if err != nil {
+		return "", errors.NewGenerateError(fmt.Sprintf("failed to append to '%s'", CLI.Output), err)
+	}
+	return string(merged), nil
+}
+
+// renderTemplate writes the analysis result rendered with the --template file
+func renderTemplate(ctx *Context, result models.AnalysisResult) error {
+	text, err := os.ReadFile(CLI.Template)
+	if err != nil {
+		return errors.NewInputError(fmt.Sprintf("failed to read template: %v", err), nil)
+	}
+	output, err := generator.NewGeneratorWithConfig(ctx.Config).GenerateFromTemplate(result, ctx.Config.Package, string(text))
+	if err != nil {
+		return errors.NewGenerateError(fmt.Sprintf("failed to render template %s: %v", CLI.Template, err), nil)
+	}
+	return writeOutput(output)
+}
+
+// writeAnalysisJSON writes the analysis result as JSON instead of Go code, indented
+// by --json-indent spaces unless --json-compact is set
+func writeAnalysisJSON(result models.AnalysisResult) error {
+	data, err := marshalJSONOutput(result)
+	if err != nil {
+		return errors.NewOutputError("failed to encode analysis result as JSON", err)
+	}
+	return writeOutput(string(data))
+}
+
+// marshalJSONOutput encodes v for the JSON output modes
+func marshalJSONOutput(v any) ([]byte, error) {
+	if CLI.JSONCompact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", strings.Repeat(" ", CLI.JSONIndent))
+}
+
+// splitImplementSpec splits an --implement value into the file path and optional interface name
+func splitImplementSpec(spec string) (path, name string) {
+	if i := strings.LastIndex(spec, ":"); i > 0 && token.IsIdentifier(spec[i+1:]) {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
+
+// goGenerateArgs reconstructs the current invocation for a //go:generate directive.
+// go generate runs in the directory of the generated file, so local paths are made
+// relative to the output file. It reports false when the input came from stdin,
+// which a directive can't reproduce.
+func goGenerateArgs(ctx *Context) ([]string, bool) {
+	baseDir := "."
+	switch {
+	case CLI.SplitFiles:
+		// The directive is written into one of the files in the --output directory
+		baseDir = CLI.Output
+	case CLI.Output != "":
+		baseDir = filepath.Dir(CLI.Output)
+	}
+	relative := func(path string) string {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return path
+		}
+		absBase, err := filepath.Abs(baseDir)
+		if err != nil {
+			return path
+		}
+		rel, err := filepath.Rel(absBase, absPath)
+		if err != nil {
+			return path
+		}
+		return rel
+	}
+	isURL := func(s string) bool {
+		lower := strings.ToLower(s)
+		return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+	}
+
+	args := []string{"gotyper"}
+	switch {
+	case CLI.Schema != "" && isURL(CLI.Schema):
+		args = append(args, "-s", CLI.Schema)
+	case CLI.Schema != "":
+		args = append(args, "-s", relative(CLI.Schema))
+	case CLI.FromTS != "":
+		args = append(args, "--from-ts", relative(CLI.FromTS))
+	case CLI.URL != "":
+		args = append(args, "-u", CLI.URL)
+	case CLI.Input != "":
+		args = append(args, "-i", relative(CLI.Input))
+	case CLI.InputDir != "":
+		args = append(args, "--input-dir", relative(CLI.InputDir))
+	default:
+		return nil, false
+	}
+
+	switch {
+	case CLI.SplitFiles:
+		args = append(args, "-o", ".", "--split-files")
+	case CLI.Output != "":
+		args = append(args, "-o", filepath.Base(CLI.Output))
+	}
+	if CLI.Append {
+		args = append(args, "--append")
+	}
+	if CLI.TypesOnly {
+		args = append(args, "--types-only")
+	}
+	args = append(args, "-p", ctx.Config.Package)
+	if rootNameGiven(ctx.Config) {
+		args = append(args, "-r", ctx.Config.RootName)
+	}
+	if ctx.ConfigPath != "" {
+		args = append(args, "-c", relative(ctx.ConfigPath))
+	}
+	if CLI.OpenAPI {
+		args = append(args, "--openapi")
+	}
+	if !CLI.Format {
+		args = append(args, "--format=false")
+	}
+	if CLI.Filter != "" {
+		args = append(args, "--filter", CLI.Filter)
+	}
+	if CLI.ErrorOnDuplicateKeys {
+		args = append(args, "--error-on-duplicate-keys")
+	}
+	if CLI.StrictConfig {
+		args = append(args, "--strict-config")
+	}
+	if CLI.AllowTrailingData {
+		args = append(args, "--allow-trailing-data")
+	}
+	if CLI.AllowNonStandardNumbers {
+		args = append(args, "--allow-non-standard-numbers")
+	}
+	if CLI.NoWrapRootPrimitive {
+		args = append(args, "--no-wrap-root-primitive")
+	}
+	if CLI.RootOptional {
+		args = append(args, "--root-optional")
+	}
+	if CLI.WrapArrayAs != "" {
+		args = append(args, "--wrap-array-as", CLI.WrapArrayAs)
+	}
+	if CLI.MaxUnionMembers > 0 {
+		args = append(args, "--max-union-members", strconv.Itoa(CLI.MaxUnionMembers))
+	}
+	if CLI.MergeStrategy != "" {
+		args = append(args, "--merge-strategy", CLI.MergeStrategy)
+	}
+	if CLI.StructOrder != "" {
+		args = append(args, "--struct-order", CLI.StructOrder)
+	}
+	if CLI.PreserveNullFields {
+		args = append(args, "--preserve-null-fields")
+	}
+	if CLI.OptionalThreshold != 0 {
+		args = append(args, "--optional-threshold", strconv.FormatFloat(CLI.OptionalThreshold, 'g', -1, 64))
+	}
+	if CLI.FieldTagCase != "" {
+		args = append(args, "--field-tag-case", CLI.FieldTagCase)
+	}
+	if CLI.OmitemptyOnlyOptional {
+		args = append(args, "--json-tag-omitempty-only-optional")
+	}
+	for _, prefix := range CLI.TrimPrefix {
+		args = append(args, "--trim-prefix", prefix)
+	}
+	for _, suffix := range CLI.TrimSuffix {
+		args = append(args, "--trim-suffix", suffix)
+	}
+	if CLI.ASCIIOnly {
+		args = append(args, "--ascii-only")
+	}
+	for _, pattern := range CLI.ExcludeFields {
+		args = append(args, "--exclude-fields", pattern)
+	}
+	if CLI.GoVersion != "" {
+		args = append(args, "--go-version", CLI.GoVersion)
+	}
+	if CLI.TypesPackageAlias != "" {
+		args = append(args, "--types-package-alias", CLI.TypesPackageAlias)
+	}
+	if CLI.Implement != "" {
+		path, name := splitImplementSpec(CLI.Implement)
+		spec := relative(path)
+		if name != "" {
+			spec += ":" + name
+		}
+		args = append(args, "--implement", spec)
+	}
+	if CLI.Template != "" {
+		args = append(args, "--template", relative(CLI.Template))
+	}
+	if CLI.PreserveCommentsFrom != "" {
+		args = append(args, "--preserve-comments-from", relative(CLI.PreserveCommentsFrom))
+	}
+
+	return args, true
+}
+
+// attachInterfaceStubs parses the interface named by spec ("file.go" or "file.go:Name")
+// and adds its methods as stubs on the root struct
+func attachInterfaceStubs(result *models.AnalysisResult, spec string) error {
+	path, name := splitImplementSpec(spec)
+	methods, err := iface.ParseFile(path, name)
+	if err != nil {
+		return errors.NewInputError(fmt.Sprintf("failed to load interface from %s", path), err)
+	}
+
+	for i := range result.Structs {
+		if result.Structs[i].IsRoot {
+			result.Structs[i].Methods = append(result.Structs[i].Methods, methods...)
+			return nil
+		}
+	}
+	return errors.NewInputError("--implement requires a root struct, but the input root is not an object", nil)
+}
+
+// preserveComments keeps the struct and field comments of an existing Go file, such as
+// the previous output, on the matching structs and fields of the result
+func preserveComments(result *models.AnalysisResult, path string) error {
+	existing, err := compat.ParseFile(path)
+	if err != nil {
+		return errors.NewInputError(fmt.Sprintf("failed to load comments from %s", path), err)
+	}
+	compat.PreserveComments(existing, result)
+	return nil
+}
+
+// checkCompatibility prints the breaking differences between the structs in an existing
+// Go file and the analysis result, and fails if there are any
+func checkCompatibility(result models.AnalysisResult, path string) error {
+	existing, err := compat.ParseFile(path)
+	if err != nil {
+		return errors.NewInputError(fmt.Sprintf("failed to load structs from %s", path), err)
+	}
+
+	diffs := compat.Compare(existing, result)
+	if len(diffs) == 0 {
+		fmt.Fprintf(os.Stderr, "No breaking changes against %s\n", path)
+		return nil
+	}
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	return errors.NewAnalysisError(fmt.Sprintf("%d breaking change(s) against %s", len(diffs), path), nil)
+}
+
 // parseSchema reads and converts a JSON Schema from file or URL
-func parseSchema(rootName string) (models.AnalysisResult, error) {
+func parseSchema(cfg *config.Config) (models.AnalysisResult, error) {
 	// Check for conflicting input sources
-	if CLI.Input != "" || CLI.URL != "" {
+	if CLI.Input != "" || CLI.InputDir != "" || CLI.URL != "" {
 		return models.AnalysisResult{}, errors.NewInputError(
-			"cannot specify --schema with --input or --url", nil)
+			"cannot specify --schema with --input, --input-dir or --url", nil)
 	}
 
 	var s *schema.Schema
@@ -209,7 +777,20 @@ func parseSchema(rootName string) (models.AnalysisResult, error) {
 	}
 
 	// Convert schema to analysis result
-	converter := schema.NewConverter(s)
+	converter := schema.NewConverterWithConfig(s, cfg)
+	if CLI.OpenAPI {
+		result, err := converter.ConvertComponents()
+		if err != nil {
+			return models.AnalysisResult{}, errors.NewAnalysisError(
+				"failed to convert OpenAPI components", err)
+		}
+		return result, nil
+	}
+	// An empty name lets the converter take the schema's title or $id first
+	rootName := ""
+	if rootNameGiven(cfg) {
+		rootName = cfg.RootName
+	}
 	result, err := converter.Convert(rootName)
 	if err != nil {
 		return models.AnalysisResult{}, errors.NewAnalysisError(
@@ -219,6 +800,32 @@ func parseSchema(rootName string) (models.AnalysisResult, error) {
 	return result, nil
 }
 
+// rootNameGiven reports whether -r or the config file named the root struct, rather
+// than it keeping the default RootType
+func rootNameGiven(cfg *config.Config) bool {
+	return CLI.RootName != "" || cfg.RootName != analyzer.DefaultRootName
+}
+
+// parseTypeScript reads the interfaces of the --from-ts file and converts them to structs
+func parseTypeScript(cfg *config.Config) (models.AnalysisResult, error) {
+	if CLI.Input != "" || CLI.InputDir != "" || CLI.URL != "" {
+		return models.AnalysisResult{}, errors.NewInputError(
+			"cannot specify --from-ts with --input, --input-dir or --url", nil)
+	}
+
+	file, err := typescript.ParseFile(CLI.FromTS)
+	if err != nil {
+		return models.AnalysisResult{}, errors.NewInputError(
+			fmt.Sprintf("failed to parse TypeScript file: %s", CLI.FromTS), err)
+	}
+	result, err := typescript.NewConverterWithConfig(file, cfg).Convert()
+	if err != nil {
+		return models.AnalysisResult{}, errors.NewAnalysisError(
+			"failed to convert TypeScript interfaces", err)
+	}
+	return result, nil
+}
+
 // fetchSchemaFromURL fetches a JSON Schema from a URL
 func fetchSchemaFromURL(url string) (*schema.Schema, error) {
 	client := &http.Client{
@@ -262,10 +869,17 @@ func parseInput() (models.IntermediateRepresentation, error) {
 	if CLI.Input != "" && CLI.URL != "" {
 		return models.IntermediateRepresentation{}, errors.NewInputError("cannot specify both --input and --url", nil)
 	}
+	if CLI.InputDir != "" && (CLI.Input != "" || CLI.URL != "") {
+		return models.IntermediateRepresentation{}, errors.NewInputError("cannot specify --input-dir with --input or --url", nil)
+	}
+
+	if CLI.InputDir != "" {
+		return parseInputDir(CLI.InputDir)
+	}
 
 	if CLI.Input != "" {
 		// Parse from file
-		return parser.ParseFile(CLI.Input)
+		return parser.ParseFileWithOptions(CLI.Input, parserOptions())
 	}
 
 	if CLI.URL != "" {
@@ -273,19 +887,15 @@ func parseInput() (models.IntermediateRepresentation, error) {
 		return fetchFromURL(CLI.URL)
 	}
 
-	// Check if stdin has data
-	stdinInfo, err := os.Stdin.Stat()
+	mode, err := resolveStdinMode(stdinIsTerminal(), CLI.Stdin, CLI.Interactive)
 	if err != nil {
-		return models.IntermediateRepresentation{}, errors.NewInputError("failed to access stdin", err)
+		return models.IntermediateRepresentation{}, err
 	}
 
-	// Interactive mode or piped input
-	if (stdinInfo.Mode() & os.ModeCharDevice) != 0 {
-		// Terminal is interactive (not piped)
-		if CLI.Interactive {
-			// Interactive mode
-			return readInteractiveInput()
-		}
+	switch mode {
+	case stdinInteractive:
+		return readInteractiveInput()
+	case stdinUnavailable:
 		// No data provided on stdin and not in interactive mode
 		return models.IntermediateRepresentation{}, errors.NewInputError("no input provided", errors.ErrNoInput)
 	}
@@ -300,11 +910,145 @@ func parseInput() (models.IntermediateRepresentation, error) {
 		return models.IntermediateRepresentation{}, errors.NewInputError("empty input received from stdin", errors.ErrEmptyInput)
 	}
 
-	return parser.ParseString(string(jsonData))
+	return parser.ParseStringWithOptions(string(jsonData), parserOptions())
+}
+
+// parseInputDir parses every .json file under dir, in path order, skipping those its
+// .gotyperignore matches, and combines them into an array with an element per file
+func parseInputDir(dir string) (models.IntermediateRepresentation, error) {
+	matcher, err := ignore.Load(dir)
+	if err != nil {
+		return models.IntermediateRepresentation{}, errors.NewInputError(fmt.Sprintf("failed to load the ignore file of '%s'", dir), err)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if matcher.Match(rel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Sidecar files such as data.gotyper.json hold directives, not samples
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") && !strings.HasSuffix(path, ".gotyper.json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.IntermediateRepresentation{}, errors.NewInputError(fmt.Sprintf("failed to read input directory '%s'", dir), err)
+	}
+	if len(paths) == 0 {
+		return models.IntermediateRepresentation{}, errors.NewInputError(fmt.Sprintf("no .json files found in '%s'", dir), errors.ErrNoInput)
+	}
+
+	ir := models.IntermediateRepresentation{RootIsArray: true}
+	values := make(models.JSONArray, 0, len(paths))
+	for _, path := range paths {
+		fileIR, err := parser.ParseFileWithOptions(path, parserOptions())
+		if err != nil {
+			return models.IntermediateRepresentation{}, errors.NewInputError(fmt.Sprintf("failed to parse '%s'", path), err)
+		}
+		values = append(values, fileIR.Root)
+		ir.DuplicateKeys = append(ir.DuplicateKeys, fileIR.DuplicateKeys...)
+		ir.NonStandardNumbers = append(ir.NonStandardNumbers, fileIR.NonStandardNumbers...)
+	}
+	ir.Root = values
+	return ir, nil
+}
+
+// loadSidecar loads the field directives of the sidecar file next to --input, such as
+// data.gotyper.json for data.json, into cfg. Inputs without one are left alone.
+func loadSidecar(cfg *config.Config) error {
+	if CLI.Input == "" {
+		return nil
+	}
+	path := config.SidecarPath(CLI.Input)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	directives, err := config.LoadSidecar(path)
+	if err != nil {
+		return errors.NewInputError("failed to load sidecar file", err)
+	}
+	cfg.FieldDirectives = directives
+	return nil
+}
+
+// parserOptions builds parser options from the CLI flags
+func parserOptions() parser.Options {
+	return parser.Options{
+		ErrorOnDuplicateKeys:    CLI.ErrorOnDuplicateKeys,
+		Filter:                  CLI.Filter,
+		AllowTrailingData:       CLI.AllowTrailingData,
+		AllowNonStandardNumbers: CLI.AllowNonStandardNumbers,
+	}
+}
+
+// writeWarningsJSON writes warnings to the --warnings-json file as a JSON array
+func writeWarningsJSON(warnings []models.Warning) error {
+	if warnings == nil {
+		warnings = []models.Warning{}
+	}
+	data, err := json.MarshalIndent(warnings, "", "  ")
+	if err != nil {
+		return errors.NewOutputError("failed to encode warnings as JSON", err)
+	}
+	if err := os.WriteFile(CLI.WarningsJSON, append(data, '\n'), 0o644); err != nil {
+		return errors.NewOutputError(fmt.Sprintf("failed to write warnings to '%s'", CLI.WarningsJSON), err)
+	}
+	return nil
+}
+
+// stdinMode describes how input should be read from stdin
+type stdinMode int
+
+const (
+	// stdinPiped reads all of stdin without prompting
+	stdinPiped stdinMode = iota
+	// stdinInteractive prompts the user and reads until EOF
+	stdinInteractive
+	// stdinUnavailable means stdin is a terminal and interactive mode was not requested
+	stdinUnavailable
+)
+
+// stdinIsTerminal reports whether stdin is attached to a terminal.
+// It is a variable so tests can simulate a TTY.
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// resolveStdinMode decides how stdin should be read. The --stdin and
+// --interactive flags override terminal detection, which can misfire in
+// some terminals and CI runners.
+func resolveStdinMode(isTerminal, forceStdin, interactive bool) (stdinMode, error) {
+	if forceStdin && interactive {
+		return stdinUnavailable, errors.NewInputError("cannot specify both --stdin and --interactive", nil)
+	}
+	if forceStdin {
+		return stdinPiped, nil
+	}
+	if interactive {
+		return stdinInteractive, nil
+	}
+	if isTerminal {
+		return stdinUnavailable, nil
+	}
+	return stdinPiped, nil
 }
 
 // writeOutput writes code to file or stdout
 func writeOutput(code string) error {
+	// Files and stdout get the same bytes, ending in exactly one newline
+	code = strings.TrimRight(code, " \t\r\n") + "\n"
+
 	if CLI.Output != "" {
 		// Write to file
 		err := os.WriteFile(CLI.Output, []byte(code), 0o644)
@@ -316,13 +1060,121 @@ func writeOutput(code string) error {
 	}
 
 	// Write to stdout
-	_, err := fmt.Println(strings.TrimSpace(code))
+	_, err := os.Stdout.WriteString(code)
 	if err != nil {
 		return errors.NewOutputError("failed to write to stdout", err)
 	}
 	return nil
 }
 
+// streamOutput writes the generated code to the --output file or stdout while it is
+// generated, producing the same bytes as writeOutput
+func streamOutput(generatorInst *generator.Generator, result models.AnalysisResult, packageName string) error {
+	out := os.Stdout
+	if CLI.Output != "" {
+		file, err := os.Create(CLI.Output)
+		if err != nil {
+			return errors.NewOutputError(fmt.Sprintf("failed to write to file '%s'", CLI.Output), err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	err := generatorInst.GenerateStructsTo(w, result, packageName)
+	if err == nil {
+		err = w.Flush()
+	}
+	if CLI.Output == "" {
+		if err != nil {
+			return errors.NewOutputError("failed to write to stdout", err)
+		}
+		return nil
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		return errors.NewOutputError(fmt.Sprintf("failed to write to file '%s'", CLI.Output), err)
+	}
+	fmt.Fprintf(os.Stderr, "Generated Go code written to %s\n", CLI.Output)
+	return nil
+}
+
+// writeFiles writes the files of --split-files into dir, creating it if needed. A pool
+// of workers formats and writes them concurrently. Every file is attempted, and any
+// failures are reported together in file name order.
+func writeFiles(dir string, files map[string]string, format bool) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.NewOutputError(fmt.Sprintf("failed to create directory '%s'", dir), err)
+	}
+
+	// Files matched by the directory's .gotyperignore, such as hand-written ones, are left alone
+	matcher, err := ignore.Load(dir)
+	if err != nil {
+		return errors.NewOutputError(fmt.Sprintf("failed to load the ignore file of '%s'", dir), err)
+	}
+	all := make([]string, 0, len(files))
+	for name := range files {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	names := make([]string, 0, len(all))
+	for _, name := range all {
+		if matcher.Match(name, false) {
+			fmt.Fprintf(os.Stderr, "Warning: not writing %s, which %s protects\n", name, ignore.FileName)
+			continue
+		}
+		names = append(names, name)
+	}
+
+	failures := make([]error, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(names)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				failures[i] = writeFile(filepath.Join(dir, names[i]), files[names[i]], format)
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var messages []string
+	var cause error
+	for i, err := range failures {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", names[i], err))
+			if cause == nil {
+				cause = err
+			}
+		}
+	}
+	if cause != nil {
+		return errors.NewOutputError(fmt.Sprintf("failed to write %d of %d files to '%s': %s", len(messages), len(names), dir, strings.Join(messages, "; ")), cause)
+	}
+	fmt.Fprintf(os.Stderr, "Generated Go code written to %d files in %s\n", len(names), dir)
+	return nil
+}
+
+// writeFile formats a generated file if requested and writes it, ending in one newline
+func writeFile(path, code string, format bool) error {
+	if format {
+		formatted, err := formatter.NewFormatter().Format(code)
+		if err != nil {
+			return fmt.Errorf("failed to format: %w", err)
+		}
+		code = formatted
+	}
+	return os.WriteFile(path, []byte(strings.TrimRight(code, " \t\r\n")+"\n"), 0o644)
+}
+
 // readInteractiveInput provides an interactive mode for users to paste JSON
 // and signal completion with Ctrl+D (EOF)
 func readInteractiveInput() (models.IntermediateRepresentation, error) {
@@ -351,7 +1203,7 @@ func readInteractiveInput() (models.IntermediateRepresentation, error) {
 	}
 
 	fmt.Fprintln(os.Stderr, "\nProcessing JSON...")
-	return parser.ParseString(jsonData)
+	return parser.ParseStringWithOptions(jsonData, parserOptions())
 }
 
 // fetchFromURL fetches JSON from a URL and parses it
@@ -363,6 +1215,17 @@ func fetchFromURL(urlStr string) (models.IntermediateRepresentation, error) {
 			fmt.Sprintf("invalid URL scheme: %s (must be http:// or https://)", urlStr), nil)
 	}
 
+	var responseCache *cache.Cache
+	if CLI.CacheDir != "" && !CLI.NoCache {
+		responseCache = cache.New(CLI.CacheDir, CLI.CacheTTL)
+		if body, ok := responseCache.Get(urlStr); ok {
+			if CLI.Debug {
+				fmt.Fprintf(os.Stderr, "Using cached response for %s\n", urlStr)
+			}
+			return parser.ParseStringWithOptions(string(body), parserOptions())
+		}
+	}
+
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -405,5 +1268,16 @@ func fetchFromURL(urlStr string) (models.IntermediateRepresentation, error) {
 			fmt.Sprintf("empty response from URL: %s", urlStr), errors.ErrEmptyInput)
 	}
 
-	return parser.ParseString(string(body))
+	ir, err := parser.ParseStringWithOptions(string(body), parserOptions())
+	if err != nil {
+		return ir, err
+	}
+
+	// Only cache bodies that parsed, and treat a failed write as a cache miss next time
+	if responseCache != nil {
+		if err := responseCache.Put(urlStr, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache response for %s: %v\n", urlStr, err)
+		}
+	}
+	return ir, nil
 }
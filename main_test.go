@@ -1,12 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alecthomas/kong"
 	"github.com/mcncl/gotyper/internal/config"
+	"github.com/mcncl/gotyper/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRun_SimpleJSON(t *testing.T) {
@@ -231,10 +244,10 @@ func TestWriteOutput_ToFile(t *testing.T) {
 	err = writeOutput(testCode)
 	require.NoError(t, err)
 
-	// Verify content was written
+	// Verify content was written, ending in a single newline
 	content, err := os.ReadFile(tmpFile.Name())
 	require.NoError(t, err)
-	assert.Equal(t, testCode, string(content))
+	assert.Equal(t, testCode+"\n", string(content))
 }
 
 func TestWriteOutput_ToStdout(t *testing.T) {
@@ -478,3 +491,956 @@ func TestParseInput_ValidURLSchemes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInput_URLCache(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+
+	CLI.Input = ""
+	CLI.URL = server.URL
+	CLI.CacheDir = t.TempDir()
+	CLI.CacheTTL = time.Hour
+
+	for i := 0; i < 2; i++ {
+		ir, err := parseInput()
+		require.NoError(t, err)
+		assert.Equal(t, models.JSONObject{"id": json.Number("1")}, ir.Root)
+	}
+	assert.Equal(t, 1, hits, "second fetch should be served from the cache")
+
+	CLI.NoCache = true
+	_, err := parseInput()
+	require.NoError(t, err)
+	assert.Equal(t, 2, hits, "--no-cache should bypass the cache")
+
+	// Entries older than the TTL are refetched
+	CLI.NoCache = false
+	CLI.CacheTTL = 0
+	_, err = parseInput()
+	require.NoError(t, err)
+	assert.Equal(t, 3, hits)
+}
+
+func TestResolveStdinMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		isTerminal  bool
+		forceStdin  bool
+		interactive bool
+		expected    stdinMode
+		expectError bool
+	}{
+		{"piped input", false, false, false, stdinPiped, false},
+		{"terminal without interactive", true, false, false, stdinUnavailable, false},
+		{"terminal with interactive", true, false, true, stdinInteractive, false},
+		{"force stdin on misdetected terminal", true, true, false, stdinPiped, false},
+		{"force interactive on misdetected pipe", false, false, true, stdinInteractive, false},
+		{"conflicting overrides", false, true, true, stdinUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := resolveStdinMode(tt.isTerminal, tt.forceStdin, tt.interactive)
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "--stdin")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mode)
+		})
+	}
+}
+
+func TestParseInput_ForceStdinOverridesTerminalDetection(t *testing.T) {
+	// Save original CLI state, stdin and terminal detection
+	originalCLI := CLI
+	originalStdin := os.Stdin
+	originalIsTerminal := stdinIsTerminal
+	defer func() {
+		CLI = originalCLI
+		os.Stdin = originalStdin
+		stdinIsTerminal = originalIsTerminal
+	}()
+
+	// Simulate a terminal that misreports piped input as a TTY
+	stdinIsTerminal = func() bool { return true }
+	CLI.Input = ""
+	CLI.URL = ""
+	CLI.Interactive = false
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	go func() {
+		defer func() { _ = w.Close() }()
+		_, _ = w.WriteString(`{"name": "piped"}`)
+	}()
+	os.Stdin = r
+	defer func() { _ = r.Close() }()
+
+	// Without the override, detection reports no input
+	_, err = parseInput()
+	require.Error(t, err)
+
+	// With --stdin, the pipe is read regardless of detection
+	CLI.Stdin = true
+	ir, err := parseInput()
+	require.NoError(t, err)
+	assert.NotNil(t, ir.Root)
+}
+
+func TestAttachInterfaceStubs(t *testing.T) {
+	dir := t.TempDir()
+	ifacePath := filepath.Join(dir, "iface.go")
+	src := "package p\ntype Namer interface{ Name() string }\ntype Closer interface{ Close() error }\n"
+	require.NoError(t, os.WriteFile(ifacePath, []byte(src), 0o644))
+
+	newResult := func() models.AnalysisResult {
+		return models.AnalysisResult{
+			Structs: []models.StructDef{{Name: "Item"}, {Name: "RootType", IsRoot: true}},
+		}
+	}
+
+	// file:Name selects one of several interfaces
+	result := newResult()
+	require.NoError(t, attachInterfaceStubs(&result, ifacePath+":Closer"))
+	assert.Empty(t, result.Structs[0].Methods)
+	assert.Equal(t, []models.MethodDef{{Name: "Close", Results: "error"}}, result.Structs[1].Methods)
+
+	// Without a name the file must declare exactly one interface
+	result = newResult()
+	err := attachInterfaceStubs(&result, ifacePath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "declares 2 interfaces")
+
+	// Root arrays have no root struct to attach to
+	result = models.AnalysisResult{Structs: []models.StructDef{{Name: "Item"}}}
+	err = attachInterfaceStubs(&result, ifacePath+":Namer")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a root struct")
+}
+
+func TestRun_OutputFormatJSON(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.json")
+	outputPath := filepath.Join(dir, "analysis.json")
+	jsonData := `{"id": 1, "created_at": "2024-01-15T10:30:00Z", "profile": {"name": "Ann"}}`
+	require.NoError(t, os.WriteFile(inputPath, []byte(jsonData), 0o644))
+
+	CLI.Input = inputPath
+	CLI.Output = outputPath
+	CLI.OutputFormat = "json"
+
+	cfg := config.NewConfig()
+	cfg.RootName = "User"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "package ")
+
+	var analysis struct {
+		Structs []struct {
+			Name   string `json:"name"`
+			IsRoot bool   `json:"is_root"`
+			Fields []struct {
+				JSONKey string `json:"json_key"`
+				GoName  string `json:"go_name"`
+				GoType  struct {
+					Kind string `json:"kind"`
+					Name string `json:"name"`
+				} `json:"go_type"`
+			} `json:"fields"`
+		} `json:"structs"`
+		Imports []string `json:"imports"`
+	}
+	require.NoError(t, json.Unmarshal(data, &analysis))
+
+	assert.Equal(t, []string{"time"}, analysis.Imports)
+
+	structsByName := make(map[string]int)
+	for i, s := range analysis.Structs {
+		structsByName[s.Name] = i
+	}
+	require.Contains(t, structsByName, "User")
+	require.Contains(t, structsByName, "UserProfile")
+
+	user := analysis.Structs[structsByName["User"]]
+	assert.True(t, user.IsRoot)
+	fieldTypes := make(map[string]string)
+	for _, field := range user.Fields {
+		fieldTypes[field.JSONKey] = field.GoType.Name
+	}
+	assert.Equal(t, map[string]string{
+		"created_at": "time.Time",
+		"id":         "int64",
+		"profile":    "UserProfile",
+	}, fieldTypes)
+}
+
+func TestRun_EmbedGoGenerate(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "testdata", "user data.json")
+	outputPath := filepath.Join(dir, "models", "user.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(inputPath), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(outputPath), 0o755))
+	require.NoError(t, os.WriteFile(inputPath, []byte(`{"id": 1}`), 0o644))
+
+	CLI.Input = inputPath
+	CLI.Output = outputPath
+	CLI.Format = true
+
+	cfg := config.NewConfig()
+	cfg.Package = "models"
+	cfg.RootName = "User"
+	cfg.Output.EmbedGoGenerate = true
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	// Paths are relative to the generated file and quoted when they contain spaces
+	expected := `//go:generate gotyper -i "../testdata/user data.json" -o user.go -p models -r User`
+	assert.Contains(t, string(data), "package models\n\n"+expected+"\n")
+}
+
+func TestGoGenerateArgs(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	cfg := config.NewConfig()
+	ctx := &Context{Config: cfg, ConfigPath: filepath.Join("configs", ".gotyper.yml")}
+
+	// Piped input can't be reproduced
+	CLI.Input = ""
+	_, ok := goGenerateArgs(ctx)
+	assert.False(t, ok)
+
+	CLI.URL = "https://example.com/users/1"
+	CLI.Format = false
+	CLI.ErrorOnDuplicateKeys = true
+	CLI.Implement = filepath.Join("api", "store.go") + ":Store"
+	args, ok := goGenerateArgs(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{
+		"gotyper", "-u", "https://example.com/users/1",
+		"-p", "main",
+		"-c", filepath.Join("configs", ".gotyper.yml"),
+		"--format=false", "--error-on-duplicate-keys",
+		"--implement", filepath.Join("api", "store.go") + ":Store",
+	}, args)
+
+	// Re-running the directive must add to the file rather than replace what's already in it
+	CLI = originalCLI
+	CLI.Input = "user.json"
+	CLI.Output = "user.go"
+	CLI.Format = true
+	CLI.Append = true
+	args, ok = goGenerateArgs(&Context{Config: cfg})
+	require.True(t, ok)
+	assert.Equal(t, []string{"gotyper", "-i", "user.json", "-o", "user.go", "--append", "-p", "main"}, args)
+
+	CLI = originalCLI
+	CLI.Input = "user.json"
+	CLI.Output = "user.go"
+	CLI.Format = true
+	CLI.TypesOnly = true
+	args, ok = goGenerateArgs(&Context{Config: cfg})
+	require.True(t, ok)
+	assert.Equal(t, []string{"gotyper", "-i", "user.json", "-o", "user.go", "--types-only", "-p", "main"}, args)
+
+	// The arguments parse back to the same flags
+	CLI = originalCLI
+	parser, err := kong.New(&CLI)
+	require.NoError(t, err)
+	_, err = parser.Parse(args[1:])
+	require.NoError(t, err)
+	assert.True(t, CLI.TypesOnly)
+	assert.False(t, CLI.Append)
+	assert.Equal(t, "user.go", filepath.Base(CLI.Output))
+}
+
+func TestRun_EmitReport(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.json")
+	jsonData := `[{"zone": "eu", "id": 1, "tags": ["a"], "owner": {"name": "Ann", "email": "ann@example.com"}, "meta": {"b": 1, "a": 2, "c": 3, "d": 4}},
+		{"id": 2, "tags": [], "owner": {"name": "Bob"}, "meta": {"d": 1, "c": 2, "b": 3, "a": 4}}]`
+	require.NoError(t, os.WriteFile(inputPath, []byte(jsonData), 0o644))
+
+	CLI.Input = inputPath
+	CLI.Emit = "report"
+
+	// Repeated runs must give byte-identical reports for diffing in CI
+	var reports []string
+	for i := 0; i < 5; i++ {
+		CLI.Output = filepath.Join(dir, fmt.Sprintf("report%d.json", i))
+		cfg := config.NewConfig()
+		cfg.RootName = "Account"
+		require.NoError(t, run(&Context{Config: cfg}))
+		data, err := os.ReadFile(CLI.Output)
+		require.NoError(t, err)
+		reports = append(reports, string(data))
+	}
+	for _, report := range reports[1:] {
+		assert.Equal(t, reports[0], report)
+	}
+
+	var report struct {
+		Structs []struct {
+			Name   string `json:"name"`
+			Fields []struct {
+				Name     string `json:"name"`
+				Type     string `json:"type"`
+				Optional bool   `json:"optional"`
+			} `json:"fields"`
+		} `json:"structs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(reports[0]), &report))
+	require.Len(t, report.Structs, 3)
+	assert.Equal(t, "Account", report.Structs[0].Name)
+	var keys []string
+	for _, field := range report.Structs[0].Fields {
+		keys = append(keys, field.Name)
+	}
+	assert.Equal(t, []string{"id", "meta", "owner", "tags", "zone"}, keys)
+	assert.Equal(t, "int64", report.Structs[0].Fields[0].Type)
+	assert.False(t, report.Structs[0].Fields[0].Optional)
+	assert.Equal(t, "[]string", report.Structs[0].Fields[3].Type)
+	assert.True(t, report.Structs[0].Fields[4].Optional)
+	assert.NotContains(t, reports[0], "package ")
+}
+
+func TestRun_OutputFormatTypeScript(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "person.schema.json")
+	outputPath := filepath.Join(dir, "person.d.ts")
+	schemaData := `{
+		"type": "object",
+		"description": "A person in the directory.\nNames are stored as entered.",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string", "description": "Full name"},
+			"role": {"enum": ["admin", "user"], "description": "Access role"},
+			"home-address": {
+				"type": "object",
+				"description": "Postal address",
+				"properties": {"city": {"type": "string"}}
+			}
+		}
+	}`
+	require.NoError(t, os.WriteFile(schemaPath, []byte(schemaData), 0o644))
+
+	CLI.Schema = schemaPath
+	CLI.Output = outputPath
+	CLI.OutputFormat = "ts"
+
+	cfg := config.NewConfig()
+	cfg.RootName = "Person"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by gotyper. DO NOT EDIT.
+
+/** Access role */
+export type PersonRole = "admin" | "user";
+
+/**
+ * A person in the directory.
+ * Names are stored as entered.
+ */
+export interface Person {
+  /** Postal address */
+  "home-address"?: PersonHomeAddress;
+  /** Full name */
+  name: string;
+  /** Access role */
+  role?: PersonRole;
+}
+
+/** Postal address */
+export interface PersonHomeAddress {
+  city?: string;
+}
+`, string(data))
+}
+
+func TestRun_FromTS(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	tsPath := filepath.Join(dir, "user.ts")
+	outputPath := filepath.Join(dir, "user.go")
+	require.NoError(t, os.WriteFile(tsPath, []byte(`export interface User {
+  name: string;
+  team?: Team;
+}
+
+export interface Team {
+  slug: string | null;
+}
+`), 0o644))
+
+	CLI.FromTS = tsPath
+	CLI.Output = outputPath
+
+	cfg := config.NewConfig()
+	cfg.Package = "models"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, `package models
+
+type Team struct {
+	Slug *string `+"`json:\"slug,omitempty\"`"+`
+}
+
+type User struct {
+	Name string `+"`json:\"name\"`"+`
+	Team *Team  `+"`json:\"team,omitempty\"`"+`
+}
+`, string(data))
+
+	CLI.Input = filepath.Join(dir, "input.json")
+	err = run(&Context{Config: cfg})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--from-ts")
+}
+
+func TestRun_OutputFormatJSONIndent(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.json")
+	require.NoError(t, os.WriteFile(inputPath, []byte(`{"id": 1, "profile": {"name": "Ann"}}`), 0o644))
+
+	render := func(indent int, compact bool) string {
+		CLI.Input = inputPath
+		CLI.Output = filepath.Join(dir, "analysis.json")
+		CLI.OutputFormat = "json"
+		CLI.JSONIndent = indent
+		CLI.JSONCompact = compact
+		require.NoError(t, run(&Context{Config: config.NewConfig()}))
+		data, err := os.ReadFile(CLI.Output)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	indented := render(2, false)
+	wide := render(4, false)
+	compact := render(4, true)
+
+	assert.Contains(t, indented, "\n  \"structs\": [")
+	assert.Contains(t, wide, "\n    \"structs\": [")
+	assert.NotContains(t, strings.TrimSuffix(compact, "\n"), "\n", "compact output is a single line")
+	assert.Less(t, len(compact), len(indented))
+	assert.Less(t, len(indented), len(wide))
+
+	// Only the layout differs
+	var fromIndented, fromCompact any
+	require.NoError(t, json.Unmarshal([]byte(indented), &fromIndented))
+	require.NoError(t, json.Unmarshal([]byte(compact), &fromCompact))
+	assert.Equal(t, fromIndented, fromCompact)
+
+	CLI.JSONIndent = -1
+	_, err := createContext()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--json-indent")
+}
+
+func TestCreateContext_RootName(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	CLI.RootName = "2cool"
+	ctx, err := createContext()
+	require.NoError(t, err)
+	assert.Equal(t, "Type2cool", ctx.Config.RootName)
+
+	CLI.RootName = "--"
+	_, err = createContext()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "root name")
+}
+
+func TestPrintConfig(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".gotyper.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("package: fromfile\noutput:\n  struct_order: declaration\n"), 0o644))
+
+	parser, err := kong.New(&CLI)
+	require.NoError(t, err)
+	_, err = parser.Parse([]string{"--print-config", "-c", configPath, "--struct-order", "name"})
+	require.NoError(t, err)
+	assert.True(t, CLI.PrintConfig)
+
+	ctx, err := createContext()
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, printConfig(&out, ctx.Config))
+
+	printed := out.String()
+	assert.Contains(t, printed, "package: fromfile\n")
+	assert.Contains(t, printed, "struct_order: name\n", "the flag overrides the config file")
+	assert.Contains(t, printed, "optional_as_pointers: true\n", "defaults are included")
+
+	// The output is itself a valid config file
+	var loaded config.Config
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &loaded))
+	assert.Equal(t, "fromfile", loaded.Package)
+	assert.Equal(t, "name", loaded.Output.StructOrder)
+}
+
+func TestRun_WarningsJSON(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	CLI.Output = filepath.Join(dir, "output.go")
+	CLI.WarningsJSON = filepath.Join(dir, "warnings.json")
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"order": {"items": [1, "two"]}}`), 0o644))
+
+	cfg := config.NewConfig()
+	cfg.Package = "test"
+	cfg.RootName = "Root"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	data, err := os.ReadFile(CLI.WarningsJSON)
+	require.NoError(t, err)
+
+	// Decode loosely so the test pins the field names, not just the Go type
+	var warnings []map[string]string
+	require.NoError(t, json.Unmarshal(data, &warnings))
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "heterogeneous_array", warnings[0]["type"])
+	assert.Equal(t, "order.items", warnings[0]["path"])
+	assert.Contains(t, warnings[0]["message"], "[]interface{}")
+	assert.Len(t, warnings[0], 3)
+
+	// A clean run still writes the file, as an empty array
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": 1}`), 0o644))
+	require.NoError(t, run(&Context{Config: cfg}))
+	data, err = os.ReadFile(CLI.WarningsJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(data))
+}
+
+func TestRun_AllowNonStandardNumbers(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	CLI.Output = filepath.Join(dir, "output.go")
+	CLI.WarningsJSON = filepath.Join(dir, "warnings.json")
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"x": NaN}`), 0o644))
+
+	cfg := config.NewConfig()
+	cfg.Package = "test"
+	cfg.RootName = "Root"
+	err := run(&Context{Config: cfg})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NaN and Infinity")
+
+	CLI.AllowNonStandardNumbers = true
+	require.NoError(t, run(&Context{Config: cfg}))
+	code, err := os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+	assert.Contains(t, string(code), "X float64 `json:\"x\"`")
+
+	data, err := os.ReadFile(CLI.WarningsJSON)
+	require.NoError(t, err)
+	var warnings []map[string]string
+	require.NoError(t, json.Unmarshal(data, &warnings))
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "non_standard_number", warnings[0]["type"])
+	assert.Equal(t, "x", warnings[0]["path"])
+}
+
+func TestRun_StdoutMatchesOutputFile(t *testing.T) {
+	originalCLI := CLI
+	originalStdout := os.Stdout
+	defer func() {
+		CLI = originalCLI
+		os.Stdout = originalStdout
+	}()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": 1, "tags": ["a"], "owner": {"name": "ann"}}`), 0o644))
+	cfg := config.NewConfig()
+	cfg.Package = "test"
+	cfg.RootName = "Root"
+
+	// Capture stdout through a pipe, reading concurrently so large output can't block
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	captured := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		captured <- data
+	}()
+	os.Stdout = w
+	CLI.Output = ""
+	err = run(&Context{Config: cfg})
+	_ = w.Close()
+	os.Stdout = originalStdout
+	require.NoError(t, err)
+	stdout := <-captured
+
+	CLI.Output = filepath.Join(dir, "output.go")
+	require.NoError(t, run(&Context{Config: cfg}))
+	file, err := os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(file), string(stdout))
+	assert.True(t, strings.HasSuffix(string(file), "}\n"), "ends in exactly one newline")
+}
+
+func TestRun_MultiMergesNewlineSeparatedValues(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "events.jsonl")
+	output := filepath.Join(dir, "event.go")
+	require.NoError(t, os.WriteFile(input, []byte(`{"id": 1}
+{"id": 2, "ok": true}
+{"id": 3, "name": "deploy"}
+`), 0o644))
+
+	// Parse real arguments so the --multi alias itself is covered
+	parser, err := kong.New(&CLI)
+	require.NoError(t, err)
+	_, err = parser.Parse([]string{"--multi", "-i", input, "-o", output, "-r", "Event", "-p", "events"})
+	require.NoError(t, err)
+	assert.True(t, CLI.AllowTrailingData)
+
+	ctx, err := createContext()
+	require.NoError(t, err)
+	require.NoError(t, run(ctx))
+
+	code, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(code), " struct {"), string(code))
+	assert.Contains(t, string(code), "type Event struct")
+	assert.Contains(t, string(code), "`json:\"ok\"`")
+	assert.Contains(t, string(code), "`json:\"name\"`")
+}
+
+func TestRun_SchemaRootNameFromID(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{
+  "$id": "https://example.com/schemas/customer-order.json",
+  "type": "object",
+  "properties": {"id": {"type": "integer"}}
+}`), 0o644))
+
+	generate := func(args ...string) string {
+		CLI = originalCLI
+		output := filepath.Join(dir, "order.go")
+		parser, err := kong.New(&CLI)
+		require.NoError(t, err)
+		_, err = parser.Parse(append([]string{"-s", schemaPath, "-o", output}, args...))
+		require.NoError(t, err)
+		ctx, err := createContext()
+		require.NoError(t, err)
+		require.NoError(t, run(ctx))
+		code, err := os.ReadFile(output)
+		require.NoError(t, err)
+		return string(code)
+	}
+
+	// Without -r the schema's $id names the root
+	assert.Contains(t, generate(), "type CustomerOrder struct")
+	// -r still wins, even when it names the default
+	assert.Contains(t, generate("-r", "Invoice"), "type Invoice struct")
+	assert.Contains(t, generate("-r", "RootType"), "type RootType struct")
+}
+
+func TestRun_TypesOnly(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	CLI.Output = filepath.Join(dir, "types.go")
+	CLI.Format = true
+	CLI.TypesOnly = true
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": 1, "created_at": "2024-01-02T03:04:05Z", "owner": {"name": "ann"}}`), 0o644))
+	cfg := config.NewConfig()
+	cfg.Package = "models"
+	cfg.RootName = "Account"
+	cfg.Output.FileHeader = "// Code generated by gotyper. DO NOT EDIT."
+
+	require.NoError(t, run(&Context{Config: cfg}))
+	code, err := os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+
+	for _, line := range strings.Split(string(code), "\n") {
+		assert.False(t, strings.HasPrefix(line, "package "), line)
+		assert.False(t, strings.HasPrefix(line, "import"), line)
+	}
+	assert.NotContains(t, string(code), "DO NOT EDIT")
+	assert.True(t, strings.HasPrefix(string(code), "type Account struct {\n"), string(code))
+	assert.Contains(t, string(code), "\tCreatedAt time.Time ")
+	assert.Contains(t, string(code), "type AccountOwner struct {")
+
+	// The fragment is valid Go once given a package clause and the imports
+	_, err = format.Source(append([]byte("package models\n\nimport \"time\"\n\n"), code...))
+	assert.NoError(t, err)
+}
+
+func TestRun_Sidecar(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "account.json")
+	CLI.Output = filepath.Join(dir, "account.go")
+	CLI.Format = true
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{
+		"user": {"address": {"zip": "0150", "city": "Oslo"}, "balance": "12.50", "debug": {"trace": true}},
+		"items": [{"price": "1.00"}, {"price": null}]
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "account.gotyper.json"), []byte(`{
+		"fields": {
+			"user.address.zip": {"rename": "PostalCode", "comment": "Norwegian postcode"},
+			"user.balance": {"type": "decimal.Decimal", "import": "github.com/shopspring/decimal"},
+			"user.debug": {"skip": true},
+			"items.price": {"type": "decimal.Decimal", "import": "github.com/shopspring/decimal"}
+		}
+	}`), 0o644))
+	cfg := config.NewConfig()
+	cfg.RootName = "Account"
+	// The sidecar wins over the config file's patterns
+	cfg.Naming.FieldMappings = map[string]string{"zip": "Zip"}
+
+	require.NoError(t, run(&Context{Config: cfg}))
+	code, err := os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(code), `"github.com/shopspring/decimal"`)
+	assert.Regexp(t, `PostalCode\s+string\s+`+"`json:\"zip\"`"+`\s+// Norwegian postcode`, string(code))
+	assert.Regexp(t, `Balance\s+decimal\.Decimal\s+`+"`json:\"balance\"`", string(code))
+	assert.Regexp(t, `Price\s+\*decimal\.Decimal\s+`+"`json:\"price,omitempty\"`", string(code))
+	assert.NotContains(t, string(code), "Debug")
+	assert.NotContains(t, string(code), "Zip ")
+}
+
+func TestRun_PreserveCommentsFrom(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "user.json")
+	CLI.Output = filepath.Join(dir, "user.go")
+	CLI.Format = true
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": 1, "name": "Ann"}`), 0o644))
+	cfg := config.NewConfig()
+	cfg.RootName = "User"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	// Document the previous output by hand
+	code, err := os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+	edited := strings.Replace(string(code), "type User struct {", "// User is an account holder.\ntype User struct {\n\t// ID is assigned by the billing system.", 1)
+	edited = strings.Replace(edited, "`json:\"name\"`", "`json:\"name\"` // Display name", 1)
+	require.NoError(t, os.WriteFile(CLI.Output, []byte(edited), 0o644))
+
+	// The id changes type and a field is added; the comments stay
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": "u-1", "name": "Ann", "email": "a@example.com"}`), 0o644))
+	CLI.PreserveCommentsFrom = CLI.Output
+	require.NoError(t, run(&Context{Config: cfg}))
+	code, err = os.ReadFile(CLI.Output)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(code), "// User is an account holder.\ntype User struct {")
+	assert.Regexp(t, `// ID is assigned by the billing system\.\n\s+Id\s+string\s+`, string(code))
+	assert.Regexp(t, "Name +string +`json:\"name\"` // Display name", string(code))
+	assert.Regexp(t, "Email +string +`json:\"email\"`\n", string(code))
+}
+
+func TestRun_SidecarUnknownDirective(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(`{"id": 1}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "input.gotyper.json"), []byte(`{"fields": {"id": {"renam": "ID"}}}`), 0o644))
+
+	err := run(&Context{Config: config.NewConfig()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "renam"`)
+}
+
+func TestRun_InputDirGotyperignore(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	dir := t.TempDir()
+	samples := filepath.Join(dir, "samples")
+	require.NoError(t, os.MkdirAll(filepath.Join(samples, "fixtures"), 0o755))
+	for name, content := range map[string]string{
+		"a.json":              `{"id": 1, "name": "Ann", "address": {"city": "Oslo"}}`,
+		"nested/b.json":       `{"id": 2}`,
+		"draft.json":          `{"id": 3, "secret": true}`,
+		"fixtures/huge.json":  `{"id": 4, "debug": {"trace": []}}`,
+		"notes.txt":           `not JSON`,
+		".gotyperignore":      "# Not real responses\nfixtures/\ndraft.json\n",
+		"a.gotyper.json":      `{"name": {"rename": "FullName"}}`,
+		"nested/unused.json~": `{`,
+	} {
+		path := filepath.Join(samples, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	// A hand-written file in the output directory is protected from --split-files
+	output := filepath.Join(dir, "models")
+	require.NoError(t, os.MkdirAll(output, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(output, ".gotyperignore"), []byte("user_address.go\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "user_address.go"), []byte("// hand-written\n"), 0o644))
+
+	CLI.InputDir = samples
+	CLI.Output = output
+	CLI.SplitFiles = true
+	cfg := config.NewConfig()
+	cfg.Package = "models"
+	cfg.RootName = "User"
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	user, err := os.ReadFile(filepath.Join(output, "user.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(user), "Name    string       `json:\"name\"`", "the sidecar is not a sample")
+	assert.Contains(t, string(user), "Address *UserAddress", "b.json has no address")
+	assert.NotContains(t, string(user), "secret", "draft.json is ignored")
+	assert.NotContains(t, string(user), "debug", "the fixtures directory is ignored")
+
+	address, err := os.ReadFile(filepath.Join(output, "user_address.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "// hand-written\n", string(address))
+
+	CLI.Input = filepath.Join(samples, "a.json")
+	err = run(&Context{Config: cfg})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--input-dir")
+}
+
+func TestRun_SplitFiles(t *testing.T) {
+	originalCLI := CLI
+	defer func() { CLI = originalCLI }()
+
+	// 50 nested objects: the even ones hold a timestamp, the odd ones only a number
+	var input strings.Builder
+	input.WriteString("{")
+	for i := range 50 {
+		if i > 0 {
+			input.WriteString(",")
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(&input, `"item_%02d": {"at%d": "2024-01-02T03:04:05Z"}`, i, i)
+		} else {
+			fmt.Fprintf(&input, `"item_%02d": {"n%d": %d}`, i, i, i)
+		}
+	}
+	input.WriteString("}")
+
+	dir := t.TempDir()
+	CLI.Input = filepath.Join(dir, "input.json")
+	CLI.Output = filepath.Join(dir, "models")
+	CLI.Format = true
+	CLI.SplitFiles = true
+	require.NoError(t, os.WriteFile(CLI.Input, []byte(input.String()), 0o644))
+	cfg := config.NewConfig()
+	cfg.Package = "models"
+	cfg.RootName = "Catalog"
+
+	require.NoError(t, run(&Context{Config: cfg}))
+
+	entries, err := os.ReadDir(CLI.Output)
+	require.NoError(t, err)
+	assert.Len(t, entries, 51) // the root and its 50 nested structs
+
+	root, err := os.ReadFile(filepath.Join(CLI.Output, "catalog.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(root), "type Catalog struct {")
+	assert.NotContains(t, string(root), "import")
+
+	for i := range 50 {
+		name := fmt.Sprintf("CatalogItem%02d", i)
+		path := filepath.Join(CLI.Output, fmt.Sprintf("catalog_item_%02d.go", i))
+		code, err := os.ReadFile(path)
+		require.NoError(t, err, path)
+
+		assert.True(t, strings.HasPrefix(string(code), "package models\n"), path)
+		assert.Contains(t, string(code), "type "+name+" struct {", path)
+		assert.Equal(t, 1, strings.Count(string(code), " struct {"), path)
+		_, err = format.Source(code)
+		assert.NoError(t, err, path)
+		if i%2 == 0 {
+			assert.Contains(t, string(code), "import (\n\t\"time\"\n)", path)
+		} else {
+			assert.NotContains(t, string(code), "import", path)
+		}
+	}
+}
+
+func TestWriteFiles_ReportsEveryFailure(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for i := range 20 {
+		files[fmt.Sprintf("t%02d.go", i)] = fmt.Sprintf("package p\n\ntype T%02d struct{}\n", i)
+	}
+	// Directories in the way of two of the files make writing them fail
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "t15.go"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "t03.go"), 0o755))
+
+	err := writeFiles(dir, files, true)
+	require.Error(t, err)
+	message := err.Error()
+	assert.Contains(t, message, "failed to write 2 of 20 files")
+	assert.Less(t, strings.Index(message, "t03.go:"), strings.Index(message, "t15.go:"), message)
+
+	// The other files are written all the same
+	for name, code := range files {
+		if name == "t03.go" || name == "t15.go" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, code, string(data))
+	}
+}